@@ -6,7 +6,6 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/spf13/viper"
@@ -19,87 +18,212 @@ import (
 
 const (
 	packageName = "awsd"
+
+	// volumeBatchSize is the number of volume IDs sent in one DescribeVolumes filter
+	volumeBatchSize = 200
 )
 
 type AWSClient struct {
 	client EC2API
 }
 
-// NewAWSClient creates a new AWS client
+// NewAWSClient creates a new AWS client for the configured AWS_REGION
 func NewAWSClient(conf *configuration.Config) (*AWSClient, error) {
+	if conf == nil {
+		return nil, fmt.Errorf("configuration cannot be nil")
+	}
+	return NewAWSClientForRegion(conf, conf.AWSRegion, nil)
+}
+
+// NewAWSClientForRegion creates a new AWS client for one of the scanned regions of an
+// account. creds is nil for the static keys of the configuration.
+func NewAWSClientForRegion(conf *configuration.Config, region string, creds aws.CredentialsProvider) (*AWSClient, error) {
 	logger := zap.L().With(
 		zap.String("package", packageName),
-		zap.String("function", "NewAWSClient"),
+		zap.String("function", "NewAWSClientForRegion"),
+		zap.String("region", region),
 	)
 
+	cfg, err := loadAWSConfig(conf, region, creds)
+	if err != nil {
+		logger.Error("Failed to create AWS client",
+			zap.String("operation", "client_creation"),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	logger.Info("AWS client created successfully")
+	return &AWSClient{
+		client: &retryingEC2{api: ec2.NewFromConfig(cfg), retrier: newRetrier(conf)},
+	}, nil
+}
+
+// loadAWSConfig validates the configuration and builds the AWS SDK config shared by
+// the service clients of a region, pointed at LOCALSTACK_URL. creds is nil for the
+// static keys of the configuration. The SDK's retryer is disabled, as the clients
+// retry through retryCall.
+func loadAWSConfig(conf *configuration.Config, region string, creds aws.CredentialsProvider) (aws.Config, error) {
 	// Validate configuration
 	if conf == nil {
-		return nil, fmt.Errorf("configuration cannot be nil")
+		return aws.Config{}, fmt.Errorf("configuration cannot be nil")
 	}
 
-	if conf.AWSRegion == "" {
-		return nil, fmt.Errorf("AWS region cannot be empty")
+	if region == "" {
+		return aws.Config{}, fmt.Errorf("AWS region cannot be empty")
 	}
 
-	if conf.AccessSecret == "" || conf.AcessKeyID == "" {
-		return nil, fmt.Errorf("AWS credentials cannot be empty")
+	if creds == nil {
+		static, err := staticCredentials(conf)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		creds = static
 	}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(conf.AWSRegion),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(conf.AccessSecret, conf.AcessKeyID, "")),
+	return config.LoadDefaultConfig(context.TODO(),
+		config.WithRegion(region),
+		config.WithCredentialsProvider(creds),
+		config.WithRetryer(func() aws.Retryer {
+			return aws.NopRetryer{}
+		}),
 		config.WithEndpointResolver(aws.EndpointResolverFunc(
 			func(service, region string) (aws.Endpoint, error) {
 				return aws.Endpoint{URL: viper.GetString("LOCALSTACK_URL"), SigningRegion: region}, nil
 			}),
 		),
 	)
-	if err != nil {
-		logger.Error("Failed to create AWS client",
-			zap.String("operation", "client_creation"),
-			zap.Error(err),
-		)
+}
+
+// GetAWSInstances fetches every EC2 instance visible to the client, following
+// NextToken until all reservations have been read
+func (c *AWSClient) GetAWSInstances(ctx context.Context) ([]*models.AWSInstance, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetAWSInstances"),
+	)
+
+	instances := make([]*models.AWSInstance, 0)
+	var nextToken *string
+	for {
+		output, err := c.client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSInstance, "failed to describe instances",
+				map[string]interface{}{
+					"operation": "describe_instances",
+				}, err)
+		}
+
+		for _, reservation := range output.Reservations {
+			for _, i := range reservation.Instances {
+				if i.InstanceId == nil {
+					continue
+				}
+				instances = append(instances, parseInstance(i))
+			}
+		}
+
+		if aws.ToString(output.NextToken) == "" {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	if err := c.enrichBlockDevices(ctx, instances); err != nil {
 		return nil, err
 	}
 
-	logger.Info("AWS client created successfully")
-	return &AWSClient{
-		client: ec2.NewFromConfig(cfg),
-	}, nil
+	logger.Info("AWS instances fetched successfully",
+		zap.Int("instance_count", len(instances)),
+	)
+	return instances, nil
 }
 
-// GetAWSInstance fetches AWS EC2 instance details
-func (c *AWSClient) GetAWSInstance() (*models.AWSInstance, error) {
+// enrichBlockDevices fills in the volume attributes of every EBS block device mapping
+// from DescribeVolumes. Volumes AWS doesn't return, e.g. ones deleted in the meantime,
+// are left without details.
+func (c *AWSClient) enrichBlockDevices(ctx context.Context, instances []*models.AWSInstance) error {
 	logger := zap.L().With(
 		zap.String("package", packageName),
-		zap.String("function", "GetAWSInstance"),
+		zap.String("function", "enrichBlockDevices"),
 	)
 
-	// Describe the instance
-	output, err := c.client.DescribeInstances(context.TODO(), &ec2.DescribeInstancesInput{
-		InstanceIds: []string{},
-	})
+	var volumeIDs []string
+	for _, instance := range instances {
+		for _, mapping := range instance.BlockDeviceMappings {
+			volumeIDs = append(volumeIDs, mapping.VolumeId)
+		}
+	}
+	if len(volumeIDs) == 0 {
+		return nil
+	}
 
-	if err != nil {
-		return nil, errors.New(errors.ErrAWSInstance, "failed to describe instances",
-			map[string]interface{}{
-				"operation": "describe_instances",
-			}, err)
+	volumes := make(map[string]types.Volume, len(volumeIDs))
+	for start := 0; start < len(volumeIDs); start += volumeBatchSize {
+		end := min(start+volumeBatchSize, len(volumeIDs))
+
+		var nextToken *string
+		for {
+			output, err := c.client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+				Filters: []types.Filter{
+					{Name: aws.String("volume-id"), Values: volumeIDs[start:end]},
+				},
+				NextToken: nextToken,
+			})
+			if err != nil {
+				return errors.New(errors.ErrAWSVolume, "failed to describe volumes",
+					map[string]interface{}{
+						"operation": "describe_volumes",
+					}, err)
+			}
+			for _, volume := range output.Volumes {
+				volumes[aws.ToString(volume.VolumeId)] = volume
+			}
+
+			if aws.ToString(output.NextToken) == "" {
+				break
+			}
+			nextToken = output.NextToken
+		}
 	}
 
-	// Check if the instance exists in the response
-	if len(output.Reservations) == 0 || len(output.Reservations[0].Instances) == 0 {
-		logger.Error("No instances found")
-		return nil, errors.New(errors.ErrAWSInstance, "no instances found",
-			map[string]interface{}{
-				"operation": "instance_lookup",
-			}, nil)
+	for _, instance := range instances {
+		for i := range instance.BlockDeviceMappings {
+			mapping := &instance.BlockDeviceMappings[i]
+			volume, ok := volumes[mapping.VolumeId]
+			if !ok {
+				logger.Warn("Volume not returned by DescribeVolumes",
+					zap.String("operation", "describe_volumes"),
+					zap.String("instance_id", instance.InstanceID),
+					zap.String("volume_id", mapping.VolumeId),
+				)
+				continue
+			}
+			applyVolume(mapping, volume)
+		}
 	}
 
-	// Extract instance details
-	i := output.Reservations[0].Instances[0]
-	logger.Info("Instance found", zap.String("instance_id", *i.InstanceId))
+	logger.Info("Block device volumes described",
+		zap.String("operation", "describe_volumes"),
+		zap.Int("volume_count", len(volumes)),
+	)
+	return nil
+}
 
+// applyVolume copies the attributes of an EBS volume onto its block device mapping
+func applyVolume(mapping *models.BlockDeviceMapping, volume types.Volume) {
+	mapping.VolumeSize = aws.ToInt32(volume.Size)
+	mapping.VolumeType = string(volume.VolumeType)
+	mapping.Iops = aws.ToInt32(volume.Iops)
+	mapping.Throughput = aws.ToInt32(volume.Throughput)
+	mapping.Encrypted = aws.ToBool(volume.Encrypted)
+	mapping.KmsKeyId = aws.ToString(volume.KmsKeyId)
+}
+
+// parseInstance maps an EC2 instance onto the AWSInstance model
+func parseInstance(i types.Instance) *models.AWSInstance {
 	// Map tags
 	tags := make(map[string]string)
 	for _, tag := range i.Tags {
@@ -108,27 +232,33 @@ func (c *AWSClient) GetAWSInstance() (*models.AWSInstance, error) {
 		}
 	}
 
-	// Return the AWSInstance model with relevant details
-	awsInstance := &models.AWSInstance{
-		InstanceID:          *i.InstanceId,
+	var launchTime string
+	if i.LaunchTime != nil {
+		launchTime = i.LaunchTime.String()
+	}
+
+	var state string
+	if i.State != nil {
+		state = string(i.State.Name)
+	}
+
+	return &models.AWSInstance{
+		InstanceID:          aws.ToString(i.InstanceId),
 		InstanceType:        string(i.InstanceType),
-		AMI:                 *i.ImageId,
+		State:               state,
+		AMI:                 aws.ToString(i.ImageId),
 		PrivateIP:           aws.ToString(i.PrivateIpAddress),
 		KeyName:             aws.ToString(i.KeyName),
 		Tags:                tags,
 		PublicIP:            aws.ToString(i.PublicIpAddress),
-		LaunchTime:          i.LaunchTime.String(),
+		LaunchTime:          launchTime,
 		PrivateDnsName:      aws.ToString(i.PrivateDnsName),
 		BlockDeviceMappings: parseBlockDeviceMappings(i.BlockDeviceMappings),
-		SecurityGroups:      parseSecurityGroups(i.SecurityGroups),
+		SecurityGroups:      parseSecurityGroups(i.SecurityGroups, aws.ToString(i.VpcId)),
 		NetworkInterfaces:   parseNetworkInterfaces(i.NetworkInterfaces),
+		RootDeviceName:      aws.ToString(i.RootDeviceName),
+		VpcId:               aws.ToString(i.VpcId),
 	}
-
-	logger.Info("AWS instance details parsed successfully",
-		zap.String("instance_id", awsInstance.InstanceID),
-		zap.String("instance_type", awsInstance.InstanceType),
-	)
-	return awsInstance, nil
 }
 
 // Helper function to parse block device mappings
@@ -139,22 +269,26 @@ func parseBlockDeviceMappings(mappings []types.InstanceBlockDeviceMapping) []mod
 			continue
 		}
 		result = append(result, models.BlockDeviceMapping{
-			DeviceName: *mapping.DeviceName,
-			VolumeId:   *mapping.Ebs.VolumeId,
+			DeviceName:          *mapping.DeviceName,
+			VolumeId:            *mapping.Ebs.VolumeId,
+			DeleteOnTermination: aws.ToBool(mapping.Ebs.DeleteOnTermination),
 		})
 	}
 	return result
 }
 
-// Helper function to parse security groups
-func parseSecurityGroups(groups []types.GroupIdentifier) []models.SecurityGroup {
+// Helper function to parse security groups. The groups of an instance all belong to
+// the instance's VPC.
+func parseSecurityGroups(groups []types.GroupIdentifier, vpcID string) []models.SecurityGroup {
 	result := make([]models.SecurityGroup, 0)
 	for _, group := range groups {
 		if group.GroupId == nil {
 			continue
 		}
 		result = append(result, models.SecurityGroup{
-			GroupId: *group.GroupId,
+			GroupId:   *group.GroupId,
+			GroupName: aws.ToString(group.GroupName),
+			VpcId:     vpcID,
 		})
 	}
 	return result
@@ -66,14 +66,14 @@ func TestNewAWSClient(t *testing.T) {
 	}
 }
 
-func TestGetAWSInstance(t *testing.T) {
+func TestGetAWSInstances(t *testing.T) {
 	now := time.Now()
 	tests := []struct {
 		name        string
 		mockOutput  *ec2.DescribeInstancesOutput
 		mockError   error
 		expectError bool
-		validate    func(t *testing.T, instance *models.AWSInstance)
+		validate    func(t *testing.T, instances []*models.AWSInstance)
 	}{
 		{
 			name: "Success Case - Complete Instance",
@@ -84,6 +84,7 @@ func TestGetAWSInstance(t *testing.T) {
 							{
 								InstanceId:       aws.String("i-1234567890abcdef0"),
 								InstanceType:     types.InstanceTypeT2Micro,
+								State:            &types.InstanceState{Name: types.InstanceStateNameRunning},
 								PrivateIpAddress: aws.String("10.0.0.1"),
 								ImageId:          aws.String("ami-123"),
 								KeyName:          aws.String("test-key"),
@@ -120,9 +121,13 @@ func TestGetAWSInstance(t *testing.T) {
 				},
 			},
 			expectError: false,
-			validate: func(t *testing.T, instance *models.AWSInstance) {
+			validate: func(t *testing.T, instances []*models.AWSInstance) {
+				assert.Len(t, instances, 1)
+				instance := instances[0]
 				assert.Equal(t, "i-1234567890abcdef0", instance.InstanceID)
 				assert.Equal(t, "t2.micro", instance.InstanceType)
+				assert.Equal(t, "running", instance.State)
+				assert.False(t, instance.IsTerminated())
 				assert.Equal(t, "ami-123", instance.AMI)
 				assert.Equal(t, "10.0.0.1", instance.PrivateIP)
 				assert.Equal(t, "test-key", instance.KeyName)
@@ -137,15 +142,48 @@ func TestGetAWSInstance(t *testing.T) {
 				assert.Len(t, instance.NetworkInterfaces, 1)
 			},
 		},
+		{
+			name: "Multiple Reservations",
+			mockOutput: &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{
+					{
+						Instances: []types.Instance{
+							{InstanceId: aws.String("i-1"), ImageId: aws.String("ami-1")},
+							{InstanceId: aws.String("i-2"), ImageId: aws.String("ami-2")},
+						},
+					},
+					{
+						Instances: []types.Instance{
+							{InstanceId: aws.String("i-3")},
+							{InstanceId: nil},
+						},
+					},
+				},
+			},
+			expectError: false,
+			validate: func(t *testing.T, instances []*models.AWSInstance) {
+				assert.Len(t, instances, 3)
+				assert.Equal(t, "i-1", instances[0].InstanceID)
+				assert.Equal(t, "i-2", instances[1].InstanceID)
+				assert.Equal(t, "i-3", instances[2].InstanceID)
+				assert.Equal(t, "", instances[2].AMI)
+			},
+		},
 		{
 			name:        "Empty Reservations",
 			mockOutput:  &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{}},
-			expectError: true,
+			expectError: false,
+			validate: func(t *testing.T, instances []*models.AWSInstance) {
+				assert.Empty(t, instances)
+			},
 		},
 		{
 			name:        "Empty Instances",
 			mockOutput:  &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{Instances: []types.Instance{}}}},
-			expectError: true,
+			expectError: false,
+			validate: func(t *testing.T, instances []*models.AWSInstance) {
+				assert.Empty(t, instances)
+			},
 		},
 		{
 			name:        "AWS Error",
@@ -163,48 +201,225 @@ func TestGetAWSInstance(t *testing.T) {
 			}
 
 			client := &AWSClient{client: mockClient}
-			instance, err := client.GetAWSInstance()
+			instances, err := client.GetAWSInstances(context.Background())
 
 			if tt.expectError {
 				assert.Error(t, err)
-				assert.Nil(t, instance)
+				assert.Nil(t, instances)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, instance)
+				assert.NotNil(t, instances)
 				if tt.validate != nil {
-					tt.validate(t, instance)
+					tt.validate(t, instances)
 				}
 			}
 		})
 	}
 }
 
+func TestGetAWSInstances_Pagination(t *testing.T) {
+	pages := map[string]*ec2.DescribeInstancesOutput{
+		"": {
+			Reservations: []types.Reservation{
+				{Instances: []types.Instance{{InstanceId: aws.String("i-page1")}}},
+			},
+			NextToken: aws.String("token-2"),
+		},
+		"token-2": {
+			Reservations: []types.Reservation{
+				{Instances: []types.Instance{{InstanceId: aws.String("i-page2a")}, {InstanceId: aws.String("i-page2b")}}},
+			},
+			NextToken: aws.String("token-3"),
+		},
+		"token-3": {
+			Reservations: []types.Reservation{
+				{Instances: []types.Instance{{InstanceId: aws.String("i-page3")}}},
+			},
+		},
+	}
+
+	var tokens []string
+	mockClient := &MockEC2Client{
+		DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+			token := aws.ToString(params.NextToken)
+			tokens = append(tokens, token)
+			return pages[token], nil
+		},
+	}
+
+	client := &AWSClient{client: mockClient}
+	instances, err := client.GetAWSInstances(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "token-2", "token-3"}, tokens)
+	var ids []string
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceID)
+	}
+	assert.Equal(t, []string{"i-page1", "i-page2a", "i-page2b", "i-page3"}, ids)
+}
+
+func TestGetAWSInstances_VolumeDetails(t *testing.T) {
+	instancesOutput := &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{
+				Instances: []types.Instance{
+					{
+						InstanceId:     aws.String("i-1"),
+						RootDeviceName: aws.String("/dev/xvda"),
+						BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+							{
+								DeviceName: aws.String("/dev/xvda"),
+								Ebs:        &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-root"), DeleteOnTermination: aws.Bool(true)},
+							},
+							{
+								DeviceName: aws.String("/dev/sdf"),
+								Ebs:        &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-data"), DeleteOnTermination: aws.Bool(false)},
+							},
+							{
+								DeviceName: aws.String("/dev/sdg"),
+								Ebs:        &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-deleted")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("Volumes are described", func(t *testing.T) {
+		var requested []string
+		mockClient := &MockEC2Client{
+			DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+				return instancesOutput, nil
+			},
+			DescribeVolumesFunc: func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+				if params.NextToken == nil {
+					requested = append(requested, params.Filters[0].Values...)
+					return &ec2.DescribeVolumesOutput{
+						Volumes: []types.Volume{
+							{VolumeId: aws.String("vol-root"), Size: aws.Int32(8), VolumeType: types.VolumeTypeGp2, Iops: aws.Int32(100), Encrypted: aws.Bool(false)},
+						},
+						NextToken: aws.String("next"),
+					}, nil
+				}
+				return &ec2.DescribeVolumesOutput{
+					Volumes: []types.Volume{
+						{
+							VolumeId:   aws.String("vol-data"),
+							Size:       aws.Int32(100),
+							VolumeType: types.VolumeTypeGp3,
+							Iops:       aws.Int32(3000),
+							Throughput: aws.Int32(125),
+							Encrypted:  aws.Bool(true),
+							KmsKeyId:   aws.String("arn:aws:kms:us-east-1:000000000000:key/abc"),
+						},
+					},
+				}, nil
+			},
+		}
+
+		client := &AWSClient{client: mockClient}
+		instances, err := client.GetAWSInstances(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"vol-root", "vol-data", "vol-deleted"}, requested)
+
+		instance := instances[0]
+		assert.Equal(t, "/dev/xvda", instance.RootDeviceName)
+		assert.Equal(t, []models.BlockDeviceMapping{
+			{DeviceName: "/dev/xvda", VolumeId: "vol-root", DeleteOnTermination: true, VolumeSize: 8, VolumeType: "gp2", Iops: 100},
+			{
+				DeviceName:          "/dev/sdf",
+				VolumeId:            "vol-data",
+				DeleteOnTermination: false,
+				VolumeSize:          100,
+				VolumeType:          "gp3",
+				Iops:                3000,
+				Throughput:          125,
+				Encrypted:           true,
+				KmsKeyId:            "arn:aws:kms:us-east-1:000000000000:key/abc",
+			},
+			{DeviceName: "/dev/sdg", VolumeId: "vol-deleted"},
+		}, instance.BlockDeviceMappings)
+		assert.True(t, instance.BlockDeviceMappings[0].HasVolumeDetails())
+		assert.False(t, instance.BlockDeviceMappings[2].HasVolumeDetails())
+	})
+
+	t.Run("DescribeVolumes error", func(t *testing.T) {
+		mockClient := &MockEC2Client{
+			DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+				return instancesOutput, nil
+			},
+			DescribeVolumesFunc: func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+				return nil, fmt.Errorf("throttled")
+			},
+		}
+
+		client := &AWSClient{client: mockClient}
+		instances, err := client.GetAWSInstances(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to describe volumes")
+		assert.Nil(t, instances)
+	})
+
+	t.Run("Volume IDs are batched", func(t *testing.T) {
+		mappings := make([]types.InstanceBlockDeviceMapping, volumeBatchSize+1)
+		for i := range mappings {
+			mappings[i] = types.InstanceBlockDeviceMapping{
+				DeviceName: aws.String(fmt.Sprintf("/dev/sd%d", i)),
+				Ebs:        &types.EbsInstanceBlockDevice{VolumeId: aws.String(fmt.Sprintf("vol-%d", i))},
+			}
+		}
+
+		var batches []int
+		mockClient := &MockEC2Client{
+			DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+				return &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{
+					{Instances: []types.Instance{{InstanceId: aws.String("i-1"), BlockDeviceMappings: mappings}}},
+				}}, nil
+			},
+			DescribeVolumesFunc: func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+				batches = append(batches, len(params.Filters[0].Values))
+				return &ec2.DescribeVolumesOutput{}, nil
+			},
+		}
+
+		client := &AWSClient{client: mockClient}
+		_, err := client.GetAWSInstances(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []int{volumeBatchSize, 1}, batches)
+	})
+}
+
 func TestParseSecurityGroups(t *testing.T) {
 	tests := []struct {
 		name   string
 		input  []types.GroupIdentifier
+		vpcID  string
 		output []models.SecurityGroup
 	}{
 		{
 			name: "single group",
 			input: []types.GroupIdentifier{
-				{GroupId: str("sg-abc123")},
+				{GroupId: str("sg-abc123"), GroupName: str("web")},
 			},
+			vpcID: "vpc-1",
 			output: []models.SecurityGroup{
-				{GroupId: "sg-abc123"},
+				{GroupId: "sg-abc123", GroupName: "web", VpcId: "vpc-1"},
 			},
 		},
 		{
 			name: "multiple groups",
 			input: []types.GroupIdentifier{
-				{GroupId: str("sg-abc123")},
-				{GroupId: str("sg-def456")},
+				{GroupId: str("sg-abc123"), GroupName: str("web")},
+				{GroupId: str("sg-def456"), GroupName: str("ssh")},
 				{GroupId: str("sg-ghi789")},
 			},
+			vpcID: "vpc-1",
 			output: []models.SecurityGroup{
-				{GroupId: "sg-abc123"},
-				{GroupId: "sg-def456"},
-				{GroupId: "sg-ghi789"},
+				{GroupId: "sg-abc123", GroupName: "web", VpcId: "vpc-1"},
+				{GroupId: "sg-def456", GroupName: "ssh", VpcId: "vpc-1"},
+				{GroupId: "sg-ghi789", VpcId: "vpc-1"},
 			},
 		},
 		{
@@ -223,7 +438,7 @@ func TestParseSecurityGroups(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parseSecurityGroups(tt.input)
+			got := parseSecurityGroups(tt.input, tt.vpcID)
 			assert.Equal(t, tt.output, got)
 		})
 	}
@@ -240,11 +455,11 @@ func TestParseBlockDeviceMappings(t *testing.T) {
 			input: []types.InstanceBlockDeviceMapping{
 				{
 					DeviceName: str("/dev/sda1"),
-					Ebs:        &types.EbsInstanceBlockDevice{VolumeId: str("vol-12345")},
+					Ebs:        &types.EbsInstanceBlockDevice{VolumeId: str("vol-12345"), DeleteOnTermination: ptr(true)},
 				},
 			},
 			expected: []models.BlockDeviceMapping{
-				{DeviceName: "/dev/sda1", VolumeId: "vol-12345"},
+				{DeviceName: "/dev/sda1", VolumeId: "vol-12345", DeleteOnTermination: true},
 			},
 		},
 		{
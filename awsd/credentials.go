@@ -0,0 +1,104 @@
+package awsd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/configuration"
+	"Savannahtakehomeassi/errors"
+)
+
+// credentialsExpiryWindow is how long before they expire assumed-role credentials
+// are refreshed, so that no request is signed with credentials about to expire
+const credentialsExpiryWindow = 5 * time.Minute
+
+// NewAccountCredentials returns the credentials of an account target, shared by the
+// clients of every region of the account. When the target has a role, it is assumed
+// through STS on first use and the credentials are cached and refreshed before they
+// expire. stsClient is nil to call STS through LOCALSTACK_URL with the base
+// credentials, the profile's or the static keys of the configuration. AssumeRole is
+// retried like the other AWS calls.
+func NewAccountCredentials(conf *configuration.Config, account configuration.AccountTarget, stsClient STSAPI) (aws.CredentialsProvider, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "NewAccountCredentials"),
+		zap.String("account", account.Name),
+	)
+
+	if conf == nil {
+		return nil, fmt.Errorf("configuration cannot be nil")
+	}
+
+	base, err := baseCredentials(conf, account)
+	if err != nil {
+		return nil, err
+	}
+	if account.RoleARN == "" {
+		logger.Info("Account credentials loaded from profile",
+			zap.String("operation", "credentials_creation"),
+			zap.String("profile", account.Profile),
+		)
+		return base, nil
+	}
+
+	if stsClient == nil {
+		cfg, err := loadAWSConfig(conf, conf.AWSRegion, base)
+		if err != nil {
+			return nil, err
+		}
+		stsClient = sts.NewFromConfig(cfg)
+	}
+	stsClient = &retryingSTS{api: stsClient, retrier: newRetrier(conf)}
+	provider := stscreds.NewAssumeRoleProvider(stsClient, account.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = account.SessionName
+		if account.ExternalID != "" {
+			o.ExternalID = aws.String(account.ExternalID)
+		}
+	})
+
+	logger.Info("Account credentials will assume role",
+		zap.String("operation", "credentials_creation"),
+		zap.String("role_arn", account.RoleARN),
+		zap.String("session_name", account.SessionName),
+	)
+	return aws.NewCredentialsCache(provider, func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = credentialsExpiryWindow
+	}), nil
+}
+
+// baseCredentials returns the credentials an account target starts from: those of
+// its shared-config profile, or the static keys of the configuration
+func baseCredentials(conf *configuration.Config, account configuration.AccountTarget) (aws.CredentialsProvider, error) {
+	if account.Profile == "" {
+		return staticCredentials(conf)
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithSharedConfigProfile(account.Profile),
+	)
+	if err != nil || cfg.Credentials == nil {
+		return nil, errors.New(errors.ErrAWSClient, "failed to load profile",
+			map[string]interface{}{
+				"operation": "load_profile",
+				"account":   account.Name,
+				"profile":   account.Profile,
+			}, err)
+	}
+	return cfg.Credentials, nil
+}
+
+// staticCredentials returns the static keys of the configuration
+func staticCredentials(conf *configuration.Config) (aws.CredentialsProvider, error) {
+	if conf.AccessSecret == "" || conf.AcessKeyID == "" {
+		return nil, fmt.Errorf("AWS credentials cannot be empty")
+	}
+	return credentials.NewStaticCredentialsProvider(conf.AcessKeyID, conf.AccessSecret, ""), nil
+}
@@ -0,0 +1,105 @@
+package awsd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Savannahtakehomeassi/configuration"
+)
+
+func TestNewAccountCredentials_AssumeRole(t *testing.T) {
+	conf := &configuration.Config{AWSRegion: "us-east-1", AccessSecret: "test-secret", AcessKeyID: "test-key"}
+	account := configuration.AccountTarget{
+		Name:        "prod",
+		RoleARN:     "arn:aws:iam::111111111111:role/drift-reader",
+		ExternalID:  "ext-123",
+		SessionName: "drift-checker",
+	}
+
+	tests := []struct {
+		name          string
+		expiresIn     time.Duration
+		expectedCalls int
+	}{
+		{name: "credentials are cached until they near expiry", expiresIn: time.Hour, expectedCalls: 1},
+		{name: "credentials within the expiry window are refreshed", expiresIn: time.Minute, expectedCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			stsClient := &MockSTSClient{
+				AssumeRoleFunc: func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+					calls++
+					assert.Equal(t, account.RoleARN, aws.ToString(params.RoleArn))
+					assert.Equal(t, account.ExternalID, aws.ToString(params.ExternalId))
+					assert.Equal(t, account.SessionName, aws.ToString(params.RoleSessionName))
+					return &sts.AssumeRoleOutput{Credentials: &types.Credentials{
+						AccessKeyId:     aws.String("ASIA-ASSUMED"),
+						SecretAccessKey: aws.String("assumed-secret"),
+						SessionToken:    aws.String("token"),
+						Expiration:      aws.Time(time.Now().Add(tt.expiresIn)),
+					}}, nil
+				},
+			}
+
+			creds, err := NewAccountCredentials(conf, account, stsClient)
+			require.NoError(t, err)
+
+			for i := 0; i < 2; i++ {
+				value, err := creds.Retrieve(context.Background())
+				require.NoError(t, err)
+				assert.Equal(t, "ASIA-ASSUMED", value.AccessKeyID)
+				assert.Equal(t, "token", value.SessionToken)
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
+
+func TestNewAccountCredentials_Profile(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	require.NoError(t, os.WriteFile(credentialsFile, []byte("[data-lake]\naws_access_key_id = PROFILEKEY\naws_secret_access_key = profile-secret\n"), 0o600))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+
+	conf := &configuration.Config{AWSRegion: "us-east-1"}
+	creds, err := NewAccountCredentials(conf, configuration.AccountTarget{Name: "data-lake", Profile: "data-lake"}, nil)
+	require.NoError(t, err)
+
+	value, err := creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "PROFILEKEY", value.AccessKeyID)
+
+	_, err = NewAccountCredentials(conf, configuration.AccountTarget{Name: "other", Profile: "missing"}, nil)
+	assert.Error(t, err)
+}
+
+func TestNewAccountCredentials_NoStaticKeys(t *testing.T) {
+	conf := &configuration.Config{AWSRegion: "us-east-1"}
+	creds, err := NewAccountCredentials(conf, configuration.AccountTarget{Name: "prod", RoleARN: "arn:aws:iam::111111111111:role/drift-reader"}, nil)
+
+	assert.Nil(t, creds)
+	assert.Error(t, err)
+}
+
+func TestStaticCredentials(t *testing.T) {
+	conf := &configuration.Config{AccessSecret: "test-secret", AcessKeyID: "test-key"}
+	provider, err := staticCredentials(conf)
+	require.NoError(t, err)
+
+	creds, err := provider.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "test-key", creds.AccessKeyID)
+	assert.Equal(t, "test-secret", creds.SecretAccessKey)
+}
@@ -0,0 +1,308 @@
+package awsd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/configuration"
+	"Savannahtakehomeassi/errors"
+)
+
+type IAMClient struct {
+	client IAMAPI
+}
+
+// NewIAMClient creates a new IAM client. IAM is global, so one client serves every
+// scanned region.
+func NewIAMClient(conf *configuration.Config) (*IAMClient, error) {
+	return NewIAMClientForAccount(conf, nil)
+}
+
+// NewIAMClientForAccount creates a new IAM client for an account. creds is nil for
+// the static keys of the configuration.
+func NewIAMClientForAccount(conf *configuration.Config, creds aws.CredentialsProvider) (*IAMClient, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "NewIAMClientForAccount"),
+	)
+
+	if conf == nil {
+		return nil, fmt.Errorf("configuration cannot be nil")
+	}
+	cfg, err := loadAWSConfig(conf, conf.AWSRegion, creds)
+	if err != nil {
+		logger.Error("Failed to create IAM client",
+			zap.String("operation", "client_creation"),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	logger.Info("IAM client created successfully")
+	return &IAMClient{
+		client: &retryingIAM{api: iam.NewFromConfig(cfg), retrier: newRetrier(conf)},
+	}, nil
+}
+
+// ListRoles returns every role of the account with its trust policy, following Marker
+// until all roles have been read. Inline and attached policies are left to GetRole.
+func (c *IAMClient) ListRoles(ctx context.Context) ([]*models.AWSRole, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "ListRoles"),
+	)
+
+	roles := make([]*models.AWSRole, 0)
+	var marker *string
+	for {
+		output, err := c.client.ListRoles(ctx, &iam.ListRolesInput{
+			Marker: marker,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSIAM, "failed to list roles",
+				map[string]interface{}{
+					"operation": "list_roles",
+				}, err)
+		}
+
+		for _, role := range output.Roles {
+			if role.RoleName == nil {
+				continue
+			}
+			roles = append(roles, parseRole(role))
+		}
+
+		if !output.IsTruncated || aws.ToString(output.Marker) == "" {
+			break
+		}
+		marker = output.Marker
+	}
+
+	logger.Info("IAM roles listed successfully",
+		zap.String("operation", "list_roles"),
+		zap.Int("role_count", len(roles)),
+	)
+	return roles, nil
+}
+
+// GetRole reads a role with its tags, inline policies and attached managed policies
+func (c *IAMClient) GetRole(ctx context.Context, name string) (*models.AWSRole, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetRole"),
+		zap.String("role_name", name),
+	)
+
+	output, err := c.client.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(name)})
+	if err != nil {
+		return nil, roleReadError(name, "get_role", err)
+	}
+	if output.Role == nil {
+		return nil, errors.New(errors.ErrAWSIAM, "role not returned",
+			map[string]interface{}{
+				"operation": "get_role",
+				"role_name": name,
+			}, nil)
+	}
+	role := parseRole(*output.Role)
+	role.InlinePolicies = make(map[string]string)
+	role.AttachedPolicyArns = make([]string, 0)
+
+	var marker *string
+	for {
+		policies, err := c.client.ListRolePolicies(ctx, &iam.ListRolePoliciesInput{
+			RoleName: aws.String(name),
+			Marker:   marker,
+		})
+		if err != nil {
+			return nil, roleReadError(name, "list_role_policies", err)
+		}
+		for _, policyName := range policies.PolicyNames {
+			policy, err := c.client.GetRolePolicy(ctx, &iam.GetRolePolicyInput{
+				RoleName:   aws.String(name),
+				PolicyName: aws.String(policyName),
+			})
+			if err != nil {
+				return nil, roleReadError(name, "get_role_policy", err)
+			}
+			role.InlinePolicies[policyName] = decodePolicyDocument(aws.ToString(policy.PolicyDocument))
+		}
+		if !policies.IsTruncated || aws.ToString(policies.Marker) == "" {
+			break
+		}
+		marker = policies.Marker
+	}
+
+	marker = nil
+	for {
+		attached, err := c.client.ListAttachedRolePolicies(ctx, &iam.ListAttachedRolePoliciesInput{
+			RoleName: aws.String(name),
+			Marker:   marker,
+		})
+		if err != nil {
+			return nil, roleReadError(name, "list_attached_role_policies", err)
+		}
+		for _, policy := range attached.AttachedPolicies {
+			if policy.PolicyArn != nil {
+				role.AttachedPolicyArns = append(role.AttachedPolicyArns, *policy.PolicyArn)
+			}
+		}
+		if !attached.IsTruncated || aws.ToString(attached.Marker) == "" {
+			break
+		}
+		marker = attached.Marker
+	}
+
+	logger.Info("IAM role described successfully",
+		zap.String("operation", "describe_role"),
+		zap.Int("inline_policy_count", len(role.InlinePolicies)),
+		zap.Int("attached_policy_count", len(role.AttachedPolicyArns)),
+	)
+	return role, nil
+}
+
+// ListPolicies returns every customer managed policy of the account, following Marker
+// until all policies have been read. Documents are left to GetPolicy.
+func (c *IAMClient) ListPolicies(ctx context.Context) ([]*models.AWSPolicy, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "ListPolicies"),
+	)
+
+	policies := make([]*models.AWSPolicy, 0)
+	var marker *string
+	for {
+		output, err := c.client.ListPolicies(ctx, &iam.ListPoliciesInput{
+			Scope:  types.PolicyScopeTypeLocal,
+			Marker: marker,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSIAM, "failed to list policies",
+				map[string]interface{}{
+					"operation": "list_policies",
+				}, err)
+		}
+
+		for _, policy := range output.Policies {
+			if policy.Arn == nil {
+				continue
+			}
+			policies = append(policies, parsePolicy(policy))
+		}
+
+		if !output.IsTruncated || aws.ToString(output.Marker) == "" {
+			break
+		}
+		marker = output.Marker
+	}
+
+	logger.Info("IAM policies listed successfully",
+		zap.String("operation", "list_policies"),
+		zap.Int("policy_count", len(policies)),
+	)
+	return policies, nil
+}
+
+// GetPolicy reads a managed policy with its tags and the document of its default version
+func (c *IAMClient) GetPolicy(ctx context.Context, arn string) (*models.AWSPolicy, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetPolicy"),
+		zap.String("policy_arn", arn),
+	)
+
+	output, err := c.client.GetPolicy(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(arn)})
+	if err != nil || output.Policy == nil {
+		return nil, errors.New(errors.ErrAWSIAM, "failed to get policy",
+			map[string]interface{}{
+				"operation":  "get_policy",
+				"policy_arn": arn,
+			}, err)
+	}
+	policy := parsePolicy(*output.Policy)
+
+	version, err := c.client.GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: aws.String(arn),
+		VersionId: aws.String(policy.DefaultVersionId),
+	})
+	if err != nil {
+		return nil, errors.New(errors.ErrAWSIAM, "failed to get policy version",
+			map[string]interface{}{
+				"operation":  "get_policy_version",
+				"policy_arn": arn,
+				"version_id": policy.DefaultVersionId,
+			}, err)
+	}
+	if version.PolicyVersion != nil {
+		policy.Document = decodePolicyDocument(aws.ToString(version.PolicyVersion.Document))
+	}
+
+	logger.Info("IAM policy described successfully",
+		zap.String("operation", "describe_policy"),
+	)
+	return policy, nil
+}
+
+// roleReadError wraps the error of a role read
+func roleReadError(role, operation string, err error) error {
+	return errors.New(errors.ErrAWSIAM, "failed to read role",
+		map[string]interface{}{
+			"operation": operation,
+			"role_name": role,
+		}, err)
+}
+
+// parseRole maps an IAM role onto the AWSRole model
+func parseRole(role types.Role) *models.AWSRole {
+	return &models.AWSRole{
+		RoleName:           aws.ToString(role.RoleName),
+		RoleId:             aws.ToString(role.RoleId),
+		Arn:                aws.ToString(role.Arn),
+		Path:               aws.ToString(role.Path),
+		Description:        aws.ToString(role.Description),
+		MaxSessionDuration: aws.ToInt32(role.MaxSessionDuration),
+		AssumeRolePolicy:   decodePolicyDocument(aws.ToString(role.AssumeRolePolicyDocument)),
+		Tags:               parseIAMTags(role.Tags),
+	}
+}
+
+// parsePolicy maps a managed IAM policy onto the AWSPolicy model
+func parsePolicy(policy types.Policy) *models.AWSPolicy {
+	return &models.AWSPolicy{
+		PolicyName:       aws.ToString(policy.PolicyName),
+		PolicyId:         aws.ToString(policy.PolicyId),
+		Arn:              aws.ToString(policy.Arn),
+		Path:             aws.ToString(policy.Path),
+		Description:      aws.ToString(policy.Description),
+		DefaultVersionId: aws.ToString(policy.DefaultVersionId),
+		Tags:             parseIAMTags(policy.Tags),
+	}
+}
+
+// parseIAMTags maps IAM tags onto a map, skipping incomplete tags
+func parseIAMTags(tags []types.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag.Key != nil && tag.Value != nil {
+			result[*tag.Key] = *tag.Value
+		}
+	}
+	return result
+}
+
+// decodePolicyDocument undoes the URL encoding IAM applies to policy documents. A
+// document that isn't encoded, as LocalStack may return it, is kept as is.
+func decodePolicyDocument(document string) string {
+	decoded, err := url.PathUnescape(document)
+	if err != nil {
+		return document
+	}
+	return decoded
+}
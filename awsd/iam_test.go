@@ -0,0 +1,193 @@
+package awsd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/errors"
+)
+
+const trustPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+
+func TestListRoles(t *testing.T) {
+	pages := map[string]*iam.ListRolesOutput{
+		"": {
+			Roles: []types.Role{
+				{
+					RoleName:                 aws.String("web"),
+					RoleId:                   aws.String("AROA1"),
+					Arn:                      aws.String("arn:aws:iam::123:role/web"),
+					Path:                     aws.String("/"),
+					AssumeRolePolicyDocument: aws.String(url.PathEscape(trustPolicy)),
+				},
+				{RoleId: aws.String("no name")},
+			},
+			IsTruncated: true,
+			Marker:      aws.String("marker-2"),
+		},
+		"marker-2": {
+			Roles: []types.Role{{RoleName: aws.String("batch"), AssumeRolePolicyDocument: aws.String(trustPolicy)}},
+		},
+	}
+
+	client := &IAMClient{client: &MockIAMClient{
+		ListRolesFunc: func(ctx context.Context, params *iam.ListRolesInput, optFns ...func(*iam.Options)) (*iam.ListRolesOutput, error) {
+			return pages[aws.ToString(params.Marker)], nil
+		},
+	}}
+	roles, err := client.ListRoles(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.AWSRole{
+		{RoleName: "web", RoleId: "AROA1", Arn: "arn:aws:iam::123:role/web", Path: "/", AssumeRolePolicy: trustPolicy, Tags: map[string]string{}},
+		{RoleName: "batch", AssumeRolePolicy: trustPolicy, Tags: map[string]string{}},
+	}, roles)
+}
+
+func TestGetRole(t *testing.T) {
+	readOnly := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+
+	tests := []struct {
+		name          string
+		client        *MockIAMClient
+		expected      *models.AWSRole
+		expectedError bool
+	}{
+		{
+			name: "role with inline and attached policies",
+			client: &MockIAMClient{
+				GetRoleFunc: func(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+					return &iam.GetRoleOutput{Role: &types.Role{
+						RoleName:                 params.RoleName,
+						Description:              aws.String("web servers"),
+						MaxSessionDuration:       aws.Int32(3600),
+						AssumeRolePolicyDocument: aws.String(url.PathEscape(trustPolicy)),
+						Tags:                     []types.Tag{{Key: aws.String("env"), Value: aws.String("prod")}},
+					}}, nil
+				},
+				ListRolePoliciesFunc: func(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error) {
+					return &iam.ListRolePoliciesOutput{PolicyNames: []string{"read-only"}}, nil
+				},
+				GetRolePolicyFunc: func(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+					return &iam.GetRolePolicyOutput{PolicyDocument: aws.String(url.PathEscape(readOnly))}, nil
+				},
+				ListAttachedRolePoliciesFunc: func(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
+					if params.Marker == nil {
+						return &iam.ListAttachedRolePoliciesOutput{
+							AttachedPolicies: []types.AttachedPolicy{{PolicyArn: aws.String("arn:aws:iam::aws:policy/ReadOnlyAccess")}},
+							IsTruncated:      true,
+							Marker:           aws.String("marker-2"),
+						}, nil
+					}
+					return &iam.ListAttachedRolePoliciesOutput{
+						AttachedPolicies: []types.AttachedPolicy{{PolicyArn: aws.String("arn:aws:iam::123:policy/logs")}},
+					}, nil
+				},
+			},
+			expected: &models.AWSRole{
+				RoleName:           "web",
+				Description:        "web servers",
+				MaxSessionDuration: 3600,
+				AssumeRolePolicy:   trustPolicy,
+				InlinePolicies:     map[string]string{"read-only": readOnly},
+				AttachedPolicyArns: []string{"arn:aws:iam::aws:policy/ReadOnlyAccess", "arn:aws:iam::123:policy/logs"},
+				Tags:               map[string]string{"env": "prod"},
+			},
+		},
+		{
+			name: "role not found",
+			client: &MockIAMClient{
+				GetRoleFunc: func(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+					return nil, &types.NoSuchEntityException{Message: aws.String("not found")}
+				},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &IAMClient{client: tt.client}
+			role, err := client.GetRole(context.Background(), "web")
+
+			if tt.expectedError {
+				assert.Nil(t, role)
+				assert.True(t, errors.Is(err, errors.ErrAWSIAM))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, role)
+		})
+	}
+}
+
+func TestListPolicies(t *testing.T) {
+	client := &IAMClient{client: &MockIAMClient{
+		ListPoliciesFunc: func(ctx context.Context, params *iam.ListPoliciesInput, optFns ...func(*iam.Options)) (*iam.ListPoliciesOutput, error) {
+			assert.Equal(t, types.PolicyScopeTypeLocal, params.Scope)
+			return &iam.ListPoliciesOutput{Policies: []types.Policy{
+				{PolicyName: aws.String("logs"), Arn: aws.String("arn:aws:iam::123:policy/logs"), DefaultVersionId: aws.String("v2")},
+				{PolicyName: aws.String("no arn")},
+			}}, nil
+		},
+	}}
+	policies, err := client.ListPolicies(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.AWSPolicy{
+		{PolicyName: "logs", Arn: "arn:aws:iam::123:policy/logs", DefaultVersionId: "v2", Tags: map[string]string{}},
+	}, policies)
+}
+
+func TestGetPolicy(t *testing.T) {
+	document := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"logs:*","Resource":"*"}]}`
+
+	client := &IAMClient{client: &MockIAMClient{
+		GetPolicyFunc: func(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error) {
+			return &iam.GetPolicyOutput{Policy: &types.Policy{
+				PolicyName:       aws.String("logs"),
+				Arn:              params.PolicyArn,
+				Path:             aws.String("/"),
+				Description:      aws.String("log shipping"),
+				DefaultVersionId: aws.String("v2"),
+			}}, nil
+		},
+		GetPolicyVersionFunc: func(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error) {
+			assert.Equal(t, "v2", aws.ToString(params.VersionId))
+			return &iam.GetPolicyVersionOutput{PolicyVersion: &types.PolicyVersion{Document: aws.String(url.PathEscape(document))}}, nil
+		},
+	}}
+	policy, err := client.GetPolicy(context.Background(), "arn:aws:iam::123:policy/logs")
+
+	require.NoError(t, err)
+	assert.Equal(t, &models.AWSPolicy{
+		PolicyName:       "logs",
+		Arn:              "arn:aws:iam::123:policy/logs",
+		Path:             "/",
+		Description:      "log shipping",
+		DefaultVersionId: "v2",
+		Document:         document,
+		Tags:             map[string]string{},
+	}, policy)
+}
+
+func TestGetPolicy_Error(t *testing.T) {
+	client := &IAMClient{client: &MockIAMClient{
+		GetPolicyFunc: func(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error) {
+			return nil, fmt.Errorf("throttled")
+		},
+	}}
+	policy, err := client.GetPolicy(context.Background(), "arn:aws:iam::123:policy/logs")
+
+	assert.Nil(t, policy)
+	assert.True(t, errors.Is(err, errors.ErrAWSIAM))
+}
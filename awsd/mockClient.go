@@ -3,12 +3,198 @@ package awsd
 import (
 	"context"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 type MockEC2Client struct {
 	DescribeInstancesFunc func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumesFunc   func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
+
+	DescribeSecurityGroupsFunc   func(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+	DescribeVpcsFunc             func(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error)
+	DescribeSubnetsFunc          func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	DescribeRouteTablesFunc      func(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRouteTablesOutput, error)
+	DescribeInternetGatewaysFunc func(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error)
 }
 
 func (m *MockEC2Client) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
 	return m.DescribeInstancesFunc(ctx, params, optFns...)
 }
+
+// DescribeVolumes returns no volumes unless DescribeVolumesFunc is set
+func (m *MockEC2Client) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	if m.DescribeVolumesFunc == nil {
+		return &ec2.DescribeVolumesOutput{}, nil
+	}
+	return m.DescribeVolumesFunc(ctx, params, optFns...)
+}
+
+// DescribeSecurityGroups returns no security groups unless DescribeSecurityGroupsFunc is set
+func (m *MockEC2Client) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	if m.DescribeSecurityGroupsFunc == nil {
+		return &ec2.DescribeSecurityGroupsOutput{}, nil
+	}
+	return m.DescribeSecurityGroupsFunc(ctx, params, optFns...)
+}
+
+// DescribeVpcs returns no VPCs unless DescribeVpcsFunc is set
+func (m *MockEC2Client) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	if m.DescribeVpcsFunc == nil {
+		return &ec2.DescribeVpcsOutput{}, nil
+	}
+	return m.DescribeVpcsFunc(ctx, params, optFns...)
+}
+
+// DescribeSubnets returns no subnets unless DescribeSubnetsFunc is set
+func (m *MockEC2Client) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	if m.DescribeSubnetsFunc == nil {
+		return &ec2.DescribeSubnetsOutput{}, nil
+	}
+	return m.DescribeSubnetsFunc(ctx, params, optFns...)
+}
+
+// DescribeRouteTables returns no route tables unless DescribeRouteTablesFunc is set
+func (m *MockEC2Client) DescribeRouteTables(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRouteTablesOutput, error) {
+	if m.DescribeRouteTablesFunc == nil {
+		return &ec2.DescribeRouteTablesOutput{}, nil
+	}
+	return m.DescribeRouteTablesFunc(ctx, params, optFns...)
+}
+
+// DescribeInternetGateways returns no internet gateways unless DescribeInternetGatewaysFunc is set
+func (m *MockEC2Client) DescribeInternetGateways(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error) {
+	if m.DescribeInternetGatewaysFunc == nil {
+		return &ec2.DescribeInternetGatewaysOutput{}, nil
+	}
+	return m.DescribeInternetGatewaysFunc(ctx, params, optFns...)
+}
+
+// MockS3Client is a mock implementation of S3API. Each bucket call returns an empty
+// configuration unless its func is set.
+type MockS3Client struct {
+	ListBucketsFunc                     func(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error)
+	GetBucketTaggingFunc                func(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error)
+	GetBucketVersioningFunc             func(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error)
+	GetBucketEncryptionFunc             func(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error)
+	GetPublicAccessBlockFunc            func(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error)
+	GetBucketLifecycleConfigurationFunc func(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error)
+	GetBucketPolicyFunc                 func(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error)
+}
+
+func (m *MockS3Client) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return m.ListBucketsFunc(ctx, params, optFns...)
+}
+
+func (m *MockS3Client) GetBucketTagging(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+	if m.GetBucketTaggingFunc == nil {
+		return &s3.GetBucketTaggingOutput{}, nil
+	}
+	return m.GetBucketTaggingFunc(ctx, params, optFns...)
+}
+
+func (m *MockS3Client) GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+	if m.GetBucketVersioningFunc == nil {
+		return &s3.GetBucketVersioningOutput{}, nil
+	}
+	return m.GetBucketVersioningFunc(ctx, params, optFns...)
+}
+
+func (m *MockS3Client) GetBucketEncryption(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+	if m.GetBucketEncryptionFunc == nil {
+		return &s3.GetBucketEncryptionOutput{}, nil
+	}
+	return m.GetBucketEncryptionFunc(ctx, params, optFns...)
+}
+
+func (m *MockS3Client) GetPublicAccessBlock(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	if m.GetPublicAccessBlockFunc == nil {
+		return &s3.GetPublicAccessBlockOutput{}, nil
+	}
+	return m.GetPublicAccessBlockFunc(ctx, params, optFns...)
+}
+
+func (m *MockS3Client) GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	if m.GetBucketLifecycleConfigurationFunc == nil {
+		return &s3.GetBucketLifecycleConfigurationOutput{}, nil
+	}
+	return m.GetBucketLifecycleConfigurationFunc(ctx, params, optFns...)
+}
+
+func (m *MockS3Client) GetBucketPolicy(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	if m.GetBucketPolicyFunc == nil {
+		return &s3.GetBucketPolicyOutput{}, nil
+	}
+	return m.GetBucketPolicyFunc(ctx, params, optFns...)
+}
+
+// MockIAMClient is a mock implementation of IAMAPI. The list calls return nothing
+// unless their func is set.
+type MockIAMClient struct {
+	ListRolesFunc                func(ctx context.Context, params *iam.ListRolesInput, optFns ...func(*iam.Options)) (*iam.ListRolesOutput, error)
+	GetRoleFunc                  func(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error)
+	ListRolePoliciesFunc         func(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error)
+	GetRolePolicyFunc            func(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error)
+	ListAttachedRolePoliciesFunc func(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error)
+	ListPoliciesFunc             func(ctx context.Context, params *iam.ListPoliciesInput, optFns ...func(*iam.Options)) (*iam.ListPoliciesOutput, error)
+	GetPolicyFunc                func(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error)
+	GetPolicyVersionFunc         func(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error)
+}
+
+// ListRoles returns no roles unless ListRolesFunc is set
+func (m *MockIAMClient) ListRoles(ctx context.Context, params *iam.ListRolesInput, optFns ...func(*iam.Options)) (*iam.ListRolesOutput, error) {
+	if m.ListRolesFunc == nil {
+		return &iam.ListRolesOutput{}, nil
+	}
+	return m.ListRolesFunc(ctx, params, optFns...)
+}
+
+func (m *MockIAMClient) GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	return m.GetRoleFunc(ctx, params, optFns...)
+}
+
+// ListRolePolicies returns no inline policies unless ListRolePoliciesFunc is set
+func (m *MockIAMClient) ListRolePolicies(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error) {
+	if m.ListRolePoliciesFunc == nil {
+		return &iam.ListRolePoliciesOutput{}, nil
+	}
+	return m.ListRolePoliciesFunc(ctx, params, optFns...)
+}
+
+func (m *MockIAMClient) GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+	return m.GetRolePolicyFunc(ctx, params, optFns...)
+}
+
+// ListAttachedRolePolicies returns no attached policies unless ListAttachedRolePoliciesFunc is set
+func (m *MockIAMClient) ListAttachedRolePolicies(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
+	if m.ListAttachedRolePoliciesFunc == nil {
+		return &iam.ListAttachedRolePoliciesOutput{}, nil
+	}
+	return m.ListAttachedRolePoliciesFunc(ctx, params, optFns...)
+}
+
+// ListPolicies returns no policies unless ListPoliciesFunc is set
+func (m *MockIAMClient) ListPolicies(ctx context.Context, params *iam.ListPoliciesInput, optFns ...func(*iam.Options)) (*iam.ListPoliciesOutput, error) {
+	if m.ListPoliciesFunc == nil {
+		return &iam.ListPoliciesOutput{}, nil
+	}
+	return m.ListPoliciesFunc(ctx, params, optFns...)
+}
+
+func (m *MockIAMClient) GetPolicy(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error) {
+	return m.GetPolicyFunc(ctx, params, optFns...)
+}
+
+func (m *MockIAMClient) GetPolicyVersion(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error) {
+	return m.GetPolicyVersionFunc(ctx, params, optFns...)
+}
+
+// MockSTSClient is a mock implementation of STSAPI
+type MockSTSClient struct {
+	AssumeRoleFunc func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+}
+
+func (m *MockSTSClient) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	return m.AssumeRoleFunc(ctx, params, optFns...)
+}
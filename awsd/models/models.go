@@ -4,6 +4,9 @@ package models
 type AWSInstance struct {
 	InstanceID          string
 	InstanceType        string
+	State               string
+	RootDeviceName      string
+	VpcId               string
 	PrivateIP           string
 	PublicIP            string
 	KeyName             string
@@ -16,15 +19,64 @@ type AWSInstance struct {
 	Tags                map[string]string
 }
 
-// BlockDeviceMapping represents a block device mapping in AWS
+// IsTerminated reports whether the instance is gone or on its way out
+func (i *AWSInstance) IsTerminated() bool {
+	return i.State == "terminated" || i.State == "shutting-down"
+}
+
+// BlockDeviceMapping represents a block device mapping in AWS. DeleteOnTermination
+// comes from the instance; the volume fields are filled in from DescribeVolumes and
+// left empty when the volume couldn't be described.
 type BlockDeviceMapping struct {
-	DeviceName string
-	VolumeId   string
+	DeviceName          string
+	VolumeId            string
+	DeleteOnTermination bool
+	VolumeSize          int32
+	VolumeType          string
+	Iops                int32
+	Throughput          int32
+	Encrypted           bool
+	KmsKeyId            string
+}
+
+// HasVolumeDetails reports whether the volume attributes were filled in
+func (b *BlockDeviceMapping) HasVolumeDetails() bool {
+	return b.VolumeType != ""
 }
 
 // SecurityGroup represents a security group associated with an instance
 type SecurityGroup struct {
-	GroupId string
+	GroupId   string
+	GroupName string
+	VpcId     string
+}
+
+// AWSSecurityGroup represents a security group with its rules, as described by
+// DescribeSecurityGroups
+type AWSSecurityGroup struct {
+	GroupId     string
+	GroupName   string
+	Description string
+	VpcId       string
+	Ingress     []SecurityGroupRule
+	Egress      []SecurityGroupRule
+	Tags        map[string]string
+}
+
+// SecurityGroupRule is a single rule of a security group for one source or
+// destination. AWS groups the sources of a port range into one permission; awsd
+// splits them so each rule has exactly one of the source fields set and its own
+// description. FromPort and ToPort are -1 when AWS leaves them out, e.g. for all
+// protocols.
+type SecurityGroupRule struct {
+	Protocol          string
+	FromPort          int32
+	ToPort            int32
+	CidrIpv4          string
+	CidrIpv6          string
+	PrefixListId      string
+	ReferencedGroupId string
+	Description       string
 }
 
 // NetworkInterface represents a network interface associated with an instance
@@ -32,3 +84,131 @@ type NetworkInterface struct {
 	PrivateIpAddress string
 	PublicIpAddress  string
 }
+
+// AWSVpc represents a VPC. Ipv6CidrBlock is the first associated IPv6 block.
+type AWSVpc struct {
+	VpcId           string
+	CidrBlock       string
+	Ipv6CidrBlock   string
+	InstanceTenancy string
+	IsDefault       bool
+	Tags            map[string]string
+}
+
+// AWSSubnet represents a subnet of a VPC
+type AWSSubnet struct {
+	SubnetId            string
+	VpcId               string
+	CidrBlock           string
+	Ipv6CidrBlock       string
+	AvailabilityZone    string
+	MapPublicIpOnLaunch bool
+	DefaultForAz        bool
+	Tags                map[string]string
+}
+
+// AWSRouteTable represents a route table with its routes
+type AWSRouteTable struct {
+	RouteTableId string
+	VpcId        string
+	Routes       []Route
+	Tags         map[string]string
+}
+
+// Route is a single route of a route table. Destination is the IPv4 or IPv6 CIDR block
+// or prefix list the route matches; Target is the ID of the gateway, NAT gateway,
+// network interface or other resource it sends traffic to. Origin tells routes created
+// with the table (the local route) and propagated routes from the ones added by hand.
+type Route struct {
+	Destination string
+	Target      string
+	Origin      string
+	State       string
+}
+
+// AWSInternetGateway represents an internet gateway. VpcId is the attached VPC, empty
+// when the gateway is detached.
+type AWSInternetGateway struct {
+	InternetGatewayId string
+	VpcId             string
+	Tags              map[string]string
+}
+
+// AWSBucket represents an S3 bucket with the configuration drift is checked on.
+// Versioning is Enabled, Suspended or empty for a bucket that never had versioning;
+// MFADelete is Enabled, Disabled or empty. Settings the bucket doesn't have are left
+// empty: no encryption rules, a nil PublicAccessBlock, no lifecycle rules, no policy.
+type AWSBucket struct {
+	Name              string
+	Versioning        string
+	MFADelete         string
+	Encryption        []BucketEncryptionRule
+	PublicAccessBlock *BucketPublicAccessBlock
+	LifecycleRules    []BucketLifecycleRule
+	Policy            string
+	Tags              map[string]string
+}
+
+// BucketEncryptionRule is a default encryption rule of a bucket
+type BucketEncryptionRule struct {
+	SSEAlgorithm     string
+	KMSMasterKeyID   string
+	BucketKeyEnabled bool
+}
+
+// BucketPublicAccessBlock is the public access block configuration of a bucket
+type BucketPublicAccessBlock struct {
+	BlockPublicAcls       bool
+	BlockPublicPolicy     bool
+	IgnorePublicAcls      bool
+	RestrictPublicBuckets bool
+}
+
+// BucketLifecycleRule is a lifecycle rule of a bucket. Prefix is the rule's filter
+// prefix, or its legacy top-level prefix. The day counts are 0 when the action isn't
+// set.
+type BucketLifecycleRule struct {
+	ID                                 string
+	Status                             string
+	Prefix                             string
+	ExpirationDays                     int32
+	NoncurrentVersionExpirationDays    int32
+	AbortIncompleteMultipartUploadDays int32
+	Transitions                        []BucketLifecycleTransition
+}
+
+// BucketLifecycleTransition moves objects to StorageClass Days after creation
+type BucketLifecycleTransition struct {
+	Days         int32
+	StorageClass string
+}
+
+// AWSRole represents an IAM role. The policy documents are URL-decoded JSON.
+// InlinePolicies maps each inline policy name to its document; AttachedPolicyArns
+// lists the managed policies attached to the role. Both are only filled in by GetRole.
+type AWSRole struct {
+	RoleName           string
+	RoleId             string
+	Arn                string
+	Path               string
+	Description        string
+	MaxSessionDuration int32
+	AssumeRolePolicy   string
+	InlinePolicies     map[string]string
+	AttachedPolicyArns []string
+	Tags               map[string]string
+}
+
+// AWSPolicy represents a customer managed IAM policy. Document is the URL-decoded
+// JSON of the default version, only filled in by GetPolicy along with Description
+// and Tags.
+type AWSPolicy struct {
+	PolicyName       string
+	PolicyId         string
+	Arn              string
+	Path             string
+	Description      string
+	DefaultVersionId string
+	Document         string
+	Tags             map[string]string
+}
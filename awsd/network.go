@@ -0,0 +1,281 @@
+package awsd
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/errors"
+)
+
+// GetVpcs fetches every VPC visible to the client, following NextToken until all VPCs
+// have been read
+func (c *AWSClient) GetVpcs(ctx context.Context) ([]*models.AWSVpc, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetVpcs"),
+	)
+
+	vpcs := make([]*models.AWSVpc, 0)
+	var nextToken *string
+	for {
+		output, err := c.client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSNetwork, "failed to describe VPCs",
+				map[string]interface{}{
+					"operation": "describe_vpcs",
+				}, err)
+		}
+
+		for _, vpc := range output.Vpcs {
+			if vpc.VpcId == nil {
+				continue
+			}
+			vpcs = append(vpcs, parseVpc(vpc))
+		}
+
+		if aws.ToString(output.NextToken) == "" {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	logger.Info("AWS VPCs fetched successfully",
+		zap.String("operation", "describe_vpcs"),
+		zap.Int("vpc_count", len(vpcs)),
+	)
+	return vpcs, nil
+}
+
+// GetSubnets fetches every subnet visible to the client, following NextToken until all
+// subnets have been read
+func (c *AWSClient) GetSubnets(ctx context.Context) ([]*models.AWSSubnet, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetSubnets"),
+	)
+
+	subnets := make([]*models.AWSSubnet, 0)
+	var nextToken *string
+	for {
+		output, err := c.client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSNetwork, "failed to describe subnets",
+				map[string]interface{}{
+					"operation": "describe_subnets",
+				}, err)
+		}
+
+		for _, subnet := range output.Subnets {
+			if subnet.SubnetId == nil {
+				continue
+			}
+			subnets = append(subnets, parseSubnet(subnet))
+		}
+
+		if aws.ToString(output.NextToken) == "" {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	logger.Info("AWS subnets fetched successfully",
+		zap.String("operation", "describe_subnets"),
+		zap.Int("subnet_count", len(subnets)),
+	)
+	return subnets, nil
+}
+
+// GetRouteTables fetches every route table visible to the client with its routes,
+// following NextToken until all route tables have been read
+func (c *AWSClient) GetRouteTables(ctx context.Context) ([]*models.AWSRouteTable, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetRouteTables"),
+	)
+
+	routeTables := make([]*models.AWSRouteTable, 0)
+	var nextToken *string
+	for {
+		output, err := c.client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSNetwork, "failed to describe route tables",
+				map[string]interface{}{
+					"operation": "describe_route_tables",
+				}, err)
+		}
+
+		for _, routeTable := range output.RouteTables {
+			if routeTable.RouteTableId == nil {
+				continue
+			}
+			routeTables = append(routeTables, parseRouteTable(routeTable))
+		}
+
+		if aws.ToString(output.NextToken) == "" {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	logger.Info("AWS route tables fetched successfully",
+		zap.String("operation", "describe_route_tables"),
+		zap.Int("route_table_count", len(routeTables)),
+	)
+	return routeTables, nil
+}
+
+// GetInternetGateways fetches every internet gateway visible to the client, following
+// NextToken until all gateways have been read
+func (c *AWSClient) GetInternetGateways(ctx context.Context) ([]*models.AWSInternetGateway, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetInternetGateways"),
+	)
+
+	gateways := make([]*models.AWSInternetGateway, 0)
+	var nextToken *string
+	for {
+		output, err := c.client.DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSNetwork, "failed to describe internet gateways",
+				map[string]interface{}{
+					"operation": "describe_internet_gateways",
+				}, err)
+		}
+
+		for _, gateway := range output.InternetGateways {
+			if gateway.InternetGatewayId == nil {
+				continue
+			}
+			gateways = append(gateways, parseInternetGateway(gateway))
+		}
+
+		if aws.ToString(output.NextToken) == "" {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	logger.Info("AWS internet gateways fetched successfully",
+		zap.String("operation", "describe_internet_gateways"),
+		zap.Int("internet_gateway_count", len(gateways)),
+	)
+	return gateways, nil
+}
+
+// parseVpc maps an EC2 VPC onto the AWSVpc model
+func parseVpc(vpc types.Vpc) *models.AWSVpc {
+	var ipv6CidrBlock string
+	for _, association := range vpc.Ipv6CidrBlockAssociationSet {
+		if association.Ipv6CidrBlockState != nil && association.Ipv6CidrBlockState.State == types.VpcCidrBlockStateCodeAssociated {
+			ipv6CidrBlock = aws.ToString(association.Ipv6CidrBlock)
+			break
+		}
+	}
+
+	return &models.AWSVpc{
+		VpcId:           aws.ToString(vpc.VpcId),
+		CidrBlock:       aws.ToString(vpc.CidrBlock),
+		Ipv6CidrBlock:   ipv6CidrBlock,
+		InstanceTenancy: string(vpc.InstanceTenancy),
+		IsDefault:       aws.ToBool(vpc.IsDefault),
+		Tags:            parseTags(vpc.Tags),
+	}
+}
+
+// parseSubnet maps an EC2 subnet onto the AWSSubnet model
+func parseSubnet(subnet types.Subnet) *models.AWSSubnet {
+	var ipv6CidrBlock string
+	for _, association := range subnet.Ipv6CidrBlockAssociationSet {
+		if association.Ipv6CidrBlockState != nil && association.Ipv6CidrBlockState.State == types.SubnetCidrBlockStateCodeAssociated {
+			ipv6CidrBlock = aws.ToString(association.Ipv6CidrBlock)
+			break
+		}
+	}
+
+	return &models.AWSSubnet{
+		SubnetId:            aws.ToString(subnet.SubnetId),
+		VpcId:               aws.ToString(subnet.VpcId),
+		CidrBlock:           aws.ToString(subnet.CidrBlock),
+		Ipv6CidrBlock:       ipv6CidrBlock,
+		AvailabilityZone:    aws.ToString(subnet.AvailabilityZone),
+		MapPublicIpOnLaunch: aws.ToBool(subnet.MapPublicIpOnLaunch),
+		DefaultForAz:        aws.ToBool(subnet.DefaultForAz),
+		Tags:                parseTags(subnet.Tags),
+	}
+}
+
+// parseRouteTable maps an EC2 route table onto the AWSRouteTable model
+func parseRouteTable(routeTable types.RouteTable) *models.AWSRouteTable {
+	routes := make([]models.Route, 0, len(routeTable.Routes))
+	for _, route := range routeTable.Routes {
+		routes = append(routes, models.Route{
+			Destination: firstNonEmpty(route.DestinationCidrBlock, route.DestinationIpv6CidrBlock, route.DestinationPrefixListId),
+			Target: firstNonEmpty(route.GatewayId, route.NatGatewayId, route.TransitGatewayId,
+				route.VpcPeeringConnectionId, route.EgressOnlyInternetGatewayId, route.CarrierGatewayId,
+				route.LocalGatewayId, route.NetworkInterfaceId, route.CoreNetworkArn, route.InstanceId),
+			Origin: string(route.Origin),
+			State:  string(route.State),
+		})
+	}
+
+	return &models.AWSRouteTable{
+		RouteTableId: aws.ToString(routeTable.RouteTableId),
+		VpcId:        aws.ToString(routeTable.VpcId),
+		Routes:       routes,
+		Tags:         parseTags(routeTable.Tags),
+	}
+}
+
+// parseInternetGateway maps an EC2 internet gateway onto the AWSInternetGateway model
+func parseInternetGateway(gateway types.InternetGateway) *models.AWSInternetGateway {
+	// DescribeInternetGateways reports attached gateways as "available" rather than
+	// "attached"
+	var vpcID string
+	for _, attachment := range gateway.Attachments {
+		if attachment.State == types.AttachmentStatusAttached || attachment.State == "available" {
+			vpcID = aws.ToString(attachment.VpcId)
+			break
+		}
+	}
+
+	return &models.AWSInternetGateway{
+		InternetGatewayId: aws.ToString(gateway.InternetGatewayId),
+		VpcId:             vpcID,
+		Tags:              parseTags(gateway.Tags),
+	}
+}
+
+// parseTags maps EC2 tags onto a map, skipping incomplete tags
+func parseTags(tags []types.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag.Key != nil && tag.Value != nil {
+			result[*tag.Key] = *tag.Value
+		}
+	}
+	return result
+}
+
+// firstNonEmpty returns the first set value
+func firstNonEmpty(values ...*string) string {
+	for _, value := range values {
+		if s := aws.ToString(value); s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package awsd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/errors"
+)
+
+func TestGetVpcs(t *testing.T) {
+	pages := map[string]*ec2.DescribeVpcsOutput{
+		"": {
+			Vpcs: []types.Vpc{
+				{
+					VpcId:           aws.String("vpc-1"),
+					CidrBlock:       aws.String("10.0.0.0/16"),
+					InstanceTenancy: types.TenancyDefault,
+					Ipv6CidrBlockAssociationSet: []types.VpcIpv6CidrBlockAssociation{
+						{Ipv6CidrBlock: aws.String("2600:1f18::/56"), Ipv6CidrBlockState: &types.VpcCidrBlockState{State: types.VpcCidrBlockStateCodeDisassociated}},
+						{Ipv6CidrBlock: aws.String("2600:1f19::/56"), Ipv6CidrBlockState: &types.VpcCidrBlockState{State: types.VpcCidrBlockStateCodeAssociated}},
+					},
+					Tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String("main")}},
+				},
+				{CidrBlock: aws.String("no id")},
+			},
+			NextToken: aws.String("token-2"),
+		},
+		"token-2": {
+			Vpcs: []types.Vpc{{VpcId: aws.String("vpc-default"), IsDefault: aws.Bool(true)}},
+		},
+	}
+
+	client := &AWSClient{client: &MockEC2Client{
+		DescribeVpcsFunc: func(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+			return pages[aws.ToString(params.NextToken)], nil
+		},
+	}}
+	vpcs, err := client.GetVpcs(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.AWSVpc{
+		{VpcId: "vpc-1", CidrBlock: "10.0.0.0/16", Ipv6CidrBlock: "2600:1f19::/56", InstanceTenancy: "default", Tags: map[string]string{"Name": "main"}},
+		{VpcId: "vpc-default", IsDefault: true, Tags: map[string]string{}},
+	}, vpcs)
+}
+
+func TestGetSubnets(t *testing.T) {
+	client := &AWSClient{client: &MockEC2Client{
+		DescribeSubnetsFunc: func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+			return &ec2.DescribeSubnetsOutput{Subnets: []types.Subnet{
+				{
+					SubnetId:            aws.String("subnet-1"),
+					VpcId:               aws.String("vpc-1"),
+					CidrBlock:           aws.String("10.0.1.0/24"),
+					AvailabilityZone:    aws.String("us-east-1a"),
+					MapPublicIpOnLaunch: aws.Bool(true),
+				},
+			}}, nil
+		},
+	}}
+	subnets, err := client.GetSubnets(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.AWSSubnet{
+		{SubnetId: "subnet-1", VpcId: "vpc-1", CidrBlock: "10.0.1.0/24", AvailabilityZone: "us-east-1a", MapPublicIpOnLaunch: true, Tags: map[string]string{}},
+	}, subnets)
+}
+
+func TestGetRouteTables(t *testing.T) {
+	client := &AWSClient{client: &MockEC2Client{
+		DescribeRouteTablesFunc: func(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRouteTablesOutput, error) {
+			return &ec2.DescribeRouteTablesOutput{RouteTables: []types.RouteTable{
+				{
+					RouteTableId: aws.String("rtb-1"),
+					VpcId:        aws.String("vpc-1"),
+					Routes: []types.Route{
+						{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local"), Origin: types.RouteOriginCreateRouteTable, State: types.RouteStateActive},
+						{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1"), Origin: types.RouteOriginCreateRoute, State: types.RouteStateActive},
+						{DestinationIpv6CidrBlock: aws.String("::/0"), EgressOnlyInternetGatewayId: aws.String("eigw-1"), Origin: types.RouteOriginCreateRoute},
+						{DestinationPrefixListId: aws.String("pl-1"), GatewayId: aws.String("vpce-1"), Origin: types.RouteOriginCreateRoute},
+						{DestinationCidrBlock: aws.String("192.168.0.0/16"), InstanceId: aws.String("i-nat"), NetworkInterfaceId: aws.String("eni-1"), Origin: types.RouteOriginCreateRoute, State: types.RouteStateBlackhole},
+					},
+				},
+			}}, nil
+		},
+	}}
+	routeTables, err := client.GetRouteTables(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, routeTables, 1)
+	assert.Equal(t, []models.Route{
+		{Destination: "10.0.0.0/16", Target: "local", Origin: "CreateRouteTable", State: "active"},
+		{Destination: "0.0.0.0/0", Target: "nat-1", Origin: "CreateRoute", State: "active"},
+		{Destination: "::/0", Target: "eigw-1", Origin: "CreateRoute"},
+		{Destination: "pl-1", Target: "vpce-1", Origin: "CreateRoute"},
+		{Destination: "192.168.0.0/16", Target: "eni-1", Origin: "CreateRoute", State: "blackhole"},
+	}, routeTables[0].Routes)
+}
+
+func TestGetInternetGateways(t *testing.T) {
+	client := &AWSClient{client: &MockEC2Client{
+		DescribeInternetGatewaysFunc: func(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error) {
+			return &ec2.DescribeInternetGatewaysOutput{InternetGateways: []types.InternetGateway{
+				{InternetGatewayId: aws.String("igw-1"), Attachments: []types.InternetGatewayAttachment{{VpcId: aws.String("vpc-1"), State: "available"}}},
+				{InternetGatewayId: aws.String("igw-2"), Attachments: []types.InternetGatewayAttachment{{VpcId: aws.String("vpc-1"), State: types.AttachmentStatusDetaching}}},
+			}}, nil
+		},
+	}}
+	gateways, err := client.GetInternetGateways(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.AWSInternetGateway{
+		{InternetGatewayId: "igw-1", VpcId: "vpc-1", Tags: map[string]string{}},
+		{InternetGatewayId: "igw-2", Tags: map[string]string{}},
+	}, gateways)
+}
+
+func TestGetNetwork_Errors(t *testing.T) {
+	describeErr := fmt.Errorf("UnauthorizedOperation")
+	client := &AWSClient{client: &MockEC2Client{
+		DescribeVpcsFunc: func(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+			return nil, describeErr
+		},
+		DescribeSubnetsFunc: func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+			return nil, describeErr
+		},
+		DescribeRouteTablesFunc: func(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRouteTablesOutput, error) {
+			return nil, describeErr
+		},
+		DescribeInternetGatewaysFunc: func(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error) {
+			return nil, describeErr
+		},
+	}}
+
+	_, err := client.GetVpcs(context.Background())
+	assert.True(t, errors.Is(err, errors.ErrAWSNetwork))
+	_, err = client.GetSubnets(context.Background())
+	assert.True(t, errors.Is(err, errors.ErrAWSNetwork))
+	_, err = client.GetRouteTables(context.Background())
+	assert.True(t, errors.Is(err, errors.ErrAWSNetwork))
+	_, err = client.GetInternetGateways(context.Background())
+	assert.True(t, errors.Is(err, errors.ErrAWSNetwork))
+}
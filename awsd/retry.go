@@ -0,0 +1,167 @@
+package awsd
+
+import (
+	"context"
+	stderrors "errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/configuration"
+)
+
+// maxRetryDelay caps the backoff between two attempts
+const maxRetryDelay = 2 * time.Minute
+
+// transientErrorCodes are the API error codes of server-side failures, retried along
+// with the SDK's throttling and timeout codes
+var transientErrorCodes = map[string]struct{}{
+	"InternalError":               {},
+	"InternalFailure":             {},
+	"ServiceUnavailable":          {},
+	"ServiceUnavailableException": {},
+	"Unavailable":                 {},
+	"EC2ThrottledException":       {},
+}
+
+// retryables classifies the errors worth another attempt: throttling, 5xx and 429
+// responses, transient server errors and network failures. Anything else, such as an
+// authorization or validation error, fails on the first attempt.
+var retryables = retry.IsErrorRetryables{
+	retry.NoRetryCanceledError{},
+	retry.RetryableError{},
+	retry.RetryableConnectionError{},
+	retry.IsErrorRetryableFunc(retryableStatusCode),
+	retry.RetryableErrorCode{Codes: retry.DefaultRetryableErrorCodes},
+	retry.RetryableErrorCode{Codes: retry.DefaultThrottleErrorCodes},
+	retry.RetryableErrorCode{Codes: transientErrorCodes},
+}
+
+// retrier retries AWS calls that fail with a retryable error, waiting an exponential
+// backoff with jitter between attempts. The SDK's own retryer is disabled, so this is
+// the only retry layer.
+type retrier struct {
+	maxRetries int
+	delay      time.Duration
+	// sleep waits between attempts; tests replace it to run without delays
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newRetrier creates a retrier from MAX_RETRIES and RETRY_DELAY_SECONDS
+func newRetrier(conf *configuration.Config) *retrier {
+	return &retrier{
+		maxRetries: conf.MaxRetries,
+		delay:      time.Duration(conf.RetryDelay) * time.Second,
+		sleep:      sleepContext,
+	}
+}
+
+// retryCall makes an AWS call, retrying it up to maxRetries times while it fails with
+// a retryable error. It returns the outcome of the last attempt.
+func retryCall[T any](ctx context.Context, r *retrier, operation string, call func(ctx context.Context) (T, error)) (T, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "retryCall"),
+		zap.String("operation", operation),
+	)
+
+	attempts := r.maxRetries + 1
+	for attempt := 1; ; attempt++ {
+		output, err := call(ctx)
+		if err == nil {
+			logger.Debug("AWS call succeeded",
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", attempts),
+			)
+			return output, nil
+		}
+
+		retryable := isRetryable(err)
+		if !retryable || attempt >= attempts || ctx.Err() != nil {
+			logger.Warn("AWS call failed",
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", attempts),
+				zap.Bool("retryable", retryable),
+				zap.String("error_code", errorCode(err)),
+				zap.Error(err),
+			)
+			return output, err
+		}
+
+		delay := r.backoff(attempt)
+		logger.Warn("AWS call failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			zap.Duration("delay", delay),
+			zap.String("error_code", errorCode(err)),
+			zap.Error(err),
+		)
+		if sleepErr := r.sleep(ctx, delay); sleepErr != nil {
+			return output, err
+		}
+	}
+}
+
+// backoff returns the wait after a failed attempt: the base delay doubled for every
+// attempt, capped at maxRetryDelay, with jitter over its upper half
+func (r *retrier) backoff(attempt int) time.Duration {
+	delay := r.delay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1)
+}
+
+// isRetryable reports whether a failed call is worth another attempt. A cancelled or
+// expired context never is.
+func isRetryable(err error) bool {
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return retryables.IsErrorRetryable(err) == aws.TrueTernary
+}
+
+// retryableStatusCode retries every 5xx response and 429 Too Many Requests
+func retryableStatusCode(err error) aws.Ternary {
+	var response interface{ HTTPStatusCode() int }
+	if !stderrors.As(err, &response) {
+		return aws.UnknownTernary
+	}
+	status := response.HTTPStatusCode()
+	if status >= 500 || status == 429 {
+		return aws.TrueTernary
+	}
+	return aws.UnknownTernary
+}
+
+// errorCode returns the API error code of an error, or "" when it has none
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if stderrors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+// sleepContext waits for d, returning early with the context's error when it's done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,172 @@
+package awsd
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// retryingEC2 retries the calls of an EC2API (see retryCall)
+type retryingEC2 struct {
+	api     EC2API
+	retrier *retrier
+}
+
+func (c *retryingEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return retryCall(ctx, c.retrier, "DescribeInstances", func(ctx context.Context) (*ec2.DescribeInstancesOutput, error) {
+		return c.api.DescribeInstances(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingEC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return retryCall(ctx, c.retrier, "DescribeVolumes", func(ctx context.Context) (*ec2.DescribeVolumesOutput, error) {
+		return c.api.DescribeVolumes(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingEC2) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return retryCall(ctx, c.retrier, "DescribeSecurityGroups", func(ctx context.Context) (*ec2.DescribeSecurityGroupsOutput, error) {
+		return c.api.DescribeSecurityGroups(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingEC2) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return retryCall(ctx, c.retrier, "DescribeVpcs", func(ctx context.Context) (*ec2.DescribeVpcsOutput, error) {
+		return c.api.DescribeVpcs(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return retryCall(ctx, c.retrier, "DescribeSubnets", func(ctx context.Context) (*ec2.DescribeSubnetsOutput, error) {
+		return c.api.DescribeSubnets(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingEC2) DescribeRouteTables(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRouteTablesOutput, error) {
+	return retryCall(ctx, c.retrier, "DescribeRouteTables", func(ctx context.Context) (*ec2.DescribeRouteTablesOutput, error) {
+		return c.api.DescribeRouteTables(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingEC2) DescribeInternetGateways(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error) {
+	return retryCall(ctx, c.retrier, "DescribeInternetGateways", func(ctx context.Context) (*ec2.DescribeInternetGatewaysOutput, error) {
+		return c.api.DescribeInternetGateways(ctx, params, optFns...)
+	})
+}
+
+// retryingS3 retries the calls of an S3API (see retryCall)
+type retryingS3 struct {
+	api     S3API
+	retrier *retrier
+}
+
+func (c *retryingS3) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return retryCall(ctx, c.retrier, "ListBuckets", func(ctx context.Context) (*s3.ListBucketsOutput, error) {
+		return c.api.ListBuckets(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingS3) GetBucketTagging(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+	return retryCall(ctx, c.retrier, "GetBucketTagging", func(ctx context.Context) (*s3.GetBucketTaggingOutput, error) {
+		return c.api.GetBucketTagging(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingS3) GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+	return retryCall(ctx, c.retrier, "GetBucketVersioning", func(ctx context.Context) (*s3.GetBucketVersioningOutput, error) {
+		return c.api.GetBucketVersioning(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingS3) GetBucketEncryption(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+	return retryCall(ctx, c.retrier, "GetBucketEncryption", func(ctx context.Context) (*s3.GetBucketEncryptionOutput, error) {
+		return c.api.GetBucketEncryption(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingS3) GetPublicAccessBlock(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	return retryCall(ctx, c.retrier, "GetPublicAccessBlock", func(ctx context.Context) (*s3.GetPublicAccessBlockOutput, error) {
+		return c.api.GetPublicAccessBlock(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingS3) GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	return retryCall(ctx, c.retrier, "GetBucketLifecycleConfiguration", func(ctx context.Context) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+		return c.api.GetBucketLifecycleConfiguration(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingS3) GetBucketPolicy(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	return retryCall(ctx, c.retrier, "GetBucketPolicy", func(ctx context.Context) (*s3.GetBucketPolicyOutput, error) {
+		return c.api.GetBucketPolicy(ctx, params, optFns...)
+	})
+}
+
+// retryingIAM retries the calls of an IAMAPI (see retryCall)
+type retryingIAM struct {
+	api     IAMAPI
+	retrier *retrier
+}
+
+func (c *retryingIAM) ListRoles(ctx context.Context, params *iam.ListRolesInput, optFns ...func(*iam.Options)) (*iam.ListRolesOutput, error) {
+	return retryCall(ctx, c.retrier, "ListRoles", func(ctx context.Context) (*iam.ListRolesOutput, error) {
+		return c.api.ListRoles(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingIAM) GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	return retryCall(ctx, c.retrier, "GetRole", func(ctx context.Context) (*iam.GetRoleOutput, error) {
+		return c.api.GetRole(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingIAM) ListRolePolicies(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error) {
+	return retryCall(ctx, c.retrier, "ListRolePolicies", func(ctx context.Context) (*iam.ListRolePoliciesOutput, error) {
+		return c.api.ListRolePolicies(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingIAM) GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+	return retryCall(ctx, c.retrier, "GetRolePolicy", func(ctx context.Context) (*iam.GetRolePolicyOutput, error) {
+		return c.api.GetRolePolicy(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingIAM) ListAttachedRolePolicies(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
+	return retryCall(ctx, c.retrier, "ListAttachedRolePolicies", func(ctx context.Context) (*iam.ListAttachedRolePoliciesOutput, error) {
+		return c.api.ListAttachedRolePolicies(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingIAM) ListPolicies(ctx context.Context, params *iam.ListPoliciesInput, optFns ...func(*iam.Options)) (*iam.ListPoliciesOutput, error) {
+	return retryCall(ctx, c.retrier, "ListPolicies", func(ctx context.Context) (*iam.ListPoliciesOutput, error) {
+		return c.api.ListPolicies(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingIAM) GetPolicy(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error) {
+	return retryCall(ctx, c.retrier, "GetPolicy", func(ctx context.Context) (*iam.GetPolicyOutput, error) {
+		return c.api.GetPolicy(ctx, params, optFns...)
+	})
+}
+
+func (c *retryingIAM) GetPolicyVersion(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error) {
+	return retryCall(ctx, c.retrier, "GetPolicyVersion", func(ctx context.Context) (*iam.GetPolicyVersionOutput, error) {
+		return c.api.GetPolicyVersion(ctx, params, optFns...)
+	})
+}
+
+// retryingSTS retries the calls of an STSAPI (see retryCall)
+type retryingSTS struct {
+	api     STSAPI
+	retrier *retrier
+}
+
+func (c *retryingSTS) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	return retryCall(ctx, c.retrier, "AssumeRole", func(ctx context.Context) (*sts.AssumeRoleOutput, error) {
+		return c.api.AssumeRole(ctx, params, optFns...)
+	})
+}
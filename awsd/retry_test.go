@@ -0,0 +1,150 @@
+package awsd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Savannahtakehomeassi/errors"
+)
+
+// statusError is a response error with the given HTTP status code
+func statusError(code int) error {
+	return &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: code}},
+		Err:      fmt.Errorf("status %d", code),
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"EC2 throttling", &smithy.GenericAPIError{Code: "RequestLimitExceeded"}, true},
+		{"throttling", &smithy.GenericAPIError{Code: "Throttling"}, true},
+		{"internal error", &smithy.GenericAPIError{Code: "InternalError"}, true},
+		{"503 response", statusError(503), true},
+		{"507 response", statusError(507), true},
+		{"429 response", statusError(429), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}, true},
+		{"connection reset", fmt.Errorf("read tcp: connection reset by peer"), true},
+		{"unauthorized", &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, false},
+		{"invalid credentials", &smithy.GenericAPIError{Code: "AuthFailure"}, false},
+		{"validation error", &smithy.GenericAPIError{Code: "InvalidParameterValue"}, false},
+		{"403 response", statusError(403), false},
+		{"cancelled", context.Canceled, false},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, isRetryable(tt.err))
+		})
+	}
+}
+
+func TestRetrier_Backoff(t *testing.T) {
+	r := &retrier{delay: time.Second}
+	for attempt, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 20: maxRetryDelay} {
+		for i := 0; i < 20; i++ {
+			delay := r.backoff(attempt)
+			assert.GreaterOrEqual(t, delay, base/2)
+			assert.LessOrEqual(t, delay, base)
+		}
+	}
+}
+
+func TestGetAWSInstances_Retry(t *testing.T) {
+	instances := &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{
+		Instances: []types.Instance{{InstanceId: aws.String("i-1")}},
+	}}}
+
+	tests := []struct {
+		name          string
+		errs          []error
+		expectedCalls int
+		expectedError bool
+	}{
+		{
+			name:          "succeeds after throttling and a server error",
+			errs:          []error{&smithy.GenericAPIError{Code: "RequestLimitExceeded"}, statusError(500)},
+			expectedCalls: 3,
+		},
+		{
+			name:          "gives up after the retries",
+			errs:          []error{statusError(503), statusError(503), statusError(503), statusError(503)},
+			expectedCalls: 4,
+			expectedError: true,
+		},
+		{
+			name:          "auth errors are not retried",
+			errs:          []error{&smithy.GenericAPIError{Code: "UnauthorizedOperation"}},
+			expectedCalls: 1,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mock := &MockEC2Client{
+				DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+					calls++
+					if calls <= len(tt.errs) {
+						return nil, tt.errs[calls-1]
+					}
+					return instances, nil
+				},
+			}
+			var delays []time.Duration
+			client := &AWSClient{client: &retryingEC2{api: mock, retrier: &retrier{
+				maxRetries: 3,
+				delay:      time.Second,
+				sleep: func(ctx context.Context, d time.Duration) error {
+					delays = append(delays, d)
+					return nil
+				},
+			}}}
+
+			result, err := client.GetAWSInstances(context.Background())
+
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.Len(t, delays, min(tt.expectedCalls-1, 3))
+			if tt.expectedError {
+				assert.Nil(t, result)
+				assert.True(t, errors.Is(err, errors.ErrAWSInstance))
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, result, 1)
+		})
+	}
+}
+
+func TestRetryCall_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	r := &retrier{maxRetries: 5, delay: time.Hour, sleep: sleepContext}
+
+	_, err := retryCall(ctx, r, "DescribeInstances", func(ctx context.Context) (*ec2.DescribeInstancesOutput, error) {
+		calls++
+		cancel()
+		return nil, statusError(503)
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,261 @@
+package awsd
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/configuration"
+	"Savannahtakehomeassi/errors"
+)
+
+// Error codes S3 returns when a bucket doesn't have a configuration at all. They mean
+// the setting is empty, not that the read failed.
+var s3NotConfiguredCodes = map[string]bool{
+	"NoSuchTagSet": true,
+	"ServerSideEncryptionConfigurationNotFoundError": true,
+	"NoSuchPublicAccessBlockConfiguration":           true,
+	"NoSuchLifecycleConfiguration":                   true,
+	"NoSuchBucketPolicy":                             true,
+}
+
+// S3Client reads the buckets of one region. A client without a region lists the
+// buckets of every region.
+type S3Client struct {
+	client S3API
+	region string
+}
+
+// NewS3Client creates a new S3 client for the configured AWS_REGION
+func NewS3Client(conf *configuration.Config) (*S3Client, error) {
+	if conf == nil {
+		return nil, fmt.Errorf("configuration cannot be nil")
+	}
+	return NewS3ClientForRegion(conf, conf.AWSRegion, nil)
+}
+
+// NewS3ClientForRegion creates a new S3 client for one of the scanned regions of an
+// account; creds is nil for the static keys of the configuration. Requests use
+// path-style addressing so bucket names don't have to resolve as LocalStack
+// subdomains.
+func NewS3ClientForRegion(conf *configuration.Config, region string, creds aws.CredentialsProvider) (*S3Client, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "NewS3ClientForRegion"),
+		zap.String("region", region),
+	)
+
+	cfg, err := loadAWSConfig(conf, region, creds)
+	if err != nil {
+		logger.Error("Failed to create S3 client",
+			zap.String("operation", "client_creation"),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	logger.Info("S3 client created successfully")
+	return &S3Client{
+		client: &retryingS3{
+			api: s3.NewFromConfig(cfg, func(o *s3.Options) {
+				o.UsePathStyle = true
+			}),
+			retrier: newRetrier(conf),
+		},
+		region: region,
+	}, nil
+}
+
+// ListBuckets returns the names of the buckets owned by the account in the client's
+// region, following ContinuationToken until all buckets have been read
+func (c *S3Client) ListBuckets(ctx context.Context) ([]string, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "ListBuckets"),
+	)
+
+	names := make([]string, 0)
+	var token *string
+	for {
+		input := &s3.ListBucketsInput{ContinuationToken: token}
+		if c.region != "" {
+			input.BucketRegion = aws.String(c.region)
+		}
+		output, err := c.client.ListBuckets(ctx, input)
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSS3, "failed to list buckets",
+				map[string]interface{}{
+					"operation": "list_buckets",
+				}, err)
+		}
+
+		for _, bucket := range output.Buckets {
+			if bucket.Name == nil {
+				continue
+			}
+			names = append(names, *bucket.Name)
+		}
+
+		if aws.ToString(output.ContinuationToken) == "" {
+			break
+		}
+		token = output.ContinuationToken
+	}
+
+	logger.Info("S3 buckets listed successfully",
+		zap.String("operation", "list_buckets"),
+		zap.Int("bucket_count", len(names)),
+	)
+	return names, nil
+}
+
+// GetBucket reads the tags, versioning, encryption, public access block, lifecycle
+// rules and policy of a bucket
+func (c *S3Client) GetBucket(ctx context.Context, name string) (*models.AWSBucket, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetBucket"),
+		zap.String("bucket", name),
+	)
+
+	bucket := &models.AWSBucket{Name: name, Tags: map[string]string{}}
+
+	tagging, err := c.client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: aws.String(name)})
+	if err = bucketReadError(name, "get_bucket_tagging", err); err != nil {
+		return nil, err
+	}
+	if tagging != nil {
+		for _, tag := range tagging.TagSet {
+			if tag.Key != nil && tag.Value != nil {
+				bucket.Tags[*tag.Key] = *tag.Value
+			}
+		}
+	}
+
+	versioning, err := c.client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(name)})
+	if err = bucketReadError(name, "get_bucket_versioning", err); err != nil {
+		return nil, err
+	}
+	if versioning != nil {
+		bucket.Versioning = string(versioning.Status)
+		bucket.MFADelete = string(versioning.MFADelete)
+	}
+
+	encryption, err := c.client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(name)})
+	if err = bucketReadError(name, "get_bucket_encryption", err); err != nil {
+		return nil, err
+	}
+	if encryption != nil && encryption.ServerSideEncryptionConfiguration != nil {
+		bucket.Encryption = parseEncryptionRules(encryption.ServerSideEncryptionConfiguration.Rules)
+	}
+
+	publicAccess, err := c.client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(name)})
+	if err = bucketReadError(name, "get_public_access_block", err); err != nil {
+		return nil, err
+	}
+	if publicAccess != nil && publicAccess.PublicAccessBlockConfiguration != nil {
+		block := publicAccess.PublicAccessBlockConfiguration
+		bucket.PublicAccessBlock = &models.BucketPublicAccessBlock{
+			BlockPublicAcls:       aws.ToBool(block.BlockPublicAcls),
+			BlockPublicPolicy:     aws.ToBool(block.BlockPublicPolicy),
+			IgnorePublicAcls:      aws.ToBool(block.IgnorePublicAcls),
+			RestrictPublicBuckets: aws.ToBool(block.RestrictPublicBuckets),
+		}
+	}
+
+	lifecycle, err := c.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(name)})
+	if err = bucketReadError(name, "get_bucket_lifecycle_configuration", err); err != nil {
+		return nil, err
+	}
+	if lifecycle != nil {
+		bucket.LifecycleRules = parseLifecycleRules(lifecycle.Rules)
+	}
+
+	policy, err := c.client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(name)})
+	if err = bucketReadError(name, "get_bucket_policy", err); err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		bucket.Policy = aws.ToString(policy.Policy)
+	}
+
+	logger.Info("S3 bucket described successfully",
+		zap.String("operation", "describe_bucket"),
+	)
+	return bucket, nil
+}
+
+// bucketReadError wraps the error of a bucket configuration read. Errors meaning the
+// bucket doesn't have the configuration are dropped.
+func bucketReadError(bucket, operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr smithy.APIError
+	if stderrors.As(err, &apiErr) && s3NotConfiguredCodes[apiErr.ErrorCode()] {
+		return nil
+	}
+	return errors.New(errors.ErrAWSS3, "failed to read bucket configuration",
+		map[string]interface{}{
+			"operation": operation,
+			"bucket":    bucket,
+		}, err)
+}
+
+// parseEncryptionRules maps the default encryption rules of a bucket
+func parseEncryptionRules(rules []types.ServerSideEncryptionRule) []models.BucketEncryptionRule {
+	result := make([]models.BucketEncryptionRule, 0, len(rules))
+	for _, rule := range rules {
+		parsed := models.BucketEncryptionRule{
+			BucketKeyEnabled: aws.ToBool(rule.BucketKeyEnabled),
+		}
+		if byDefault := rule.ApplyServerSideEncryptionByDefault; byDefault != nil {
+			parsed.SSEAlgorithm = string(byDefault.SSEAlgorithm)
+			parsed.KMSMasterKeyID = aws.ToString(byDefault.KMSMasterKeyID)
+		}
+		result = append(result, parsed)
+	}
+	return result
+}
+
+// parseLifecycleRules maps the lifecycle rules of a bucket
+func parseLifecycleRules(rules []types.LifecycleRule) []models.BucketLifecycleRule {
+	result := make([]models.BucketLifecycleRule, 0, len(rules))
+	for _, rule := range rules {
+		parsed := models.BucketLifecycleRule{
+			ID:     aws.ToString(rule.ID),
+			Status: string(rule.Status),
+			Prefix: aws.ToString(rule.Prefix),
+		}
+		if rule.Filter != nil {
+			parsed.Prefix = firstNonEmpty(rule.Filter.Prefix, rule.Prefix)
+			if rule.Filter.And != nil && parsed.Prefix == "" {
+				parsed.Prefix = aws.ToString(rule.Filter.And.Prefix)
+			}
+		}
+		if rule.Expiration != nil {
+			parsed.ExpirationDays = aws.ToInt32(rule.Expiration.Days)
+		}
+		if rule.NoncurrentVersionExpiration != nil {
+			parsed.NoncurrentVersionExpirationDays = aws.ToInt32(rule.NoncurrentVersionExpiration.NoncurrentDays)
+		}
+		if rule.AbortIncompleteMultipartUpload != nil {
+			parsed.AbortIncompleteMultipartUploadDays = aws.ToInt32(rule.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+		}
+		for _, transition := range rule.Transitions {
+			parsed.Transitions = append(parsed.Transitions, models.BucketLifecycleTransition{
+				Days:         aws.ToInt32(transition.Days),
+				StorageClass: string(transition.StorageClass),
+			})
+		}
+		result = append(result, parsed)
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+package awsd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/errors"
+)
+
+func TestListBuckets(t *testing.T) {
+	pages := map[string]*s3.ListBucketsOutput{
+		"": {
+			Buckets:           []types.Bucket{{Name: aws.String("logs")}, {}},
+			ContinuationToken: aws.String("token-2"),
+		},
+		"token-2": {
+			Buckets: []types.Bucket{{Name: aws.String("assets")}},
+		},
+	}
+
+	client := &S3Client{client: &MockS3Client{
+		ListBucketsFunc: func(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+			assert.Nil(t, params.BucketRegion)
+			return pages[aws.ToString(params.ContinuationToken)], nil
+		},
+	}}
+	names, err := client.ListBuckets(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs", "assets"}, names)
+}
+
+func TestListBuckets_Region(t *testing.T) {
+	client := &S3Client{region: "eu-west-1", client: &MockS3Client{
+		ListBucketsFunc: func(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+			assert.Equal(t, "eu-west-1", aws.ToString(params.BucketRegion))
+			return &s3.ListBucketsOutput{Buckets: []types.Bucket{{Name: aws.String("eu-logs")}}}, nil
+		},
+	}}
+	names, err := client.ListBuckets(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-logs"}, names)
+}
+
+func TestListBuckets_Error(t *testing.T) {
+	client := &S3Client{client: &MockS3Client{
+		ListBucketsFunc: func(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+			return nil, fmt.Errorf("access denied")
+		},
+	}}
+	names, err := client.ListBuckets(context.Background())
+
+	assert.Nil(t, names)
+	assert.True(t, errors.Is(err, errors.ErrAWSS3))
+}
+
+func TestGetBucket(t *testing.T) {
+	notConfigured := func(code string) error {
+		return &smithy.GenericAPIError{Code: code, Message: "not configured"}
+	}
+
+	tests := []struct {
+		name          string
+		client        *MockS3Client
+		expected      *models.AWSBucket
+		expectedError bool
+	}{
+		{
+			name: "fully configured bucket",
+			client: &MockS3Client{
+				GetBucketTaggingFunc: func(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+					return &s3.GetBucketTaggingOutput{TagSet: []types.Tag{{Key: aws.String("env"), Value: aws.String("prod")}}}, nil
+				},
+				GetBucketVersioningFunc: func(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+					return &s3.GetBucketVersioningOutput{Status: types.BucketVersioningStatusEnabled, MFADelete: types.MFADeleteStatusDisabled}, nil
+				},
+				GetBucketEncryptionFunc: func(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+					return &s3.GetBucketEncryptionOutput{ServerSideEncryptionConfiguration: &types.ServerSideEncryptionConfiguration{
+						Rules: []types.ServerSideEncryptionRule{{
+							ApplyServerSideEncryptionByDefault: &types.ServerSideEncryptionByDefault{
+								SSEAlgorithm:   types.ServerSideEncryptionAwsKms,
+								KMSMasterKeyID: aws.String("alias/s3"),
+							},
+							BucketKeyEnabled: aws.Bool(true),
+						}},
+					}}, nil
+				},
+				GetPublicAccessBlockFunc: func(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+					return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+						BlockPublicAcls:   aws.Bool(true),
+						BlockPublicPolicy: aws.Bool(true),
+					}}, nil
+				},
+				GetBucketLifecycleConfigurationFunc: func(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+					return &s3.GetBucketLifecycleConfigurationOutput{Rules: []types.LifecycleRule{{
+						ID:         aws.String("expire-logs"),
+						Status:     types.ExpirationStatusEnabled,
+						Filter:     &types.LifecycleRuleFilter{Prefix: aws.String("logs/")},
+						Expiration: &types.LifecycleExpiration{Days: aws.Int32(90)},
+						Transitions: []types.Transition{
+							{Days: aws.Int32(30), StorageClass: types.TransitionStorageClassStandardIa},
+						},
+						AbortIncompleteMultipartUpload: &types.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int32(7)},
+					}}}, nil
+				},
+				GetBucketPolicyFunc: func(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+					return &s3.GetBucketPolicyOutput{Policy: aws.String(`{"Version":"2012-10-17","Statement":[]}`)}, nil
+				},
+			},
+			expected: &models.AWSBucket{
+				Name:       "logs",
+				Versioning: "Enabled",
+				MFADelete:  "Disabled",
+				Encryption: []models.BucketEncryptionRule{
+					{SSEAlgorithm: "aws:kms", KMSMasterKeyID: "alias/s3", BucketKeyEnabled: true},
+				},
+				PublicAccessBlock: &models.BucketPublicAccessBlock{BlockPublicAcls: true, BlockPublicPolicy: true},
+				LifecycleRules: []models.BucketLifecycleRule{
+					{
+						ID:                                 "expire-logs",
+						Status:                             "Enabled",
+						Prefix:                             "logs/",
+						ExpirationDays:                     90,
+						AbortIncompleteMultipartUploadDays: 7,
+						Transitions:                        []models.BucketLifecycleTransition{{Days: 30, StorageClass: "STANDARD_IA"}},
+					},
+				},
+				Policy: `{"Version":"2012-10-17","Statement":[]}`,
+				Tags:   map[string]string{"env": "prod"},
+			},
+		},
+		{
+			name: "bucket without configuration",
+			client: &MockS3Client{
+				GetBucketTaggingFunc: func(ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.GetBucketTaggingOutput, error) {
+					return nil, notConfigured("NoSuchTagSet")
+				},
+				GetBucketEncryptionFunc: func(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+					return nil, notConfigured("ServerSideEncryptionConfigurationNotFoundError")
+				},
+				GetPublicAccessBlockFunc: func(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+					return nil, notConfigured("NoSuchPublicAccessBlockConfiguration")
+				},
+				GetBucketLifecycleConfigurationFunc: func(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+					return nil, notConfigured("NoSuchLifecycleConfiguration")
+				},
+				GetBucketPolicyFunc: func(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+					return nil, notConfigured("NoSuchBucketPolicy")
+				},
+			},
+			expected: &models.AWSBucket{
+				Name:           "logs",
+				Encryption:     nil,
+				LifecycleRules: nil,
+				Tags:           map[string]string{},
+			},
+		},
+		{
+			name: "read error",
+			client: &MockS3Client{
+				GetBucketPolicyFunc: func(ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+					return nil, &smithy.GenericAPIError{Code: "AccessDenied", Message: "denied"}
+				},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &S3Client{client: tt.client}
+			bucket, err := client.GetBucket(context.Background(), "logs")
+
+			if tt.expectedError {
+				assert.Nil(t, bucket)
+				assert.True(t, errors.Is(err, errors.ErrAWSS3))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, bucket)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package awsd
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/errors"
+)
+
+// GetSecurityGroups fetches every security group visible to the client with its
+// ingress and egress rules, following NextToken until all groups have been read
+func (c *AWSClient) GetSecurityGroups(ctx context.Context) ([]*models.AWSSecurityGroup, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "GetSecurityGroups"),
+	)
+
+	groups := make([]*models.AWSSecurityGroup, 0)
+	var nextToken *string
+	for {
+		output, err := c.client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, errors.New(errors.ErrAWSSecurityGroup, "failed to describe security groups",
+				map[string]interface{}{
+					"operation": "describe_security_groups",
+				}, err)
+		}
+
+		for _, group := range output.SecurityGroups {
+			if group.GroupId == nil {
+				continue
+			}
+			groups = append(groups, parseSecurityGroup(group))
+		}
+
+		if aws.ToString(output.NextToken) == "" {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	logger.Info("AWS security groups fetched successfully",
+		zap.String("operation", "describe_security_groups"),
+		zap.Int("security_group_count", len(groups)),
+	)
+	return groups, nil
+}
+
+// parseSecurityGroup maps an EC2 security group onto the AWSSecurityGroup model
+func parseSecurityGroup(group types.SecurityGroup) *models.AWSSecurityGroup {
+	return &models.AWSSecurityGroup{
+		GroupId:     aws.ToString(group.GroupId),
+		GroupName:   aws.ToString(group.GroupName),
+		Description: aws.ToString(group.Description),
+		VpcId:       aws.ToString(group.VpcId),
+		Ingress:     parseIpPermissions(group.IpPermissions),
+		Egress:      parseIpPermissions(group.IpPermissionsEgress),
+		Tags:        parseTags(group.Tags),
+	}
+}
+
+// parseIpPermissions splits the permissions of a security group into one rule per
+// source: CIDR, IPv6 CIDR, prefix list or referenced group
+func parseIpPermissions(permissions []types.IpPermission) []models.SecurityGroupRule {
+	result := make([]models.SecurityGroupRule, 0)
+	for _, permission := range permissions {
+		base := models.SecurityGroupRule{
+			Protocol: aws.ToString(permission.IpProtocol),
+			FromPort: -1,
+			ToPort:   -1,
+		}
+		if permission.FromPort != nil {
+			base.FromPort = *permission.FromPort
+		}
+		if permission.ToPort != nil {
+			base.ToPort = *permission.ToPort
+		}
+
+		for _, r := range permission.IpRanges {
+			rule := base
+			rule.CidrIpv4 = aws.ToString(r.CidrIp)
+			rule.Description = aws.ToString(r.Description)
+			result = append(result, rule)
+		}
+		for _, r := range permission.Ipv6Ranges {
+			rule := base
+			rule.CidrIpv6 = aws.ToString(r.CidrIpv6)
+			rule.Description = aws.ToString(r.Description)
+			result = append(result, rule)
+		}
+		for _, r := range permission.PrefixListIds {
+			rule := base
+			rule.PrefixListId = aws.ToString(r.PrefixListId)
+			rule.Description = aws.ToString(r.Description)
+			result = append(result, rule)
+		}
+		for _, r := range permission.UserIdGroupPairs {
+			rule := base
+			rule.ReferencedGroupId = aws.ToString(r.GroupId)
+			rule.Description = aws.ToString(r.Description)
+			result = append(result, rule)
+		}
+	}
+	return result
+}
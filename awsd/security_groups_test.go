@@ -0,0 +1,143 @@
+package awsd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+
+	"Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/errors"
+)
+
+func TestGetSecurityGroups(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockResponse  *ec2.DescribeSecurityGroupsOutput
+		mockError     error
+		expected      []*models.AWSSecurityGroup
+		expectedError bool
+	}{
+		{
+			name: "group with ingress and egress rules",
+			mockResponse: &ec2.DescribeSecurityGroupsOutput{
+				SecurityGroups: []types.SecurityGroup{
+					{
+						GroupId:     aws.String("sg-web"),
+						GroupName:   aws.String("web"),
+						Description: aws.String("web servers"),
+						VpcId:       aws.String("vpc-1"),
+						Tags:        []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+						IpPermissions: []types.IpPermission{
+							{
+								IpProtocol: aws.String("tcp"),
+								FromPort:   aws.Int32(443),
+								ToPort:     aws.Int32(443),
+								IpRanges: []types.IpRange{
+									{CidrIp: aws.String("0.0.0.0/0"), Description: aws.String("https")},
+								},
+								Ipv6Ranges: []types.Ipv6Range{{CidrIpv6: aws.String("::/0")}},
+							},
+							{
+								IpProtocol:       aws.String("tcp"),
+								FromPort:         aws.Int32(22),
+								ToPort:           aws.Int32(22),
+								UserIdGroupPairs: []types.UserIdGroupPair{{GroupId: aws.String("sg-bastion")}},
+								PrefixListIds:    []types.PrefixListId{{PrefixListId: aws.String("pl-1")}},
+							},
+						},
+						IpPermissionsEgress: []types.IpPermission{
+							{
+								IpProtocol: aws.String("-1"),
+								IpRanges:   []types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+							},
+						},
+					},
+					{GroupName: aws.String("no id")},
+				},
+			},
+			expected: []*models.AWSSecurityGroup{
+				{
+					GroupId:     "sg-web",
+					GroupName:   "web",
+					Description: "web servers",
+					VpcId:       "vpc-1",
+					Tags:        map[string]string{"Name": "web"},
+					Ingress: []models.SecurityGroupRule{
+						{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIpv4: "0.0.0.0/0", Description: "https"},
+						{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIpv6: "::/0"},
+						{Protocol: "tcp", FromPort: 22, ToPort: 22, PrefixListId: "pl-1"},
+						{Protocol: "tcp", FromPort: 22, ToPort: 22, ReferencedGroupId: "sg-bastion"},
+					},
+					Egress: []models.SecurityGroupRule{
+						{Protocol: "-1", FromPort: -1, ToPort: -1, CidrIpv4: "0.0.0.0/0"},
+					},
+				},
+			},
+		},
+		{
+			name:         "no security groups",
+			mockResponse: &ec2.DescribeSecurityGroupsOutput{},
+			expected:     []*models.AWSSecurityGroup{},
+		},
+		{
+			name:          "describe error",
+			mockError:     fmt.Errorf("access denied"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockEC2Client{
+				DescribeSecurityGroupsFunc: func(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+					return tt.mockResponse, tt.mockError
+				},
+			}
+
+			client := &AWSClient{client: mockClient}
+			groups, err := client.GetSecurityGroups(context.Background())
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.True(t, errors.Is(err, errors.ErrAWSSecurityGroup))
+				assert.Nil(t, groups)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, groups)
+		})
+	}
+}
+
+func TestGetSecurityGroups_Pagination(t *testing.T) {
+	pages := map[string]*ec2.DescribeSecurityGroupsOutput{
+		"": {
+			SecurityGroups: []types.SecurityGroup{{GroupId: aws.String("sg-1")}},
+			NextToken:      aws.String("token-2"),
+		},
+		"token-2": {
+			SecurityGroups: []types.SecurityGroup{{GroupId: aws.String("sg-2")}},
+		},
+	}
+
+	var tokens []string
+	mockClient := &MockEC2Client{
+		DescribeSecurityGroupsFunc: func(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+			token := aws.ToString(params.NextToken)
+			tokens = append(tokens, token)
+			return pages[token], nil
+		},
+	}
+
+	client := &AWSClient{client: mockClient}
+	groups, err := client.GetSecurityGroups(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "token-2"}, tokens)
+	assert.Len(t, groups, 2)
+}
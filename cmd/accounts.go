@@ -0,0 +1,129 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/awsd"
+	"Savannahtakehomeassi/configuration"
+	"Savannahtakehomeassi/driftChecker"
+	"Savannahtakehomeassi/errors"
+)
+
+// accountTargets returns the configured accounts, or a single unnamed account using
+// the static keys of the configuration when none are configured
+func accountTargets(config *configuration.Config) []configuration.AccountTarget {
+	if len(config.Accounts) > 0 {
+		return config.Accounts
+	}
+	return []configuration.AccountTarget{{
+		TFStatePath: config.TFStatePath,
+		MainTFPath:  config.MainTFPath,
+	}}
+}
+
+// accountReportPath returns where the report of an account is written. With several
+// accounts, each gets its own file named after it, e.g. report.prod.json for
+// report.json.
+func accountReportPath(config *configuration.Config, account configuration.AccountTarget) string {
+	path := config.ReportPath
+	if path == "" || path == "-" || len(config.Accounts) < 2 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + account.Name + ext
+}
+
+// validateReportPath rejects writing the reports of the daemon to stdout when several
+// accounts are checked: their loops would interleave their documents on one stream.
+func validateReportPath(config *configuration.Config) error {
+	if config.ReportPath == "-" && len(config.Accounts) > 1 {
+		return errors.New(errors.ErrConfigInvalid, "invalid REPORT_PATH",
+			map[string]interface{}{
+				"config_key": "REPORT_PATH",
+				"value":      config.ReportPath,
+				"accounts":   len(config.Accounts),
+			}, nil)
+	}
+	return nil
+}
+
+// newAccountDriftService creates the clients of every scanned region of an account
+// and a DriftService over them. The clients of an account share its credentials, so
+// an assumed role is only refreshed once for all of them.
+func newAccountDriftService(config *configuration.Config, account configuration.AccountTarget,
+	terraformClient driftChecker.TerraformClient, logger *zap.Logger) (*driftChecker.DriftService, error) {
+	var creds aws.CredentialsProvider
+	if account.Name != "" {
+		var err error
+		creds, err = awsd.NewAccountCredentials(config, account, nil)
+		if err != nil {
+			return nil, accountClientError(account, config.AWSRegion, "credentials_init", err)
+		}
+	}
+
+	awsClient, err := awsd.NewAWSClientForRegion(config, config.AWSRegion, creds)
+	if err != nil {
+		return nil, accountClientError(account, config.AWSRegion, "aws_client_init", err)
+	}
+	s3Client, err := awsd.NewS3ClientForRegion(config, config.AWSRegion, creds)
+	if err != nil {
+		return nil, accountClientError(account, config.AWSRegion, "s3_client_init", err)
+	}
+	iamClient, err := awsd.NewIAMClientForAccount(config, creds)
+	if err != nil {
+		return nil, accountClientError(account, config.AWSRegion, "iam_client_init", err)
+	}
+
+	// IAM is global, so its handlers are only registered for the home region
+	var options []driftChecker.Option
+	if account.Name != "" {
+		options = append(options, driftChecker.WithAccount(account.Name))
+	}
+	options = append(options,
+		driftChecker.WithUnmanagedFilter(driftChecker.UnmanagedFilter{
+			IgnoreTags:  config.UnmanagedIgnoreTags,
+			IgnoreNames: config.UnmanagedIgnoreNames,
+		}),
+		driftChecker.WithComparisonTimeout(time.Duration(config.ComparisonTimeout)*time.Second),
+		driftChecker.WithFailureBudget(config.FailureBudget),
+		driftChecker.WithReportPath(accountReportPath(config, account)),
+		driftChecker.WithHomeRegion(config.AWSRegion),
+		driftChecker.WithProviderRegions(config.ProviderRegions),
+		driftChecker.WithHandler(driftChecker.NewS3BucketHandler(s3Client, logger)),
+		driftChecker.WithHandler(driftChecker.NewIAMRoleHandler(iamClient, logger)),
+		driftChecker.WithHandler(driftChecker.NewIAMPolicyHandler(iamClient, logger)),
+	)
+
+	for _, region := range config.AWSRegions {
+		if region == config.AWSRegion {
+			continue
+		}
+		regionClient, err := awsd.NewAWSClientForRegion(config, region, creds)
+		if err != nil {
+			return nil, accountClientError(account, region, "aws_client_init", err)
+		}
+		regionS3Client, err := awsd.NewS3ClientForRegion(config, region, creds)
+		if err != nil {
+			return nil, accountClientError(account, region, "s3_client_init", err)
+		}
+		options = append(options, driftChecker.WithRegion(region, regionClient,
+			driftChecker.NewS3BucketHandler(regionS3Client, logger)))
+	}
+
+	return driftChecker.NewDriftService(awsClient, terraformClient, logger, options...), nil
+}
+
+// accountClientError wraps the failure to create a client of an account
+func accountClientError(account configuration.AccountTarget, region, operation string, err error) error {
+	return errors.New(errors.ErrAWSClient, "AWS client creation failed",
+		map[string]interface{}{
+			"operation": operation,
+			"account":   account.Name,
+			"region":    region,
+		}, err)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"Savannahtakehomeassi/configuration"
+)
+
+func TestAccountReportPath(t *testing.T) {
+	prod := configuration.AccountTarget{Name: "prod"}
+	accounts := []configuration.AccountTarget{prod, {Name: "staging"}}
+
+	assert.Equal(t, "", accountReportPath(&configuration.Config{Accounts: accounts}, prod))
+	assert.Equal(t, "report.json", accountReportPath(&configuration.Config{ReportPath: "report.json"}, prod))
+	assert.Equal(t, "report.prod.json", accountReportPath(&configuration.Config{ReportPath: "report.json", Accounts: accounts}, prod))
+}
+
+func TestValidateReportPath(t *testing.T) {
+	accounts := []configuration.AccountTarget{{Name: "prod"}, {Name: "staging"}}
+
+	assert.NoError(t, validateReportPath(&configuration.Config{ReportPath: "-"}))
+	assert.NoError(t, validateReportPath(&configuration.Config{ReportPath: "-", Accounts: accounts[:1]}))
+	assert.NoError(t, validateReportPath(&configuration.Config{ReportPath: "report.json", Accounts: accounts}))
+	assert.Error(t, validateReportPath(&configuration.Config{ReportPath: "-", Accounts: accounts}))
+}
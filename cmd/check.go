@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/configuration"
+	"Savannahtakehomeassi/driftChecker"
+	"Savannahtakehomeassi/errors"
+	"Savannahtakehomeassi/teraform"
+)
+
+// Exit codes of the check subcommand
+const (
+	exitNoDrift = 0
+	exitError   = 1
+	exitDrift   = 2
+)
+
+// Output formats of the check subcommand
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// checkOptions are the flags of the check subcommand. Empty values keep the
+// configuration's.
+type checkOptions struct {
+	statePath  string
+	configPath string
+	region     string
+	output     string
+	outputFile string
+}
+
+// parseCheckFlags parses the flags of the check subcommand, writing usage and flag
+// errors to output
+func parseCheckFlags(args []string, output io.Writer) (checkOptions, error) {
+	var opts checkOptions
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&opts.statePath, "state", "", "path of the Terraform state file (default TFSTATE_PATH)")
+	flags.StringVar(&opts.configPath, "tf-config", "", "path of the Terraform configuration file or module directory (default MAINTF_PATH)")
+	flags.StringVar(&opts.region, "region", "", "check only this region (default AWS_REGION and AWS_REGIONS)")
+	flags.StringVar(&opts.output, "output", outputText, "output format: text or json")
+	flags.StringVar(&opts.outputFile, "output-file", "", "file the report is written to (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if flags.NArg() > 0 {
+		return opts, errors.New(errors.ErrConfigInvalid, "unexpected arguments",
+			map[string]interface{}{
+				"operation": "check_flags",
+				"arguments": flags.Args(),
+			}, nil)
+	}
+	if opts.output != outputText && opts.output != outputJSON {
+		return opts, errors.New(errors.ErrConfigInvalid, "invalid output format",
+			map[string]interface{}{
+				"operation": "check_flags",
+				"value":     opts.output,
+			}, nil)
+	}
+	return opts, nil
+}
+
+// apply overrides the configuration with the flags. A region replaces the scanned
+// regions, keeping only the provider aliases that target it; a state or config path
+// applies to every account.
+func (o checkOptions) apply(config *configuration.Config) {
+	if o.region != "" {
+		config.AWSRegion = o.region
+		config.AWSRegions = []string{o.region}
+		providerRegions := make(map[string]string)
+		for alias, region := range config.ProviderRegions {
+			if region == o.region {
+				providerRegions[alias] = region
+			}
+		}
+		config.ProviderRegions = providerRegions
+	}
+	if o.statePath != "" {
+		config.TFStatePath = o.statePath
+	}
+	if o.configPath != "" {
+		config.MainTFPath = o.configPath
+	}
+	for i := range config.Accounts {
+		if o.statePath != "" {
+			config.Accounts[i].TFStatePath = o.statePath
+		}
+		if o.configPath != "" {
+			config.Accounts[i].MainTFPath = o.configPath
+		}
+	}
+}
+
+// runCheck runs the check subcommand: a single drift check of every account, whose
+// report is written to stdout or the output file. It returns the exit code of the
+// process.
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "runCheck"),
+	)
+
+	opts, err := parseCheckFlags(args, stderr)
+	if err == flag.ErrHelp {
+		return exitNoDrift
+	}
+	if err != nil {
+		logger.Error("Invalid check flags",
+			zap.String("operation", "check_flags"),
+			zap.Error(err),
+		)
+		return exitError
+	}
+
+	config, err := configuration.Initialize()
+	if err != nil {
+		logger.Error("Failed to load configuration",
+			zap.String("operation", "config_load"),
+			zap.Error(err),
+		)
+		return exitError
+	}
+	opts.apply(config)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	terraformClient := teraform.NewTerraformClient()
+	targets := accountTargets(config)
+	services := make([]*driftChecker.DriftService, 0, len(targets))
+	for _, account := range targets {
+		service, err := newAccountDriftService(config, account, terraformClient, logger)
+		if err != nil {
+			logger.Error("Failed to create AWS clients",
+				zap.String("operation", "aws_client_creation"),
+				zap.String("account", account.Name),
+				zap.Error(err),
+			)
+			return exitError
+		}
+		services = append(services, service)
+	}
+
+	report := checkAccounts(ctx, services, targets)
+	if err := writeCheckOutput(stdout, report, opts); err != nil {
+		logger.Error("Failed to write drift report",
+			zap.String("operation", "report_write"),
+			zap.Error(err),
+		)
+		return exitError
+	}
+	return checkExitCode(report)
+}
+
+// checkAccounts runs a single drift check with the service of every account, over the
+// account's state and config. An account whose check fails is recorded in the
+// report's errors and the other accounts are still checked.
+func checkAccounts(ctx context.Context, services []*driftChecker.DriftService,
+	targets []configuration.AccountTarget) *driftChecker.Report {
+	report := driftChecker.NewReport(time.Now())
+	for i, service := range services {
+		account := targets[i]
+		driftReport, err := service.Check(ctx, account.TFStatePath, account.MainTFPath)
+		if err != nil {
+			report.AddError(account.Name, errors.New(errors.ErrDriftChecker, "Drift check failed",
+				map[string]interface{}{
+					"operation": "drift_check",
+					"account":   account.Name,
+				}, err))
+			continue
+		}
+		report.Add(driftReport)
+	}
+	report.Complete()
+	return report
+}
+
+// checkExitCode returns the exit code of a check. A check that failed or didn't finish
+// is an error even when it found drift, so that CI can tell it from a complete check.
+func checkExitCode(report *driftChecker.Report) int {
+	if report.Partial() {
+		return exitError
+	}
+	if report.HasDrift() {
+		return exitDrift
+	}
+	return exitNoDrift
+}
+
+// writeCheckOutput writes the report in the output format, to the output file when
+// one is set or to stdout
+func writeCheckOutput(stdout io.Writer, report *driftChecker.Report, opts checkOptions) error {
+	if opts.outputFile == "" || opts.outputFile == "-" {
+		if opts.output == outputJSON {
+			return report.Encode(stdout)
+		}
+		return writeText(stdout, report)
+	}
+
+	if opts.output == outputJSON {
+		return driftChecker.WriteReport(report, opts.outputFile)
+	}
+	var text bytes.Buffer
+	if err := writeText(&text, report); err != nil {
+		return err
+	}
+	return os.WriteFile(opts.outputFile, text.Bytes(), 0o644)
+}
+
+// writeText writes the report in the text output format, one line per drift
+func writeText(w io.Writer, report *driftChecker.Report) error {
+	for _, accountReport := range report.Accounts {
+		header := "Drift check"
+		if accountReport.Account != "" {
+			header += " of account " + accountReport.Account
+		}
+		if len(accountReport.Regions) > 0 {
+			header += " in " + strings.Join(accountReport.Regions, ", ")
+		}
+		if _, err := fmt.Fprintf(w, "%s: %d resources checked, %d drifts\n",
+			header, accountReport.ResourcesChecked, len(accountReport.Drifts)); err != nil {
+			return err
+		}
+		for _, drift := range accountReport.Drifts {
+			if _, err := fmt.Fprintf(w, "  [%s] %s\n", drift.Severity, drift); err != nil {
+				return err
+			}
+		}
+		for _, unfinished := range accountReport.Unfinished {
+			if _, err := fmt.Fprintf(w, "  unfinished: %s %s (%s)\n",
+				unfinished.Region, unfinished.ResourceType, unfinished.Reason); err != nil {
+				return err
+			}
+		}
+	}
+	for _, reportErr := range report.Errors {
+		header := "Drift check failed"
+		if reportErr.Account != "" {
+			header = "Drift check of account " + reportErr.Account + " failed"
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", header, reportErr.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/configuration"
+	"Savannahtakehomeassi/driftChecker"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+func TestParseCheckFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		expected  checkOptions
+		expectErr bool
+	}{
+		{
+			name:     "defaults",
+			expected: checkOptions{output: outputText},
+		},
+		{
+			name:     "all flags",
+			args:     []string{"-state", "prod.tfstate", "-tf-config", "infra/", "-region", "eu-west-1", "-output", "json", "-output-file", "drift.json"},
+			expected: checkOptions{statePath: "prod.tfstate", configPath: "infra/", region: "eu-west-1", output: outputJSON, outputFile: "drift.json"},
+		},
+		{
+			name:      "unknown output format",
+			args:      []string{"-output", "yaml"},
+			expectErr: true,
+		},
+		{
+			name:      "unexpected argument",
+			args:      []string{"main.tf"},
+			expectErr: true,
+		},
+		{
+			name:      "unknown flag",
+			args:      []string{"-verbose"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseCheckFlags(tt.args, io.Discard)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, opts)
+		})
+	}
+}
+
+func TestCheckOptions_Apply(t *testing.T) {
+	config := &configuration.Config{
+		TFStatePath:     "terraform.tfstate",
+		MainTFPath:      "main.tf",
+		AWSRegion:       "us-east-1",
+		AWSRegions:      []string{"us-east-1", "eu-west-1"},
+		ProviderRegions: map[string]string{"eu": "eu-west-1", "us": "us-east-1"},
+		Accounts:        []configuration.AccountTarget{{Name: "prod", TFStatePath: "prod.tfstate", MainTFPath: "main.tf"}},
+	}
+	checkOptions{statePath: "ci.tfstate", region: "eu-west-1"}.apply(config)
+
+	assert.Equal(t, "eu-west-1", config.AWSRegion)
+	assert.Equal(t, []string{"eu-west-1"}, config.AWSRegions)
+	assert.Equal(t, map[string]string{"eu": "eu-west-1"}, config.ProviderRegions)
+	assert.Equal(t, "ci.tfstate", config.TFStatePath)
+	assert.Equal(t, "main.tf", config.MainTFPath)
+	assert.Equal(t, "ci.tfstate", config.Accounts[0].TFStatePath)
+	assert.Equal(t, "main.tf", config.Accounts[0].MainTFPath)
+}
+
+func TestCheckExitCode(t *testing.T) {
+	clean := &driftChecker.DriftReport{}
+	drifted := &driftChecker.DriftReport{Drifts: []driftChecker.Drift{{Address: "aws_vpc.main"}}}
+	partial := &driftChecker.DriftReport{Partial: true}
+	newReport := func(reports ...*driftChecker.DriftReport) *driftChecker.Report {
+		report := driftChecker.NewReport(time.Now())
+		for _, r := range reports {
+			report.Add(r)
+		}
+		return report
+	}
+	failed := newReport(clean)
+	failed.AddError("staging", errors.New("access denied"))
+
+	assert.Equal(t, exitNoDrift, checkExitCode(newReport(clean, clean)))
+	assert.Equal(t, exitDrift, checkExitCode(newReport(clean, drifted)))
+	assert.Equal(t, exitError, checkExitCode(newReport(partial, drifted)))
+	assert.Equal(t, exitError, checkExitCode(newReport(clean, partial)))
+	assert.Equal(t, exitError, checkExitCode(failed))
+}
+
+func TestCheckAccounts(t *testing.T) {
+	tfClient := new(driftChecker.MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "prod.tfstate").Return(&terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_instance", Name: "web", Instances: []terafm.Instance{
+			{Attributes: terafm.InstanceAttributes{InstanceID: "i-12345", InstanceType: "t2.micro"}},
+		}},
+	}}, nil)
+	tfClient.On("ParseTerraformInstance", "staging.tfstate").Return(nil, errors.New("state not found"))
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+	awsClient := new(driftChecker.MockAWSClient)
+	awsClient.On("GetAWSInstances").Return([]*awsm.AWSInstance{
+		{InstanceID: "i-12345", InstanceType: "t2.large"},
+		{InstanceID: "i-manual", InstanceType: "t2.micro"},
+	}, nil)
+
+	services := []*driftChecker.DriftService{
+		driftChecker.NewDriftService(awsClient, tfClient, zap.NewNop(), driftChecker.WithAccount("prod")),
+		driftChecker.NewDriftService(awsClient, tfClient, zap.NewNop(), driftChecker.WithAccount("staging")),
+	}
+	targets := []configuration.AccountTarget{
+		{Name: "prod", TFStatePath: "prod.tfstate", MainTFPath: "main.tf"},
+		{Name: "staging", TFStatePath: "staging.tfstate", MainTFPath: "main.tf"},
+	}
+	report := checkAccounts(context.Background(), services, targets)
+	require.Len(t, report.Accounts, 1)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "staging", report.Errors[0].Account)
+	assert.Equal(t, exitError, checkExitCode(report))
+
+	var text bytes.Buffer
+	require.NoError(t, writeCheckOutput(&text, report, checkOptions{output: outputText}))
+	assert.Equal(t, "Drift check of account prod: 1 resources checked, 2 drifts\n"+
+		"  [medium] aws_instance (i-manual): unmanaged\n"+
+		"  [medium] aws_instance.web (i-12345): instance_type drift (expected: \"t2.micro\", actual: \"t2.large\", source: state)\n"+
+		"Drift check of account staging failed: "+report.Errors[0].Message+"\n",
+		text.String())
+
+	path := filepath.Join(t.TempDir(), "drift.json")
+	var stdout bytes.Buffer
+	require.NoError(t, writeCheckOutput(&stdout, report, checkOptions{output: outputJSON, outputFile: path}))
+	assert.Empty(t, stdout.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded driftChecker.Report
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, driftChecker.ReportSchemaVersion, decoded.SchemaVersion)
+	require.Len(t, decoded.Accounts, 1)
+	assert.Equal(t, report.Accounts[0].Drifts, decoded.Accounts[0].Drifts)
+	assert.Equal(t, report.Errors, decoded.Errors)
+}
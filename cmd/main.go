@@ -7,7 +7,6 @@ import (
 	"syscall"
 	"time"
 
-	"Savannahtakehomeassi/awsd"
 	"Savannahtakehomeassi/configuration"
 	"Savannahtakehomeassi/driftChecker"
 	"Savannahtakehomeassi/errors"
@@ -15,6 +14,7 @@ import (
 	"Savannahtakehomeassi/teraform"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 const (
@@ -22,15 +22,17 @@ const (
 )
 
 func main() {
-	// Initialize logger
-	if err := logger.Initialize("info"); err != nil {
-		panic(errors.New(errors.ErrConfigParse, "Failed to initialize logger",
-			map[string]interface{}{
-				"operation": "logger_init",
-			}, err))
-	}
+	// Logs go to stderr until the configuration is loaded: the check subcommand, and
+	// the daemon when REPORT_PATH is "-", write their reports to stdout
+	initLogger(os.Stderr)
 	defer logger.Sync()
 
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		code := runCheck(os.Args[2:], os.Stdout, os.Stderr)
+		logger.Sync()
+		os.Exit(code)
+	}
+
 	logger := zap.L().With(zap.String("package", packageName))
 	logger.Info("Application starting",
 		zap.String("operation", "startup"),
@@ -48,27 +50,23 @@ func main() {
 		)
 		os.Exit(1)
 	}
+	if err := validateReportPath(config); err != nil {
+		logger.Error("Invalid report path",
+			zap.String("operation", "config_load"),
+			zap.Error(err),
+		)
+		os.Exit(1)
+	}
+	if config.ReportPath != "-" {
+		initLogger(os.Stdout)
+		logger = zap.L().With(zap.String("package", packageName))
+	}
 	logger.Info("Configuration loaded successfully",
 		zap.String("operation", "config_load"),
 		zap.String("tf_state_path", config.TFStatePath),
 		zap.String("main_tf_path", config.MainTFPath),
 		zap.Int("check_interval", config.CheckInterval),
-	)
-
-	// Create AWS client
-	awsClient, err := awsd.NewAWSClient(config)
-	if err != nil {
-		logger.Error("Failed to create AWS client",
-			zap.String("operation", "aws_client_creation"),
-			zap.Error(errors.New(errors.ErrAWSClient, "AWS client creation failed",
-				map[string]interface{}{
-					"operation": "aws_client_init",
-				}, err)),
-		)
-		os.Exit(1)
-	}
-	logger.Info("AWS client created successfully",
-		zap.String("operation", "aws_client_creation"),
+		zap.Strings("regions", config.AWSRegions),
 	)
 
 	// Create Terraform client
@@ -77,10 +75,24 @@ func main() {
 		zap.String("operation", "terraform_client_creation"),
 	)
 
-	// Create DriftService
-	driftService := driftChecker.NewDriftService(awsClient, terraformClient, logger)
+	// Create a DriftService per account
+	targets := accountTargets(config)
+	services := make([]*driftChecker.DriftService, 0, len(targets))
+	for _, account := range targets {
+		service, err := newAccountDriftService(config, account, terraformClient, logger)
+		if err != nil {
+			logger.Error("Failed to create AWS clients",
+				zap.String("operation", "aws_client_creation"),
+				zap.String("account", account.Name),
+				zap.Error(err),
+			)
+			os.Exit(1)
+		}
+		services = append(services, service)
+	}
 	logger.Info("DriftService created successfully",
 		zap.String("operation", "drift_service_creation"),
+		zap.Int("accounts", len(services)),
 	)
 
 	// Create context with cancellation
@@ -91,41 +103,64 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start drift checker in a goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		logger.Info("Starting drift checker service",
-			zap.String("operation", "drift_service_start"),
-		)
-		err := driftService.RunLoop(ctx, config.TFStatePath, config.MainTFPath, config.CheckInterval)
-		if err != nil {
-			errChan <- errors.New(errors.ErrDriftChecker, "Drift service run loop failed",
-				map[string]interface{}{
-					"operation": "drift_service_run",
-				}, err)
-		}
-	}()
+	// Start the drift checker of every account in a goroutine
+	errChan := make(chan error, len(services))
+	for i, driftService := range services {
+		account := targets[i]
+		go func() {
+			logger.Info("Starting drift checker service",
+				zap.String("operation", "drift_service_start"),
+				zap.String("account", account.Name),
+			)
+			err := driftService.RunLoop(ctx, account.TFStatePath, account.MainTFPath,
+				time.Duration(config.CheckInterval)*time.Minute)
+			if err != nil {
+				errChan <- errors.New(errors.ErrDriftChecker, "Drift service run loop failed",
+					map[string]interface{}{
+						"operation": "drift_service_run",
+						"account":   account.Name,
+					}, err)
+			}
+		}()
+	}
 
-	// Wait for either a signal or an error
-	select {
-	case sig := <-sigChan:
-		logger.Info("Received signal, initiating shutdown",
-			zap.String("operation", "shutdown"),
-			zap.String("signal", sig.String()),
-		)
-		cancel()
-		// Give some time for cleanup
-		time.Sleep(2 * time.Second)
-		logger.Info("Shutdown complete",
-			zap.String("operation", "shutdown_complete"),
-		)
-	case err := <-errChan:
-		if err != nil {
+	// Wait for a signal, or for the loop of every account to give up. An account whose
+	// loop gives up is logged and the others keep running.
+	for stopped := 0; stopped < len(services); {
+		select {
+		case sig := <-sigChan:
+			logger.Info("Received signal, initiating shutdown",
+				zap.String("operation", "shutdown"),
+				zap.String("signal", sig.String()),
+			)
+			cancel()
+			// Give some time for cleanup
+			time.Sleep(2 * time.Second)
+			logger.Info("Shutdown complete",
+				zap.String("operation", "shutdown_complete"),
+			)
+			return
+		case err := <-errChan:
+			stopped++
 			logger.Error("Drift checker error",
 				zap.String("operation", "drift_check"),
+				zap.Int("accounts_running", len(services)-stopped),
 				zap.Error(err),
 			)
-			os.Exit(1)
 		}
 	}
+	logger.Error("Drift checker of every account stopped",
+		zap.String("operation", "drift_check"),
+	)
+	os.Exit(1)
+}
+
+// initLogger sets up the global logger, writing to out
+func initLogger(out zapcore.WriteSyncer) {
+	if err := logger.InitializeWithOutput("info", out); err != nil {
+		panic(errors.New(errors.ErrConfigParse, "Failed to initialize logger",
+			map[string]interface{}{
+				"operation": "logger_init",
+			}, err))
+	}
 }
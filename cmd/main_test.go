@@ -14,6 +14,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 	"go.uber.org/zap"
 )
 
@@ -90,7 +91,7 @@ func TestMainSetup(t *testing.T) {
 
 				// Create and set up mock AWS client
 				awsClient := new(driftChecker.MockAWSClient)
-				awsClient.On("GetAWSInstance").Return(&awsm.AWSInstance{
+				awsClient.On("GetAWSInstances").Return([]*awsm.AWSInstance{{
 					InstanceID:   "i-1234567890abcdef0",
 					InstanceType: "t2.micro",
 					PrivateIP:    "10.0.0.1",
@@ -108,7 +109,9 @@ func TestMainSetup(t *testing.T) {
 							PublicIpAddress:  "54.0.0.1",
 						},
 					},
-				}, nil)
+				}}, nil)
+				// The state lists the group by name, so it is looked up as detached
+				awsClient.On("GetSecurityGroups").Return([]*awsm.AWSSecurityGroup{}, nil).Maybe()
 				logger.Info("AWS client mock setup completed")
 
 				// Create and set up mock Terraform client
@@ -135,12 +138,23 @@ func TestMainSetup(t *testing.T) {
 						},
 					},
 				}, nil)
-				tfClient.On("ParseHCLConfig", config.MainTFPath).Return(&terafm.TFInstance{
-					ID:           "i-1234567890abcdef0",
-					InstanceType: "t2.micro",
-					AMI:          "ami-12345678",
-					Tags: map[string]string{
-						"Name": "test-instance",
+				tfClient.On("ParseHCLConfig", config.MainTFPath).Return(&terafm.Config{
+					Resources: []terafm.ResourceBlock{
+						{
+							Type: "aws_instance",
+							Instances: []*terafm.ResourceInstance{
+								{
+									Address: "aws_instance.",
+									Attributes: map[string]*terafm.Attribute{
+										"ami":           {Name: "ami", Value: cty.StringVal("ami-12345678")},
+										"instance_type": {Name: "instance_type", Value: cty.StringVal("t2.micro")},
+										"tags": {Name: "tags", Value: cty.MapVal(map[string]cty.Value{
+											"Name": cty.StringVal("test-instance"),
+										})},
+									},
+								},
+							},
+						},
 					},
 				}, nil)
 				logger.Info("Terraform client mock setup completed")
@@ -185,7 +199,8 @@ func TestMainSetup(t *testing.T) {
 				errChan := make(chan error, 1)
 				go func() {
 					logger.Info("Starting DriftService run loop")
-					err := driftService.RunLoop(ctx, config.TFStatePath, config.MainTFPath, config.CheckInterval)
+					err := driftService.RunLoop(ctx, config.TFStatePath, config.MainTFPath,
+						time.Duration(config.CheckInterval)*time.Minute)
 					if err != nil {
 						logger.Error("DriftService run loop failed",
 							zap.Error(errors.New("drift service run loop failed")))
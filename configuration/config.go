@@ -1,6 +1,10 @@
 package configuration
 
 import (
+	"os"
+	"path"
+	"strings"
+
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 
@@ -13,16 +17,49 @@ const (
 
 // Config holds the application configuration
 type Config struct {
-	TFStatePath       string
-	MainTFPath        string
-	CheckInterval     int
-	AWSRegion         string
+	TFStatePath string
+	// MainTFPath is either a single .tf file or a Terraform module directory
+	MainTFPath    string
+	CheckInterval int
+	AWSRegion     string
+	// AWSRegions are the regions scanned for drift. AWSRegion is always one of them:
+	// resources whose region can't be told from the state are checked there.
+	AWSRegions []string
+	// ProviderRegions maps Terraform provider aliases to the region they target, for
+	// resources whose state doesn't record one
+	ProviderRegions   map[string]string
 	AcessKeyID        string
 	AccessSecret      string
 	LogLevel          string
 	MaxRetries        int
 	RetryDelay        int
 	ComparisonTimeout int
+	// FailureBudget is the number of consecutive failed drift checks after which the
+	// daemon stops checking an account
+	FailureBudget int
+	// ReportPath is where the JSON report of every check is written, "-" for stdout;
+	// empty means no report is written
+	ReportPath string
+	// UnmanagedIgnoreTags and UnmanagedIgnoreNames exclude known exceptions from the
+	// unmanaged resource report. A tag with an empty value matches any value.
+	UnmanagedIgnoreTags  map[string]string
+	UnmanagedIgnoreNames []string
+	// Accounts are the AWS accounts scanned for drift. Without any, the static keys
+	// of AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are used for a single account.
+	Accounts []AccountTarget
+}
+
+// AccountTarget is an AWS account scanned for drift, reached by assuming RoleARN or
+// through a shared-config Profile. With both, the profile's credentials assume the
+// role. The account's state and config default to TFSTATE_PATH and MAINTF_PATH.
+type AccountTarget struct {
+	Name        string
+	RoleARN     string
+	ExternalID  string
+	SessionName string
+	Profile     string
+	TFStatePath string
+	MainTFPath  string
 }
 
 // Initialize sets up the configuration system
@@ -41,22 +78,13 @@ func Initialize() (*Config, error) {
 	viper.SetDefault("MAX_RETRIES", 3)
 	viper.SetDefault("RETRY_DELAY_SECONDS", 5)
 	viper.SetDefault("COMPARISON_TIMEOUT_SECONDS", 30)
+	viper.SetDefault("MAX_CONSECUTIVE_FAILURES", 5)
 
 	// Configure Viper to read from environment
 	viper.AutomaticEnv()
 
-	// Read from .env file
-	viper.SetConfigFile(".env")
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, errors.New(errors.ErrConfigParse, "error reading config file",
-				map[string]interface{}{
-					"config_file": ".env",
-				}, err)
-		}
-		logger.Info("No .env file found, using environment variables and defaults",
-			zap.String("operation", "config_loading"),
-		)
+	if err := readConfigFile(logger); err != nil {
+		return nil, err
 	}
 
 	// Validate paths
@@ -138,17 +166,93 @@ func Initialize() (*Config, error) {
 		zap.String("operation", "config_validation"),
 	)
 
+	failureBudget := viper.GetInt("MAX_CONSECUTIVE_FAILURES")
+	if failureBudget <= 0 {
+		return nil, errors.New(errors.ErrConfigInvalid, "invalid MAX_CONSECUTIVE_FAILURES",
+			map[string]interface{}{
+				"config_key": "MAX_CONSECUTIVE_FAILURES",
+				"value":      failureBudget,
+			}, nil)
+	}
+	logger.Info("Failure budget configured",
+		zap.Int("consecutive_failures", failureBudget),
+		zap.String("operation", "config_validation"),
+	)
+
+	// Regions: AWS_REGIONS="us-east-1,eu-west-1" and AWS_PROVIDER_REGIONS="eu=eu-west-1"
+	region := viper.GetString("AWS_REGION")
+	regions := []string{region}
+	for _, r := range splitList(viper.GetString("AWS_REGIONS")) {
+		if !containsString(regions, r) {
+			regions = append(regions, r)
+		}
+	}
+	providerRegions := parseTagList(viper.GetString("AWS_PROVIDER_REGIONS"))
+	for alias, r := range providerRegions {
+		if !containsString(regions, r) {
+			return nil, errors.New(errors.ErrConfigInvalid, "invalid AWS_PROVIDER_REGIONS, region is not scanned",
+				map[string]interface{}{
+					"config_key": "AWS_PROVIDER_REGIONS",
+					"alias":      alias,
+					"value":      r,
+				}, nil)
+		}
+	}
+	logger.Info("Regions configured",
+		zap.Strings("regions", regions),
+		zap.Any("provider_regions", providerRegions),
+		zap.String("operation", "config_validation"),
+	)
+
+	// Unmanaged resource filters: UNMANAGED_IGNORE_TAGS="key=value,key" and
+	// UNMANAGED_IGNORE_NAMES="bastion-*,eks-node-*"
+	ignoreTags := parseTagList(viper.GetString("UNMANAGED_IGNORE_TAGS"))
+	ignoreNames := splitList(viper.GetString("UNMANAGED_IGNORE_NAMES"))
+	for _, pattern := range ignoreNames {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.New(errors.ErrConfigInvalid, "invalid UNMANAGED_IGNORE_NAMES",
+				map[string]interface{}{
+					"config_key": "UNMANAGED_IGNORE_NAMES",
+					"value":      pattern,
+				}, err)
+		}
+	}
+	logger.Info("Unmanaged resource filters configured",
+		zap.Int("ignore_tags", len(ignoreTags)),
+		zap.Strings("ignore_names", ignoreNames),
+		zap.String("operation", "config_validation"),
+	)
+
+	// Accounts: AWS_ACCOUNTS="prod,staging" with AWS_ACCOUNT_PROD_ROLE_ARN,
+	// AWS_ACCOUNT_PROD_EXTERNAL_ID, AWS_ACCOUNT_PROD_PROFILE, ...
+	accounts, err := parseAccounts(splitList(viper.GetString("AWS_ACCOUNTS")), tfStatePath, mainTFPath)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Accounts configured",
+		zap.Int("accounts", len(accounts)),
+		zap.String("operation", "config_validation"),
+	)
+
 	config := &Config{
 		TFStatePath:       tfStatePath,
 		MainTFPath:        mainTFPath,
 		CheckInterval:     interval,
-		AWSRegion:         viper.GetString("AWS_REGION"),
+		AWSRegion:         region,
+		AWSRegions:        regions,
+		ProviderRegions:   providerRegions,
 		AccessSecret:      viper.GetString("AWS_SECRET_ACCESS_KEY"),
 		AcessKeyID:        viper.GetString("AWS_ACCESS_KEY_ID"),
 		LogLevel:          viper.GetString("LOG_LEVEL"),
 		MaxRetries:        maxRetries,
 		RetryDelay:        retryDelay,
 		ComparisonTimeout: comparisonTimeout,
+		FailureBudget:     failureBudget,
+		ReportPath:        viper.GetString("REPORT_PATH"),
+
+		UnmanagedIgnoreTags:  ignoreTags,
+		UnmanagedIgnoreNames: ignoreNames,
+		Accounts:             accounts,
 	}
 
 	logger.Info("Configuration loaded successfully",
@@ -156,3 +260,95 @@ func Initialize() (*Config, error) {
 	)
 	return config, nil
 }
+
+// readConfigFile reads the .env file, or the config file set before Initialize. A
+// missing file isn't an error: the environment and defaults apply.
+func readConfigFile(logger *zap.Logger) error {
+	if viper.ConfigFileUsed() == "" {
+		viper.SetConfigFile(".env")
+	}
+	viper.SetConfigType("env")
+	if err := viper.ReadInConfig(); err != nil {
+		// With an explicit config file, viper reports a missing one as an os error
+		// rather than ConfigFileNotFoundError
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok && !os.IsNotExist(err) {
+			return errors.New(errors.ErrConfigParse, "error reading config file",
+				map[string]interface{}{
+					"config_file": viper.ConfigFileUsed(),
+				}, err)
+		}
+		logger.Info("No .env file found, using environment variables and defaults",
+			zap.String("operation", "config_loading"),
+		)
+	}
+	return nil
+}
+
+// parseAccounts reads the settings of each account target from its
+// AWS_ACCOUNT_<NAME>_ keys, where the name is upper-cased and dashes become underscores
+func parseAccounts(names []string, tfStatePath, mainTFPath string) ([]AccountTarget, error) {
+	accounts := make([]AccountTarget, 0, len(names))
+	for _, name := range names {
+		prefix := "AWS_ACCOUNT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		account := AccountTarget{
+			Name:        name,
+			RoleARN:     viper.GetString(prefix + "ROLE_ARN"),
+			ExternalID:  viper.GetString(prefix + "EXTERNAL_ID"),
+			SessionName: viper.GetString(prefix + "SESSION_NAME"),
+			Profile:     viper.GetString(prefix + "PROFILE"),
+			TFStatePath: viper.GetString(prefix + "TFSTATE_PATH"),
+			MainTFPath:  viper.GetString(prefix + "MAINTF_PATH"),
+		}
+		if account.RoleARN == "" && account.Profile == "" {
+			return nil, errors.New(errors.ErrConfigInvalid, "account needs a role ARN or a profile",
+				map[string]interface{}{
+					"config_key": prefix + "ROLE_ARN",
+					"account":    name,
+				}, nil)
+		}
+		if account.SessionName == "" {
+			account.SessionName = "drift-checker"
+		}
+		if account.TFStatePath == "" {
+			account.TFStatePath = tfStatePath
+		}
+		if account.MainTFPath == "" {
+			account.MainTFPath = mainTFPath
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseTagList parses a comma-separated list of key=value or bare key tag filters
+func parseTagList(value string) map[string]string {
+	tags := make(map[string]string)
+	for _, item := range splitList(value) {
+		key, val, _ := strings.Cut(item, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			tags[key] = strings.TrimSpace(val)
+		}
+	}
+	return tags
+}
+
+// containsString reports whether a list contains the value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"Savannahtakehomeassi/configuration"
 )
@@ -53,6 +54,8 @@ func TestInitialize_TableDriven(t *testing.T) {
 				"MAX_RETRIES":                "5",
 				"RETRY_DELAY_SECONDS":        "10",
 				"COMPARISON_TIMEOUT_SECONDS": "60",
+				"MAX_CONSECUTIVE_FAILURES":   "3",
+				"REPORT_PATH":                "/var/lib/drift/report.json",
 			},
 			expectErr: false,
 			assertions: func(t *testing.T, cfg *configuration.Config) {
@@ -66,6 +69,8 @@ func TestInitialize_TableDriven(t *testing.T) {
 				assert.Equal(t, 5, cfg.MaxRetries)
 				assert.Equal(t, 10, cfg.RetryDelay)
 				assert.Equal(t, 60, cfg.ComparisonTimeout)
+				assert.Equal(t, 3, cfg.FailureBudget)
+				assert.Equal(t, "/var/lib/drift/report.json", cfg.ReportPath)
 			},
 		},
 		{
@@ -94,8 +99,97 @@ COMPARISON_TIMEOUT_SECONDS=25
 				assert.Equal(t, 2, cfg.MaxRetries)
 				assert.Equal(t, 6, cfg.RetryDelay)
 				assert.Equal(t, 25, cfg.ComparisonTimeout)
+				assert.Equal(t, 5, cfg.FailureBudget)
 			},
 		},
+		{
+			name: "Unmanaged resource filters",
+			env: map[string]string{
+				"UNMANAGED_IGNORE_TAGS":  "aws:autoscaling:groupName, drift=ignore",
+				"UNMANAGED_IGNORE_NAMES": "bastion-*,,eks-node-*",
+			},
+			expectErr: false,
+			assertions: func(t *testing.T, cfg *configuration.Config) {
+				assert.Equal(t, map[string]string{"aws:autoscaling:groupName": "", "drift": "ignore"}, cfg.UnmanagedIgnoreTags)
+				assert.Equal(t, []string{"bastion-*", "eks-node-*"}, cfg.UnmanagedIgnoreNames)
+			},
+		},
+		{
+			name: "Multiple regions",
+			env: map[string]string{
+				"AWS_REGION":           "us-east-1",
+				"AWS_REGIONS":          "eu-west-1, us-east-1,ap-southeast-2",
+				"AWS_PROVIDER_REGIONS": "eu=eu-west-1,apac=ap-southeast-2",
+			},
+			expectErr: false,
+			assertions: func(t *testing.T, cfg *configuration.Config) {
+				assert.Equal(t, "us-east-1", cfg.AWSRegion)
+				assert.Equal(t, []string{"us-east-1", "eu-west-1", "ap-southeast-2"}, cfg.AWSRegions)
+				assert.Equal(t, map[string]string{"eu": "eu-west-1", "apac": "ap-southeast-2"}, cfg.ProviderRegions)
+			},
+		},
+		{
+			name: "Provider alias mapped to a region that isn't scanned",
+			env: map[string]string{
+				"AWS_REGIONS":          "us-east-1",
+				"AWS_PROVIDER_REGIONS": "eu=eu-west-1",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Account targets",
+			env: map[string]string{
+				"TFSTATE_PATH":                       "shared.tfstate",
+				"MAINTF_PATH":                        "main.tf",
+				"AWS_ACCOUNTS":                       "prod,data-lake",
+				"AWS_ACCOUNT_PROD_ROLE_ARN":          "arn:aws:iam::111111111111:role/drift-reader",
+				"AWS_ACCOUNT_PROD_EXTERNAL_ID":       "ext-123",
+				"AWS_ACCOUNT_PROD_TFSTATE_PATH":      "prod.tfstate",
+				"AWS_ACCOUNT_DATA_LAKE_PROFILE":      "data-lake",
+				"AWS_ACCOUNT_DATA_LAKE_SESSION_NAME": "drift",
+			},
+			expectErr: false,
+			assertions: func(t *testing.T, cfg *configuration.Config) {
+				assert.Equal(t, []configuration.AccountTarget{
+					{
+						Name:        "prod",
+						RoleARN:     "arn:aws:iam::111111111111:role/drift-reader",
+						ExternalID:  "ext-123",
+						SessionName: "drift-checker",
+						TFStatePath: "prod.tfstate",
+						MainTFPath:  "main.tf",
+					},
+					{
+						Name:        "data-lake",
+						SessionName: "drift",
+						Profile:     "data-lake",
+						TFStatePath: "shared.tfstate",
+						MainTFPath:  "main.tf",
+					},
+				}, cfg.Accounts)
+			},
+		},
+		{
+			name: "Account without a role or profile",
+			env: map[string]string{
+				"AWS_ACCOUNTS": "prod",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid UNMANAGED_IGNORE_NAMES pattern",
+			env: map[string]string{
+				"UNMANAGED_IGNORE_NAMES": "web-[",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid MAX_CONSECUTIVE_FAILURES",
+			env: map[string]string{
+				"MAX_CONSECUTIVE_FAILURES": "0",
+			},
+			expectErr: true,
+		},
 		{
 			name: "Invalid CHECK_INTERVAL_MINUTES from env",
 			env: map[string]string{
@@ -167,6 +261,7 @@ COMPARISON_TIMEOUT_SECONDS=45
 	assert.Equal(t, "custom_main.tf", cfg.MainTFPath)
 	assert.Equal(t, 10, cfg.CheckInterval)
 	assert.Equal(t, "eu-west-1", cfg.AWSRegion)
+	assert.Equal(t, []string{"eu-west-1"}, cfg.AWSRegions)
 	assert.Equal(t, "TESTKEY", cfg.AcessKeyID)
 	assert.Equal(t, "TESTSECRET", cfg.AccessSecret)
 	assert.Equal(t, "warn", cfg.LogLevel)
@@ -174,3 +269,16 @@ COMPARISON_TIMEOUT_SECONDS=45
 	assert.Equal(t, 7, cfg.RetryDelay)
 	assert.Equal(t, 45, cfg.ComparisonTimeout)
 }
+
+func TestInitialize_WithoutEnvFile(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer os.Chdir(wd)
+
+	viper.Reset()
+	cfg, err := configuration.Initialize()
+	require.NoError(t, err)
+	assert.Equal(t, "terraform.tfstate", cfg.TFStatePath)
+	assert.Equal(t, "main.tf", cfg.MainTFPath)
+}
@@ -0,0 +1,117 @@
+package driftChecker
+
+import (
+	"context"
+	"strconv"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// stateBlockDevice is a root_block_device or ebs_block_device entry of the state,
+// reduced to the fields compared with the live volume
+type stateBlockDevice struct {
+	// path is the attribute path of the device, e.g. root_block_device or
+	// ebs_block_device["/dev/sdf"]
+	path                string
+	deviceName          string
+	volumeID            string
+	volumeSize          int
+	volumeType          string
+	iops                int
+	throughput          int
+	encrypted           bool
+	kmsKeyID            string
+	deleteOnTermination bool
+}
+
+// stateBlockDevices lists the root and EBS block devices recorded in the state. A root
+// device without a device name is the instance's live root device.
+func stateBlockDevices(aws *awsm.AWSInstance, tf *terafm.Instance) []stateBlockDevice {
+	var devices []stateBlockDevice
+	for _, root := range tf.Attributes.RootBlockDevice {
+		deviceName := root.DeviceName
+		if deviceName == "" {
+			deviceName = aws.RootDeviceName
+		}
+		devices = append(devices, stateBlockDevice{
+			path:                "root_block_device",
+			deviceName:          deviceName,
+			volumeID:            root.VolumeID,
+			volumeSize:          root.VolumeSize,
+			volumeType:          root.VolumeType,
+			iops:                root.Iops,
+			throughput:          root.Throughput,
+			encrypted:           root.Encrypted,
+			kmsKeyID:            root.KmsKeyID,
+			deleteOnTermination: root.DeleteOnTermination,
+		})
+	}
+	for _, ebs := range tf.Attributes.EbsBlockDevice {
+		devices = append(devices, stateBlockDevice{
+			path:                "ebs_block_device[" + strconv.Quote(ebs.DeviceName) + "]",
+			deviceName:          ebs.DeviceName,
+			volumeID:            ebs.VolumeID,
+			volumeSize:          ebs.VolumeSize,
+			volumeType:          ebs.VolumeType,
+			iops:                ebs.Iops,
+			throughput:          ebs.Throughput,
+			encrypted:           ebs.Encrypted,
+			kmsKeyID:            ebs.KmsKeyID,
+			deleteOnTermination: ebs.DeleteOnTermination,
+		})
+	}
+	return devices
+}
+
+// compareBlockDevices compares every root and EBS block device in the state with the
+// live volume attached under the same device name. Volumes attached outside the
+// instance's block devices, e.g. by aws_volume_attachment, aren't in the state and
+// are not reported.
+func compareBlockDevices(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- Drift) {
+	live := make(map[string]awsm.BlockDeviceMapping, len(aws.BlockDeviceMappings))
+	for _, mapping := range aws.BlockDeviceMappings {
+		live[mapping.DeviceName] = mapping
+	}
+
+	for _, device := range stateBlockDevices(aws, tf) {
+		mapping, ok := live[device.deviceName]
+		if !ok {
+			ch <- attributeDrift(device.path, device.deviceName, "")
+			continue
+		}
+
+		if device.volumeID != "" && mapping.VolumeId != device.volumeID {
+			ch <- attributeDrift(device.path+".volume_id", device.volumeID, mapping.VolumeId)
+		}
+		if mapping.DeleteOnTermination != device.deleteOnTermination {
+			ch <- attributeDrift(device.path+".delete_on_termination",
+				strconv.FormatBool(device.deleteOnTermination), strconv.FormatBool(mapping.DeleteOnTermination))
+		}
+		if !mapping.HasVolumeDetails() {
+			continue
+		}
+
+		if device.volumeSize != 0 && int(mapping.VolumeSize) != device.volumeSize {
+			ch <- attributeDrift(device.path+".volume_size",
+				strconv.Itoa(device.volumeSize), strconv.Itoa(int(mapping.VolumeSize)))
+		}
+		if device.volumeType != "" && mapping.VolumeType != device.volumeType {
+			ch <- attributeDrift(device.path+".volume_type", device.volumeType, mapping.VolumeType)
+		}
+		if device.iops != 0 && int(mapping.Iops) != device.iops {
+			ch <- attributeDrift(device.path+".iops", strconv.Itoa(device.iops), strconv.Itoa(int(mapping.Iops)))
+		}
+		if device.throughput != 0 && int(mapping.Throughput) != device.throughput {
+			ch <- attributeDrift(device.path+".throughput",
+				strconv.Itoa(device.throughput), strconv.Itoa(int(mapping.Throughput)))
+		}
+		if mapping.Encrypted != device.encrypted {
+			ch <- attributeDrift(device.path+".encrypted",
+				strconv.FormatBool(device.encrypted), strconv.FormatBool(mapping.Encrypted))
+		}
+		if device.kmsKeyID != "" && mapping.KmsKeyId != device.kmsKeyID {
+			ch <- attributeDrift(device.path+".kms_key_id", device.kmsKeyID, mapping.KmsKeyId)
+		}
+	}
+}
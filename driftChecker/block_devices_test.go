@@ -0,0 +1,113 @@
+package driftChecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+func TestCompareBlockDevices(t *testing.T) {
+	kmsKey := "arn:aws:kms:us-east-1:000000000000:key/abc"
+	liveRoot := awsm.BlockDeviceMapping{DeviceName: "/dev/xvda", VolumeId: "vol-root", DeleteOnTermination: true, VolumeSize: 8, VolumeType: "gp2", Iops: 100}
+	liveData := awsm.BlockDeviceMapping{DeviceName: "/dev/sdf", VolumeId: "vol-data", VolumeSize: 100, VolumeType: "gp3", Iops: 3000, Throughput: 125, Encrypted: true, KmsKeyId: kmsKey}
+	stateRoot := terafm.RootBlockDevice{DeviceName: "/dev/xvda", VolumeID: "vol-root", DeleteOnTermination: true, VolumeSize: 8, VolumeType: "gp2", Iops: 100}
+	stateData := terafm.EbsBlockDevice{DeviceName: "/dev/sdf", VolumeID: "vol-data", VolumeSize: 100, VolumeType: "gp3", Iops: 3000, Throughput: 125, Encrypted: true, KmsKeyID: kmsKey}
+
+	tests := []struct {
+		name     string
+		live     []awsm.BlockDeviceMapping
+		root     []terafm.RootBlockDevice
+		ebs      []terafm.EbsBlockDevice
+		expected []Drift
+	}{
+		{
+			name: "no drift",
+			live: []awsm.BlockDeviceMapping{liveRoot, liveData},
+			root: []terafm.RootBlockDevice{stateRoot},
+			ebs:  []terafm.EbsBlockDevice{stateData},
+		},
+		{
+			name: "root volume resized and retyped",
+			live: []awsm.BlockDeviceMapping{
+				{DeviceName: "/dev/xvda", VolumeId: "vol-root", DeleteOnTermination: true, VolumeSize: 20, VolumeType: "gp3", Iops: 100},
+			},
+			root: []terafm.RootBlockDevice{stateRoot},
+			expected: []Drift{
+				attributeDrift("root_block_device.volume_size", "8", "20"),
+				attributeDrift("root_block_device.volume_type", "gp2", "gp3"),
+			},
+		},
+		{
+			name: "root device name taken from the instance",
+			live: []awsm.BlockDeviceMapping{
+				{DeviceName: "/dev/xvda", VolumeId: "vol-other", DeleteOnTermination: true, VolumeSize: 8, VolumeType: "gp2", Iops: 100},
+			},
+			root: []terafm.RootBlockDevice{{VolumeID: "vol-root", DeleteOnTermination: true, VolumeSize: 8, VolumeType: "gp2", Iops: 100}},
+			expected: []Drift{
+				attributeDrift("root_block_device.volume_id", "vol-root", "vol-other"),
+			},
+		},
+		{
+			name: "EBS volume changes",
+			live: []awsm.BlockDeviceMapping{
+				{DeviceName: "/dev/sdf", VolumeId: "vol-data", DeleteOnTermination: true, VolumeSize: 100, VolumeType: "gp3", Iops: 6000, Throughput: 250, Encrypted: false},
+			},
+			ebs: []terafm.EbsBlockDevice{stateData},
+			expected: []Drift{
+				attributeDrift(`ebs_block_device["/dev/sdf"].delete_on_termination`, "false", "true"),
+				attributeDrift(`ebs_block_device["/dev/sdf"].iops`, "3000", "6000"),
+				attributeDrift(`ebs_block_device["/dev/sdf"].throughput`, "125", "250"),
+				attributeDrift(`ebs_block_device["/dev/sdf"].encrypted`, "true", "false"),
+				attributeDrift(`ebs_block_device["/dev/sdf"].kms_key_id`, kmsKey, ""),
+			},
+		},
+		{
+			name: "EBS volume detached",
+			live: []awsm.BlockDeviceMapping{liveRoot},
+			root: []terafm.RootBlockDevice{stateRoot},
+			ebs:  []terafm.EbsBlockDevice{stateData},
+			expected: []Drift{
+				attributeDrift(`ebs_block_device["/dev/sdf"]`, "/dev/sdf", ""),
+			},
+		},
+		{
+			name: "volume size unset in the state",
+			live: []awsm.BlockDeviceMapping{liveData},
+			ebs:  []terafm.EbsBlockDevice{{DeviceName: "/dev/sdf", VolumeID: "vol-data", VolumeType: "gp3", Iops: 3000, Throughput: 125, Encrypted: true, KmsKeyID: kmsKey}},
+		},
+		{
+			name: "volume details unavailable",
+			live: []awsm.BlockDeviceMapping{{DeviceName: "/dev/sdf", VolumeId: "vol-data"}},
+			ebs:  []terafm.EbsBlockDevice{stateData},
+		},
+		{
+			name: "volumes attached outside the instance are not reported",
+			live: []awsm.BlockDeviceMapping{liveRoot, liveData},
+			root: []terafm.RootBlockDevice{stateRoot},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aws := &awsm.AWSInstance{InstanceID: "i-1", RootDeviceName: "/dev/xvda", BlockDeviceMappings: tt.live}
+			tf := &terafm.Instance{Attributes: terafm.InstanceAttributes{RootBlockDevice: tt.root, EbsBlockDevice: tt.ebs}}
+
+			ch := make(chan Drift, 16)
+			compareBlockDevices(context.Background(), aws, tf, ch)
+			close(ch)
+
+			var drifts []Drift
+			for d := range ch {
+				drifts = append(drifts, d)
+			}
+			assert.Equal(t, tt.expected, drifts)
+		})
+	}
+
+	assert.Equal(t, SeverityHigh, severityFor(`ebs_block_device["/dev/sdf"].encrypted`))
+	assert.Equal(t, SeverityMedium, severityFor("root_block_device.volume_size"))
+}
@@ -0,0 +1,337 @@
+package driftChecker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Source is the side of Terraform a live value was compared against
+type Source string
+
+const (
+	SourceState  Source = "state"
+	SourceConfig Source = "config"
+)
+
+// Severity ranks how urgently a drift needs attention
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Category describes what kind of drift was found
+type Category string
+
+const (
+	// CategoryOutOfBand is a live value that differs from the Terraform state, i.e. a
+	// change made outside Terraform (console, CLI) that needs to be chased down
+	CategoryOutOfBand Category = "out_of_band"
+	// CategoryConfigNotApplied is a live value that matches the state but not the HCL
+	// config, i.e. a config change still waiting for terraform apply
+	CategoryConfigNotApplied Category = "config_not_applied"
+	// CategoryUnmanaged is a live resource that no Terraform state entry manages
+	CategoryUnmanaged Category = "unmanaged"
+	// CategoryMissing is a managed state entry whose live resource no longer exists
+	CategoryMissing Category = "missing"
+)
+
+// attributeSeverities holds the severity of attributes that aren't SeverityMedium
+var attributeSeverities = map[string]Severity{
+	"tags":                    SeverityLow,
+	"private_ip":              SeverityLow,
+	"public_ip":               SeverityLow,
+	"vpc_security_group_ids":  SeverityHigh,
+	"security_groups":         SeverityHigh,
+	"ingress":                 SeverityHigh,
+	"route":                   SeverityHigh,
+	"map_public_ip_on_launch": SeverityHigh,
+	"policy":                  SeverityHigh,
+	"public_access_block":     SeverityHigh,
+	"assume_role_policy":      SeverityHigh,
+	"inline_policy":           SeverityHigh,
+	"managed_policy_arns":     SeverityHigh,
+
+	"server_side_encryption_configuration": SeverityHigh,
+}
+
+// leafSeverities holds the severity of nested attributes wherever they appear, e.g.
+// the encryption of any block device
+var leafSeverities = map[string]Severity{
+	"encrypted":  SeverityHigh,
+	"kms_key_id": SeverityHigh,
+}
+
+// severityFor returns the severity of a drift in the given attribute path
+func severityFor(attribute string) Severity {
+	for name := attribute; name != ""; name = parentAttribute(name) {
+		if severity, ok := attributeSeverities[name]; ok {
+			return severity
+		}
+	}
+	leaf := attribute[strings.LastIndex(attribute, ".")+1:]
+	if severity, ok := leafSeverities[leaf]; ok {
+		return severity
+	}
+	return SeverityMedium
+}
+
+// parentAttribute strips the last element of a dotted attribute path
+func parentAttribute(attribute string) string {
+	if i := strings.LastIndex(attribute, "."); i != -1 {
+		return attribute[:i]
+	}
+	return ""
+}
+
+// Change describes how an element of a collection attribute, such as one security
+// group of an instance, drifted
+type Change string
+
+const (
+	// ChangeAdded is an element present live but not in Terraform
+	ChangeAdded Change = "added"
+	// ChangeRemoved is an element in Terraform that is gone live
+	ChangeRemoved Change = "removed"
+	// ChangeModified is an element present on both sides with different values
+	ChangeModified Change = "changed"
+)
+
+// Drift is a single difference between a live AWS resource and Terraform
+type Drift struct {
+	// Address is the Terraform address, e.g. module.app.aws_instance.web["blue"]
+	Address string `json:"address"`
+	// ResourceID is the AWS ID of the live resource
+	ResourceID string `json:"resource_id"`
+	// ResourceType is the Terraform type of an unmanaged resource, which has no address
+	ResourceType string `json:"resource_type,omitempty"`
+	// Account and Region are the AWS account and region the resource was checked in
+	Account string `json:"account,omitempty"`
+	Region  string `json:"region,omitempty"`
+	// Attribute is the dotted attribute path, e.g. tags.Name or root_block_device.volume_id
+	Attribute string   `json:"attribute"`
+	Expected  string   `json:"expected"`
+	Actual    string   `json:"actual"`
+	Source    Source   `json:"source"`
+	Severity  Severity `json:"severity"`
+	Category  Category `json:"category"`
+	// Change is set for drifts of a single element of a collection attribute
+	Change Change `json:"change,omitempty"`
+	// Details gives context on the drifted element, e.g. a security group's name and VPC
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// String formats the drift for logs and messages. An unmanaged resource is named by
+// its type.
+func (d Drift) String() string {
+	if d.Attribute == "" {
+		name := d.Address
+		if name == "" {
+			name = d.ResourceType
+		}
+		return fmt.Sprintf("%s (%s): %s", name, d.ResourceID, d.Category)
+	}
+	return fmt.Sprintf("%s (%s): %s drift (expected: %q, actual: %q, source: %s)",
+		d.Address, d.ResourceID, d.Attribute, d.Expected, d.Actual, d.Source)
+}
+
+// attributeDrift creates a drift of attribute; the resource fields are filled in by
+// the caller collecting the drifts
+func attributeDrift(attribute, expected, actual string) Drift {
+	return Drift{
+		Attribute: attribute,
+		Expected:  expected,
+		Actual:    actual,
+		Severity:  severityFor(attribute),
+	}
+}
+
+// unmanagedDrift reports a live resource of the given Terraform type that Terraform
+// doesn't know about
+func unmanagedDrift(resourceType, resourceID string) Drift {
+	return Drift{
+		ResourceID:   resourceID,
+		ResourceType: resourceType,
+		Source:       SourceState,
+		Severity:     SeverityMedium,
+		Category:     CategoryUnmanaged,
+	}
+}
+
+// missingDrift reports a managed resource whose live counterpart is gone
+func missingDrift(address, resourceID string) Drift {
+	return Drift{
+		Address:    address,
+		ResourceID: resourceID,
+		Source:     SourceState,
+		Severity:   SeverityHigh,
+		Category:   CategoryMissing,
+	}
+}
+
+// classifyDrifts combines the live-vs-state and live-vs-config drifts of one resource
+// into a single three-way classification per attribute. A live value that differs
+// from the state is an out-of-band change, whatever the config says. A live value
+// that matches the state but differs from the config means the config differs from
+// the state too, so the change hasn't been applied.
+func classifyDrifts(stateDrifts, configDrifts []Drift) []Drift {
+	outOfBand := make(map[string]bool, len(stateDrifts))
+	drifts := make([]Drift, 0, len(stateDrifts)+len(configDrifts))
+	for _, d := range stateDrifts {
+		d.Category = CategoryOutOfBand
+		outOfBand[d.Attribute] = true
+		drifts = append(drifts, d)
+	}
+	for _, d := range configDrifts {
+		if outOfBand[d.Attribute] {
+			continue
+		}
+		d.Category = CategoryConfigNotApplied
+		drifts = append(drifts, d)
+	}
+	return drifts
+}
+
+// DriftReport is the outcome of one drift check iteration of an account
+type DriftReport struct {
+	Account     string         `json:"account,omitempty"`
+	StartedAt   time.Time      `json:"started_at"`
+	CompletedAt time.Time      `json:"completed_at"`
+	Regions     []string       `json:"regions,omitempty"`
+	State       *StateMetadata `json:"state,omitempty"`
+	// ResourcesChecked counts the state resources compared to their live resource;
+	// Resources lists them, along with the state resources found missing
+	ResourcesChecked int               `json:"resources_checked"`
+	Resources        []CheckedResource `json:"resources"`
+	Drifts           []Drift           `json:"drifts"`
+	// Partial is set when the check didn't finish, e.g. because it ran past
+	// COMPARISON_TIMEOUT_SECONDS. Unfinished names what wasn't checked, or only in part.
+	Partial    bool              `json:"partial"`
+	Unfinished []UnfinishedCheck `json:"unfinished,omitempty"`
+}
+
+// StateMetadata identifies the Terraform state a report was checked against
+type StateMetadata struct {
+	Path             string `json:"path"`
+	Serial           int    `json:"serial"`
+	Lineage          string `json:"lineage"`
+	TerraformVersion string `json:"terraform_version,omitempty"`
+}
+
+// ResourceStatus is the outcome of the check of a state resource
+type ResourceStatus string
+
+const (
+	// ResourceInSync is a resource whose live resource matches Terraform
+	ResourceInSync ResourceStatus = "in_sync"
+	// ResourceDrifted is a resource with at least one drifted attribute
+	ResourceDrifted ResourceStatus = "drifted"
+	// ResourceMissing is a resource whose live resource is gone
+	ResourceMissing ResourceStatus = "missing"
+)
+
+// CheckedResource is a state resource checked against its live resource
+type CheckedResource struct {
+	Address    string         `json:"address"`
+	Type       string         `json:"type"`
+	ResourceID string         `json:"resource_id"`
+	Region     string         `json:"region,omitempty"`
+	Status     ResourceStatus `json:"status"`
+}
+
+// UnfinishedCheck is a resource type of a region whose check didn't finish
+type UnfinishedCheck struct {
+	Region       string `json:"region,omitempty"`
+	ResourceType string `json:"resource_type"`
+	Reason       string `json:"reason"`
+}
+
+// newDriftReport starts an empty report
+func newDriftReport() *DriftReport {
+	return &DriftReport{
+		StartedAt: time.Now().UTC(),
+		Resources: []CheckedResource{},
+		Drifts:    []Drift{},
+	}
+}
+
+// Add appends drifts to the report
+func (r *DriftReport) Add(drifts ...Drift) {
+	r.Drifts = append(r.Drifts, drifts...)
+}
+
+// complete stamps the completion time, orders the resources by address and the
+// drifts by address, source and attribute
+func (r *DriftReport) complete() {
+	r.CompletedAt = time.Now().UTC()
+	sort.SliceStable(r.Resources, func(i, j int) bool {
+		return r.Resources[i].Address < r.Resources[j].Address
+	})
+	sort.SliceStable(r.Drifts, func(i, j int) bool {
+		a, b := r.Drifts[i], r.Drifts[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.Source != b.Source {
+			return a.Source > b.Source
+		}
+		return a.Attribute < b.Attribute
+	})
+}
+
+// markUnfinished records a resource type whose check was cut short by the end of ctx
+func (r *DriftReport) markUnfinished(region, resourceType string, ctxErr error) {
+	reason := "cancelled"
+	if ctxErr == context.DeadlineExceeded {
+		reason = "timed out"
+	}
+	r.Partial = true
+	r.Unfinished = append(r.Unfinished, UnfinishedCheck{
+		Region:       region,
+		ResourceType: resourceType,
+		Reason:       reason,
+	})
+}
+
+// HasDrift reports whether any drift was found
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+// ForAddress returns the drifts of the resource at the given Terraform address
+func (r *DriftReport) ForAddress(address string) []Drift {
+	var drifts []Drift
+	for _, d := range r.Drifts {
+		if d.Address == address {
+			drifts = append(drifts, d)
+		}
+	}
+	return drifts
+}
+
+// logFields returns the structured log fields of a drift
+func (d Drift) logFields() []zap.Field {
+	return []zap.Field{
+		zap.String("address", d.Address),
+		zap.String("resource_id", d.ResourceID),
+		zap.String("resource_type", d.ResourceType),
+		zap.String("account", d.Account),
+		zap.String("region", d.Region),
+		zap.String("attribute", d.Attribute),
+		zap.String("expected", d.Expected),
+		zap.String("actual", d.Actual),
+		zap.String("source", string(d.Source)),
+		zap.String("severity", string(d.Severity)),
+		zap.String("category", string(d.Category)),
+		zap.String("change", string(d.Change)),
+		zap.Any("details", d.Details),
+	}
+}
@@ -1,7 +1,6 @@
 package driftChecker
 
 import (
-	"Savannahtakehomeassi/logger"
 	"context"
 	"sync"
 	"time"
@@ -9,6 +8,7 @@ import (
 	"go.uber.org/zap"
 
 	"Savannahtakehomeassi/errors"
+	terafm "Savannahtakehomeassi/teraform/models"
 )
 
 // DriftService handles drift checking operations
@@ -16,76 +16,209 @@ type DriftService struct {
 	awsClient       AWSClient
 	terraformClient TerraformClient
 	logger          *zap.Logger
+	unmanagedFilter UnmanagedFilter
+	// registry holds the handlers of the home region, named by region; regions holds
+	// the additional regions scanned
+	registry        *Registry
+	account         string
+	region          string
+	regions         []*regionScope
+	providerRegions map[string]string
+	// comparisonTimeout bounds a drift check iteration; zero means no deadline
+	comparisonTimeout time.Duration
+	// failureBudget is the number of consecutive failed checks after which RunLoop
+	// gives up; zero means it never does
+	failureBudget int
+	// reportPath is where RunLoop writes the JSON report of every check; empty means
+	// no report is written
+	reportPath string
+	// sleep waits between the checks of RunLoop; tests replace it to run without delays
+	sleep func(ctx context.Context, d time.Duration) error
+
+	// mu guards the health of the loop
+	mu                  sync.Mutex
+	lastSuccess         time.Time
+	consecutiveFailures int
 }
 
-// NewDriftService creates a new DriftService instance
-func NewDriftService(awsClient AWSClient, terraformClient TerraformClient, logger *zap.Logger) *DriftService {
-	return &DriftService{
+// maxFailureBackoff caps the wait between checks after consecutive failures, unless
+// the check interval is longer
+const maxFailureBackoff = time.Hour
+
+// NewDriftService creates a new DriftService instance with the built-in resource
+// handlers registered
+func NewDriftService(awsClient AWSClient, terraformClient TerraformClient, logger *zap.Logger, opts ...Option) *DriftService {
+	s := &DriftService{
 		awsClient:       awsClient,
 		terraformClient: terraformClient,
 		logger:          logger,
+		registry:        newBuiltinRegistry(awsClient, logger),
+		sleep:           sleepContext,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// RunLoop runs the drift checking loop
-func (s *DriftService) RunLoop(ctx context.Context, tfSpath, mainfile string, interval int) error {
-	s.logger.Info("Starting drift checker loop",
-		zap.String("operation", "loop_start"),
+// newBuiltinRegistry creates a registry of the built-in handlers bound to an AWS client
+func newBuiltinRegistry(awsClient AWSClient, logger *zap.Logger) *Registry {
+	return NewRegistry(
+		NewInstanceHandler(awsClient, logger),
+		NewSecurityGroupHandler(awsClient, logger),
+		NewVpcHandler(awsClient, logger),
+		NewSubnetHandler(awsClient, logger),
+		NewRouteTableHandler(awsClient, logger),
+		NewInternetGatewayHandler(awsClient, logger),
 	)
+}
 
-	ticker := time.NewTicker(time.Duration(interval) * time.Second)
-	defer ticker.Stop()
+// WithComparisonTimeout bounds every drift check iteration. An iteration that runs
+// past it returns a partial report naming the resource types it didn't finish.
+func WithComparisonTimeout(timeout time.Duration) Option {
+	return func(s *DriftService) {
+		s.comparisonTimeout = timeout
+	}
+}
 
-	// First run immediately
-	if err := s.runDriftCheck(ctx, tfSpath, mainfile); err != nil {
-		return errors.New(errors.ErrDriftChecker, "Initial drift check failed",
-			map[string]interface{}{
-				"operation": "initial_drift_check",
-			}, err)
+// WithFailureBudget makes RunLoop give up after the given number of consecutive
+// failed drift checks. Without it, the loop keeps checking until its context ends.
+func WithFailureBudget(failures int) Option {
+	return func(s *DriftService) {
+		s.failureBudget = failures
 	}
+}
+
+// RunLoop runs the drift checking loop, a check every interval. A failed check is
+// logged and the next one is delayed, doubling the wait with each consecutive failure;
+// the loop only returns an error once the failure budget is exhausted.
+func (s *DriftService) RunLoop(ctx context.Context, tfSpath, mainfile string, interval time.Duration) error {
+	s.logger.Info("Starting drift checker loop",
+		zap.String("operation", "loop_start"),
+	)
 
 	for {
-		select {
-		case <-ctx.Done():
-			logger.Info("Drift checker shutdown complete",
-				zap.String("operation", "loop_shutdown"),
-			)
-			return nil
-		case <-ticker.C:
-			if err := s.runDriftCheck(ctx, tfSpath, mainfile); err != nil {
-				return errors.New(errors.ErrDriftChecker, "Periodic drift check failed",
+		startedAt := time.Now()
+		report, err := s.runDriftCheck(ctx, tfSpath, mainfile)
+		s.writeReport(startedAt, report, err)
+		if err == nil && report.Partial {
+			err = errors.New(errors.ErrDriftChecker, "Drift check did not finish",
+				map[string]interface{}{
+					"operation":  "drift_check",
+					"unfinished": len(report.Unfinished),
+				}, nil)
+		}
+		switch {
+		case ctx.Err() != nil:
+			// Shutting down, the check was cut short rather than failed
+		case err != nil:
+			failures := s.recordFailure()
+			if s.failureBudget > 0 && failures >= s.failureBudget {
+				return errors.New(errors.ErrDriftChecker, "Drift check failure budget exhausted",
 					map[string]interface{}{
-						"operation": "periodic_drift_check",
+						"operation":             "drift_check",
+						"consecutive_failures":  failures,
+						"last_successful_check": s.LastSuccessfulCheck(),
 					}, err)
 			}
+			s.logger.Warn("Drift check failed, backing off",
+				zap.String("operation", "drift_check_failure"),
+				zap.Int("consecutive_failures", failures),
+				zap.Int("failure_budget", s.failureBudget),
+				zap.Time("last_successful_check", s.LastSuccessfulCheck()),
+				zap.Duration("next_check_in", failureBackoff(interval, failures)),
+				zap.Error(err),
+			)
+		default:
+			s.recordSuccess()
+		}
+
+		if err := s.sleep(ctx, failureBackoff(interval, s.ConsecutiveFailures())); err != nil {
+			s.logger.Info("Drift checker shutdown complete",
+				zap.String("operation", "loop_shutdown"),
+			)
+			return nil
 		}
 	}
 }
 
-// runDriftCheck performs a single drift check iteration
-func (s *DriftService) runDriftCheck(ctx context.Context, tfPath, mainFile string) error {
+// sleepContext waits for d, returning early with the context's error when it's done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Check runs a single drift check and returns its report, for one-shot runs outside
+// RunLoop
+func (s *DriftService) Check(ctx context.Context, tfPath, mainFile string) (*DriftReport, error) {
+	return s.runDriftCheck(ctx, tfPath, mainFile)
+}
+
+// LastSuccessfulCheck returns when the last drift check that completed finished, or
+// the zero time when none has yet
+func (s *DriftService) LastSuccessfulCheck() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSuccess
+}
+
+// ConsecutiveFailures returns the number of drift checks that failed since the last
+// successful one
+func (s *DriftService) ConsecutiveFailures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.consecutiveFailures
+}
+
+// recordSuccess marks a completed check, resetting the failure count
+func (s *DriftService) recordSuccess() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSuccess = time.Now()
+	s.consecutiveFailures = 0
+}
+
+// recordFailure counts a failed check and returns the consecutive failures so far
+func (s *DriftService) recordFailure() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consecutiveFailures++
+	return s.consecutiveFailures
+}
+
+// failureBackoff returns the wait before the next check: the interval, doubled for
+// each consecutive failure and capped at maxFailureBackoff or the interval if longer
+func failureBackoff(interval time.Duration, failures int) time.Duration {
+	wait := interval
+	for i := 0; i < failures && wait < maxFailureBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxFailureBackoff && interval < maxFailureBackoff {
+		return maxFailureBackoff
+	}
+	return wait
+}
+
+// runDriftCheck performs a single drift check iteration and returns the drift found.
+// When the comparison timeout expires first, the report is partial.
+func (s *DriftService) runDriftCheck(ctx context.Context, tfPath, mainFile string) (*DriftReport, error) {
 	s.logger.Info("Starting drift check iteration",
 		zap.String("operation", "drift_check_start"),
 	)
 
-	// Get AWS instance details
-	awsInstance, err := s.awsClient.GetAWSInstance()
-	if err != nil {
-		s.logger.Error("Failed to get AWS instance details",
-			zap.String("operation", "get_aws_instance"),
-			zap.Error(errors.New(errors.ErrAWSInstance, "Failed to get AWS instance",
-				map[string]interface{}{
-					"operation": "get_aws_instance",
-				}, err)),
-		)
-		return err
+	if s.comparisonTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.comparisonTimeout)
+		defer cancel()
 	}
-	s.logger.Info("Successfully retrieved AWS instance details",
-		zap.String("operation", "get_aws_instance"),
-		zap.String("instance_id", awsInstance.InstanceID),
-	)
 
-	tfState, err := s.terraformClient.ParseTerraformInstance(tfPath)
+	tfState, err := s.terraformClient.ParseTerraformInstance(ctx, tfPath)
 	if err != nil {
 		s.logger.Error("Failed to parse Terraform state",
 			zap.String("operation", "terraform_state_parse"),
@@ -95,13 +228,13 @@ func (s *DriftService) runDriftCheck(ctx context.Context, tfPath, mainFile strin
 					"path":      tfPath,
 				}, err)),
 		)
-		return err
+		return nil, err
 	}
 	s.logger.Info("Successfully parsed Terraform state",
 		zap.String("operation", "terraform_state_parse"),
 	)
 
-	tfConfig, err := s.terraformClient.ParseHCLConfig(mainFile)
+	tfConfig, err := s.terraformClient.ParseHCLConfig(ctx, mainFile)
 	if err != nil {
 		s.logger.Error("Failed to parse HCL config",
 			zap.String("operation", "hcl_config_parse"),
@@ -111,85 +244,209 @@ func (s *DriftService) runDriftCheck(ctx context.Context, tfPath, mainFile strin
 					"path":      mainFile,
 				}, err)),
 		)
-		return err
+		return nil, err
 	}
 	s.logger.Info("Successfully parsed HCL config",
 		zap.String("operation", "hcl_config_parse"),
 	)
 
-	// Channels for collecting results
-	type result struct {
-		drift []string
-		err   error
+	// Check the regions concurrently, each over the state resources routed to it
+	report := newDriftReport()
+	report.Account = s.account
+	if tfState != nil {
+		report.State = &StateMetadata{
+			Path:             tfPath,
+			Serial:           tfState.Serial,
+			Lineage:          tfState.Lineage,
+			TerraformVersion: tfState.TerraformVersion,
+		}
+	}
+	scopes := s.scopes()
+	states := map[string]*terafm.TerraformState{s.region: tfState}
+	if s.region != "" || len(s.regions) > 0 {
+		states = s.splitState(tfState, scopes)
 	}
-	results := make(chan result, 2)
 
+	regionReports := make([]*DriftReport, len(scopes))
+	regionErrs := make([]error, len(scopes))
 	var wg sync.WaitGroup
-	wg.Add(2)
+	for i, scope := range scopes {
+		wg.Add(1)
+		go func(i int, scope *regionScope) {
+			defer wg.Done()
+			regionReports[i], regionErrs[i] = s.checkRegion(ctx, scope, states[scope.name], tfConfig)
+		}(i, scope)
+	}
+	wg.Wait()
 
-	go func() {
-		defer wg.Done()
-		select {
-		case <-ctx.Done():
-			results <- result{nil, errors.New(errors.ErrDriftChecker, "drift check cancelled",
-				map[string]interface{}{
-					"operation": "drift_check",
-					"context":   "cancelled",
-				}, nil)}
-			return
-		default:
-			drift, err := compareAWSInstanceWithTerraform(ctx, awsInstance, tfState)
-			results <- result{drift, err}
+	for i, scope := range scopes {
+		if regionErrs[i] != nil {
+			return nil, regionErrs[i]
+		}
+		if scope.name != "" {
+			report.Regions = append(report.Regions, scope.name)
 		}
-	}()
+		report.ResourcesChecked += regionReports[i].ResourcesChecked
+		report.Resources = append(report.Resources, regionReports[i].Resources...)
+		report.Add(regionReports[i].Drifts...)
+		report.Partial = report.Partial || regionReports[i].Partial
+		report.Unfinished = append(report.Unfinished, regionReports[i].Unfinished...)
+	}
+	report.complete()
 
-	go func() {
-		defer wg.Done()
-		select {
-		case <-ctx.Done():
-			results <- result{nil, errors.New(errors.ErrDriftChecker, "HCL drift check cancelled",
-				map[string]interface{}{
-					"operation": "hcl_drift_check",
-					"context":   "cancelled",
-				}, nil)}
-			return
-		default:
-			drift, err := compareInstances(awsInstance, tfConfig)
-			results <- result{drift, err}
+	if report.Partial {
+		s.logger.Warn("Drift check did not finish, report is partial",
+			zap.String("operation", "drift_check_complete"),
+			zap.Duration("timeout", s.comparisonTimeout),
+			zap.Any("unfinished", report.Unfinished),
+			zap.Int("resources_checked", report.ResourcesChecked),
+			zap.Int("drift_count", len(report.Drifts)),
+		)
+		return report, nil
+	}
+	s.logger.Info("Drift check completed successfully",
+		zap.String("operation", "drift_check_complete"),
+		zap.Strings("regions", report.Regions),
+		zap.Int("resources_checked", report.ResourcesChecked),
+		zap.Int("drift_count", len(report.Drifts)),
+	)
+	return report, nil
+}
+
+// checkRegion runs the handler of every resource type found in the state of a region.
+// Once ctx is done, the resource types left are recorded as unfinished; the drift
+// already found is kept.
+func (s *DriftService) checkRegion(ctx context.Context, scope *regionScope, tfState *terafm.TerraformState, tfConfig *terafm.Config) (*DriftReport, error) {
+	report := newDriftReport()
+	for _, handler := range scope.registry.handlersFor(tfState) {
+		if ctx.Err() != nil {
+			report.markUnfinished(scope.name, handler.Types()[0], ctx.Err())
+			continue
 		}
-	}()
+		if err := s.checkResourceType(ctx, scope.name, handler, tfState, tfConfig, report); err != nil {
+			if ctx.Err() == nil {
+				return nil, err
+			}
+			report.markUnfinished(scope.name, handler.Types()[0], ctx.Err())
+		}
+	}
+	return report, nil
+}
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+// checkResourceType pairs the live resources of a handler with its state entries and
+// adds their drift to the report, along with the unmanaged and missing resources. The
+// drifts are tagged with the account and region checked.
+func (s *DriftService) checkResourceType(ctx context.Context, region string, handler ResourceHandler, tfState *terafm.TerraformState, tfConfig *terafm.Config, report *DriftReport) error {
+	resourceType := handler.Types()[0]
+	index := newStateIndex(handler.MapState(tfState))
 
-	// Handle results safely
-	for res := range results {
-		if res.err != nil {
-			s.logger.Error("Drift check failed",
-				zap.String("operation", "drift_check"),
-				zap.Error(res.err),
+	live, err := handler.FetchLive(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get live AWS resources",
+			zap.String("operation", "fetch_live"),
+			zap.String("resource_type", resourceType),
+			zap.String("region", region),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	liveIDs := make(map[string]bool, len(live))
+	for _, resource := range live {
+		liveIDs[resource.ID] = true
+
+		entry := index.lookup(resource.ID)
+		if entry == nil {
+			if !handler.ReportsUnmanaged() {
+				continue
+			}
+			if s.unmanagedFilter.Ignores(resource.ID, resource.Tags) {
+				s.logger.Info("Ignoring unmanaged AWS resource matched by filter",
+					zap.String("operation", "resource_match"),
+					zap.String("resource_type", resourceType),
+					zap.String("resource_id", resource.ID),
+				)
+				continue
+			}
+			drift := unmanagedDrift(resourceType, resource.ID)
+			drift.Account, drift.Region = s.account, region
+			s.logger.Warn("No Terraform resource found for AWS resource",
+				append([]zap.Field{
+					zap.String("operation", "resource_match"),
+					zap.String("resource_type", resourceType),
+				}, drift.logFields()...)...,
 			)
-			return res.err
+			report.Add(drift)
+			continue
 		}
+		s.logger.Info("Matched AWS resource to Terraform resource",
+			zap.String("operation", "resource_match"),
+			zap.String("resource_id", resource.ID),
+			zap.String("address", entry.Address),
+		)
 
-		if len(res.drift) == 1 && res.drift[0] == "No drift detected between AWS instance and Terraform state." {
-			s.logger.Info("No drift detected between AWS and Terraform",
-				zap.String("operation", "drift_check"),
-				zap.String("status", "no_drift"),
-			)
-		} else {
-			s.logger.Info("Drift detected between AWS and Terraform",
-				zap.String("operation", "drift_check"),
-				zap.String("status", "drift_detected"),
-				zap.Strings("drifts", res.drift),
-			)
+		drifts, err := handler.Compare(ctx, resource, entry, tfConfig)
+		if err != nil {
+			return err
 		}
+		for i := range drifts {
+			drifts[i].Account, drifts[i].Region = s.account, region
+		}
+		status := ResourceInSync
+		if len(drifts) > 0 {
+			status = ResourceDrifted
+		}
+		report.ResourcesChecked++
+		report.Resources = append(report.Resources, CheckedResource{
+			Address:    entry.Address,
+			Type:       entry.Resource.Type,
+			ResourceID: entry.ID,
+			Region:     region,
+			Status:     status,
+		})
+		report.Add(drifts...)
+		s.logDrifts(entry, drifts)
 	}
 
-	s.logger.Info("Drift check completed successfully",
-		zap.String("operation", "drift_check_complete"),
-	)
+	// State entries whose live resource no longer exists
+	for _, entry := range index.missing(liveIDs) {
+		drift := missingDrift(entry.Address, entry.ID)
+		drift.Account, drift.Region = s.account, region
+		report.Resources = append(report.Resources, CheckedResource{
+			Address:    entry.Address,
+			Type:       entry.Resource.Type,
+			ResourceID: entry.ID,
+			Region:     region,
+			Status:     ResourceMissing,
+		})
+		s.logger.Warn("No AWS resource found for Terraform resource",
+			append([]zap.Field{
+				zap.String("operation", "resource_match"),
+				zap.String("resource_type", resourceType),
+			}, drift.logFields()...)...,
+		)
+		report.Add(drift)
+	}
 	return nil
 }
+
+// logDrifts logs the outcome of comparing one resource
+func (s *DriftService) logDrifts(entry *StateEntry, drifts []Drift) {
+	if len(drifts) == 0 {
+		s.logger.Info("No drift detected between AWS and Terraform",
+			zap.String("operation", "drift_check"),
+			zap.String("resource_id", entry.ID),
+			zap.String("address", entry.Address),
+			zap.String("status", "no_drift"),
+		)
+		return
+	}
+	for _, drift := range drifts {
+		s.logger.Info("Drift detected between AWS and Terraform",
+			append([]zap.Field{
+				zap.String("operation", "drift_check"),
+				zap.String("status", "drift_detected"),
+			}, drift.logFields()...)...,
+		)
+	}
+}
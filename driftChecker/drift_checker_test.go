@@ -2,12 +2,17 @@ package driftChecker
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 	"go.uber.org/zap"
 
 	awsm "Savannahtakehomeassi/awsd/models"
@@ -73,6 +78,14 @@ func TestDriftService_RunLoop(t *testing.T) {
 			mockAWSError: errors.New("AWS error"),
 			mockTFError:  nil,
 			expectErr:    true,
+			mockTerraform: &terafm.TerraformState{Resources: []terafm.Resource{
+				{
+					Type: "aws_instance",
+					Instances: []terafm.Instance{
+						{Attributes: terafm.InstanceAttributes{InstanceID: "i-12345", InstanceType: "t2.micro"}},
+					},
+				},
+			}},
 		},
 		{
 			name:         "Test Terraform client error",
@@ -87,51 +100,51 @@ func TestDriftService_RunLoop(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
 			// Reset mock expectations
 			tt.awsMock.ExpectedCalls = nil
 			tt.tfMock.ExpectedCalls = nil
 
-			tt.awsMock.On("GetAWSInstance").Return(tt.mockAWS, tt.mockAWSError)
-
-			// Only set up Terraform mocks if we expect to reach them
-			if tt.mockAWSError == nil {
-				tt.tfMock.On("ParseTerraformInstance", mock.Anything).Return(tt.mockTerraform, tt.mockTFError)
+			var awsInstances []*awsm.AWSInstance
+			if tt.mockAWS != nil {
+				awsInstances = []*awsm.AWSInstance{tt.mockAWS}
+			}
+			tt.tfMock.On("ParseTerraformInstance", mock.Anything).Return(tt.mockTerraform, tt.mockTFError)
 
-				// Only set up ParseHCLConfig mock if we expect it to be called
-				if tt.mockTFError == nil {
-					// Create a TFInstance from the TerraformState for the HCL config
-					var tfInstance *terafm.TFInstance
-					if tt.mockTerraform != nil && len(tt.mockTerraform.Resources) > 0 && len(tt.mockTerraform.Resources[0].Instances) > 0 {
-						instance := tt.mockTerraform.Resources[0].Instances[0]
-						tfInstance = &terafm.TFInstance{
-							ID:           instance.Attributes.InstanceID,
+			// Only set up the HCL and AWS mocks if we expect to reach them
+			if tt.mockTFError == nil {
+				// Create an HCL config from the TerraformState
+				var tfConfig *terafm.Config
+				if tt.mockTerraform != nil && len(tt.mockTerraform.Resources) > 0 && len(tt.mockTerraform.Resources[0].Instances) > 0 {
+					resource := tt.mockTerraform.Resources[0]
+					instance := resource.Instances[0]
+					tfConfig = &terafm.Config{Resources: []terafm.ResourceBlock{
+						newInstanceResourceBlock(resource.Name, &terafm.TFInstance{
 							InstanceType: instance.Attributes.InstanceType,
 							AMI:          instance.Attributes.AMI,
 							Tags:         instance.Attributes.Tags,
-						}
-					}
-					tt.tfMock.On("ParseHCLConfig", mock.Anything).Return(tfInstance, tt.mockTFError)
+						}),
+					}}
 				}
+				tt.tfMock.On("ParseHCLConfig", mock.Anything).Return(tfConfig, nil)
+				tt.awsMock.On("GetAWSInstances").Return(awsInstances, tt.mockAWSError)
 			}
 
-			// Create DriftService with mocked clients
-			service := &DriftService{
-				awsClient:       tt.awsMock,
-				terraformClient: tt.tfMock,
-				logger:          logger,
-			}
+			// Create DriftService with mocked clients, giving up on the first failure,
+			// whose loop is stopped after three checks
+			service := NewDriftService(tt.awsMock, tt.tfMock, logger, WithFailureBudget(1))
+			var waits []time.Duration
+			service.sleep = stopAfterWaits(cancel, 3, &waits)
 
-			// Run the test with a short interval
-			err := service.RunLoop(ctx, "path/to/tfstate", "path/to/mainfile", 1)
+			err := service.RunLoop(ctx, "path/to/tfstate", "path/to/mainfile", time.Minute)
 
 			if tt.expectTimeout {
-				// For timeout cases, we expect a context cancellation error
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), "drift check cancelled")
+				// The loop is stopped while it waits for the next check, so it shuts
+				// down cleanly
+				assert.NoError(t, err)
+				assert.Equal(t, []time.Duration{time.Minute, time.Minute, time.Minute}, waits)
 			} else if tt.expectErr {
 				assert.Error(t, err)
 			} else {
@@ -145,22 +158,118 @@ func TestDriftService_RunLoop(t *testing.T) {
 	}
 }
 
+// stopAfterWaits replaces the waits of RunLoop, recording them and stopping the loop
+// by cancelling its context at the nth wait
+func stopAfterWaits(cancel context.CancelFunc, n int, waits *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		if len(*waits) >= n {
+			cancel()
+			return ctx.Err()
+		}
+		return nil
+	}
+}
+
+func TestDriftService_RunLoop_FailureBudget(t *testing.T) {
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_instance", Instances: []terafm.Instance{
+			{Attributes: terafm.InstanceAttributes{InstanceID: "i-12345", InstanceType: "t2.micro"}},
+		}},
+	}}
+
+	t.Run("recovers after a failed check", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		tfClient := new(MockTerraformClient)
+		tfClient.On("ParseTerraformInstance", mock.Anything).Return(nil, errors.New("LocalStack unavailable")).Once()
+		tfClient.On("ParseTerraformInstance", mock.Anything).Return(tfState, nil)
+		tfClient.On("ParseHCLConfig", mock.Anything).Return(&terafm.Config{}, nil)
+		awsClient := new(MockAWSClient)
+		awsClient.On("GetAWSInstances").Return([]*awsm.AWSInstance{{InstanceID: "i-12345", InstanceType: "t2.micro"}}, nil)
+
+		service := NewDriftService(awsClient, tfClient, zap.NewNop(), WithFailureBudget(2))
+		var waits []time.Duration
+		service.sleep = stopAfterWaits(cancel, 2, &waits)
+		start := time.Now()
+		require.NoError(t, service.RunLoop(ctx, "path/to/tfstate", "path/to/mainfile", time.Minute))
+
+		// The failed check doubles the wait, the successful one resets it
+		assert.Equal(t, []time.Duration{2 * time.Minute, time.Minute}, waits)
+		assert.Zero(t, service.ConsecutiveFailures())
+		assert.True(t, service.LastSuccessfulCheck().After(start))
+		tfClient.AssertNumberOfCalls(t, "ParseTerraformInstance", 2)
+	})
+
+	t.Run("gives up once the budget is exhausted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		tfClient := new(MockTerraformClient)
+		tfClient.On("ParseTerraformInstance", mock.Anything).Return(nil, errors.New("LocalStack unavailable"))
+
+		reportPath := filepath.Join(t.TempDir(), "report.json")
+		service := NewDriftService(new(MockAWSClient), tfClient, zap.NewNop(), WithFailureBudget(2), WithReportPath(reportPath))
+		var waits []time.Duration
+		service.sleep = stopAfterWaits(cancel, 5, &waits)
+		err := service.RunLoop(ctx, "path/to/tfstate", "path/to/mainfile", time.Minute)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failure budget exhausted")
+		assert.Equal(t, []time.Duration{2 * time.Minute}, waits)
+
+		assert.Equal(t, 2, service.ConsecutiveFailures())
+		assert.True(t, service.LastSuccessfulCheck().IsZero())
+		assert.NoError(t, ctx.Err(), "the loop should give up before its context ends")
+
+		// Every failed check still writes its report, carrying the error
+		data, err := os.ReadFile(reportPath)
+		require.NoError(t, err)
+		var report Report
+		require.NoError(t, json.Unmarshal(data, &report))
+		assert.Empty(t, report.Accounts)
+		require.Len(t, report.Errors, 1)
+		assert.Contains(t, report.Errors[0].Message, "LocalStack unavailable")
+	})
+}
+
+func TestFailureBackoff(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		failures int
+		expected time.Duration
+	}{
+		{5 * time.Minute, 0, 5 * time.Minute},
+		{5 * time.Minute, 1, 10 * time.Minute},
+		{5 * time.Minute, 3, 40 * time.Minute},
+		{5 * time.Minute, 10, maxFailureBackoff},
+		{2 * time.Hour, 3, 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, failureBackoff(tt.interval, tt.failures), "interval %s, %d failures", tt.interval, tt.failures)
+	}
+}
+
 func TestDriftService_runDriftCheck(t *testing.T) {
 	tests := []struct {
-		name        string
-		awsInstance *awsm.AWSInstance
-		awsError    error
-		tfState     *terafm.TerraformState
-		tfInstance  *terafm.TFInstance
-		tfPath      string
-		mainFile    string
-		expectError bool
-		errorMsg    string
+		name           string
+		awsInstances   []*awsm.AWSInstance
+		awsError       error
+		securityGroups []*awsm.AWSSecurityGroup
+		tfState        *terafm.TerraformState
+		tfConfig       *terafm.Config
+		tfPath         string
+		mainFile       string
+		expectError    bool
+		errorMsg       string
+		opts           []Option
+		expected       *DriftReport
 	}{
 		{
 			name: "successful drift check",
-			awsInstance: &awsm.AWSInstance{
-				InstanceID:     "i-12345",
+			awsInstances: []*awsm.AWSInstance{{
+				InstanceID:     "i-0b0f62398bf34f224",
 				InstanceType:   "t2.micro",
 				AMI:            "ami-12345",
 				PrivateIP:      "10.0.0.1",
@@ -173,7 +282,7 @@ func TestDriftService_runDriftCheck(t *testing.T) {
 						PublicIpAddress:  "54.214.227.242",
 					},
 				},
-			},
+			}},
 			awsError: nil,
 			tfState: &terafm.TerraformState{
 				Resources: []terafm.Resource{
@@ -195,22 +304,167 @@ func TestDriftService_runDriftCheck(t *testing.T) {
 					},
 				},
 			},
-			tfInstance: &terafm.TFInstance{
-				ID:           "i-0b0f62398bf34f224",
-				InstanceType: "t2.micro",
-				AMI:          "ami-12345678",
-				Tags:         map[string]string{"Name": "TestInstance"},
+			tfConfig: &terafm.Config{Resources: []terafm.ResourceBlock{
+				newInstanceResourceBlock("", &terafm.TFInstance{
+					InstanceType: "t2.micro",
+					AMI:          "ami-12345678",
+					Tags:         map[string]string{"Name": "TestInstance"},
+				}),
+			}},
+			tfPath:      "terraform.tfstate",
+			mainFile:    "main.tf",
+			expectError: false,
+			expected: &DriftReport{
+				ResourcesChecked: 1,
+				Drifts: []Drift{
+					{Address: "aws_instance.", ResourceID: "i-0b0f62398bf34f224", Attribute: "ami", Expected: "ami-12345678", Actual: "ami-12345", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand},
+					{Address: "aws_instance.", ResourceID: "i-0b0f62398bf34f224", Attribute: "private_ip", Expected: "10.249.67.6", Actual: "10.0.0.1", Source: SourceState, Severity: SeverityLow, Category: CategoryOutOfBand},
+				},
+			},
+		},
+		{
+			name: "multiple instances with unmanaged instance",
+			awsInstances: []*awsm.AWSInstance{
+				{InstanceID: "i-aaa", InstanceType: "t2.micro", AMI: "ami-1"},
+				{InstanceID: "i-bbb", InstanceType: "t2.large", AMI: "ami-1"},
+				{InstanceID: "i-unmanaged", InstanceType: "t3.nano", AMI: "ami-2"},
+			},
+			tfState: &terafm.TerraformState{
+				Resources: []terafm.Resource{
+					{
+						Type: "aws_instance",
+						Name: "a",
+						Instances: []terafm.Instance{
+							{Attributes: terafm.InstanceAttributes{InstanceID: "i-aaa", InstanceType: "t2.micro", AMI: "ami-1"}},
+						},
+					},
+					{
+						Type: "aws_instance",
+						Name: "b",
+						Instances: []terafm.Instance{
+							{Attributes: terafm.InstanceAttributes{InstanceID: "i-bbb", InstanceType: "t2.micro", AMI: "ami-1"}},
+						},
+					},
+				},
 			},
+			tfConfig: &terafm.Config{Resources: []terafm.ResourceBlock{
+				newInstanceResourceBlock("a", &terafm.TFInstance{InstanceType: "t2.micro", AMI: "ami-1"}),
+			}},
 			tfPath:      "terraform.tfstate",
 			mainFile:    "main.tf",
 			expectError: false,
+			expected: &DriftReport{
+				ResourcesChecked: 2,
+				Drifts: []Drift{
+					{ResourceID: "i-unmanaged", ResourceType: "aws_instance", Source: SourceState, Severity: SeverityMedium, Category: CategoryUnmanaged},
+					{Address: "aws_instance.b", ResourceID: "i-bbb", Attribute: "instance_type", Expected: "t2.micro", Actual: "t2.large", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand},
+				},
+			},
 		},
 		{
-			name:        "AWS instance not found",
-			awsInstance: nil,
-			awsError:    errors.New("AWS instance not found"),
-			tfState:     nil,
-			tfInstance:  nil,
+			name: "missing and filtered unmanaged instances",
+			awsInstances: []*awsm.AWSInstance{
+				{InstanceID: "i-aaa", InstanceType: "t2.micro", AMI: "ami-1"},
+				{InstanceID: "i-terminated", InstanceType: "t2.micro", AMI: "ami-1", State: "terminated"},
+				{InstanceID: "i-asg", Tags: map[string]string{"aws:autoscaling:groupName": "workers"}},
+				{InstanceID: "i-bastion", Tags: map[string]string{"Name": "bastion-1"}},
+				{InstanceID: "i-manual", Tags: map[string]string{"Name": "debug-box"}},
+			},
+			tfState: &terafm.TerraformState{
+				Resources: []terafm.Resource{
+					{
+						Type: "aws_instance",
+						Name: "a",
+						Instances: []terafm.Instance{
+							{Attributes: terafm.InstanceAttributes{InstanceID: "i-aaa", InstanceType: "t2.micro", AMI: "ami-1"}},
+						},
+					},
+					{
+						Type: "aws_instance",
+						Name: "gone",
+						Instances: []terafm.Instance{
+							{Attributes: terafm.InstanceAttributes{InstanceID: "i-terminated", InstanceType: "t2.micro", AMI: "ami-1"}},
+						},
+					},
+					{
+						Module: "module.app",
+						Type:   "aws_instance",
+						Name:   "web",
+						Instances: []terafm.Instance{
+							{IndexKey: "blue", Attributes: terafm.InstanceAttributes{ARN: "arn:aws:ec2:us-east-1:000000000000:instance/i-deleted"}},
+						},
+					},
+				},
+			},
+			tfConfig: &terafm.Config{Resources: []terafm.ResourceBlock{
+				newInstanceResourceBlock("a", &terafm.TFInstance{InstanceType: "t2.micro", AMI: "ami-1"}),
+			}},
+			tfPath:   "terraform.tfstate",
+			mainFile: "main.tf",
+			opts: []Option{WithUnmanagedFilter(UnmanagedFilter{
+				IgnoreTags:  map[string]string{"aws:autoscaling:groupName": ""},
+				IgnoreNames: []string{"bastion-*"},
+			})},
+			expected: &DriftReport{
+				ResourcesChecked: 1,
+				Drifts: []Drift{
+					{ResourceID: "i-manual", ResourceType: "aws_instance", Source: SourceState, Severity: SeverityMedium, Category: CategoryUnmanaged},
+					{Address: "aws_instance.gone", ResourceID: "i-terminated", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+					{Address: `module.app.aws_instance.web["blue"]`, ResourceID: "i-deleted", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+				},
+			},
+		},
+		{
+			name: "security group opened to the internet",
+			securityGroups: []*awsm.AWSSecurityGroup{
+				{
+					GroupId:   "sg-web",
+					GroupName: "web",
+					VpcId:     "vpc-1",
+					Ingress: []awsm.SecurityGroupRule{
+						{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIpv4: "10.0.0.0/8"},
+						{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrIpv4: "0.0.0.0/0"},
+					},
+				},
+			},
+			tfState: &terafm.TerraformState{
+				Resources: []terafm.Resource{
+					{
+						Type: "aws_security_group",
+						Name: "web",
+						Instances: []terafm.Instance{decodeStateInstance(`{
+							"id": "sg-web",
+							"ingress": [{"protocol": "tcp", "from_port": 443, "to_port": 443, "cidr_blocks": ["10.0.0.0/8"]}],
+							"egress": []
+						}`)},
+					},
+					{
+						Type:      "aws_security_group",
+						Name:      "deleted",
+						Instances: []terafm.Instance{decodeStateInstance(`{"id": "sg-deleted"}`)},
+					},
+				},
+			},
+			tfConfig: &terafm.Config{},
+			tfPath:   "terraform.tfstate",
+			mainFile: "main.tf",
+			expected: &DriftReport{
+				ResourcesChecked: 1,
+				Drifts: []Drift{
+					{Address: "aws_security_group.deleted", ResourceID: "sg-deleted", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+					{Address: "aws_security_group.web", ResourceID: "sg-web", Attribute: "ingress", Actual: "tcp 22 from 0.0.0.0/0", Source: SourceState, Severity: SeverityCritical, Category: CategoryOutOfBand, Change: ChangeAdded,
+						Details: map[string]string{"group_name": "web", "vpc_id": "vpc-1", "protocol": "tcp", "port_range": "22", "source": "0.0.0.0/0"}},
+				},
+			},
+		},
+		{
+			name:         "AWS instance not found",
+			awsInstances: nil,
+			awsError:     errors.New("AWS instance not found"),
+			tfState: &terafm.TerraformState{Resources: []terafm.Resource{
+				{Type: "aws_instance", Instances: []terafm.Instance{{Attributes: terafm.InstanceAttributes{InstanceID: "i-aaa"}}}},
+			}},
+			tfConfig:    &terafm.Config{},
 			tfPath:      "terraform.tfstate",
 			mainFile:    "main.tf",
 			expectError: true,
@@ -231,36 +485,122 @@ func TestDriftService_runDriftCheck(t *testing.T) {
 			logger := zap.L().With(zap.String("package", "packageName"))
 
 			// Setup mock expectations
-			awsClient.On("GetAWSInstance").Return(tt.awsInstance, tt.awsError)
-			if tt.awsError == nil && tt.tfState != nil {
-				tfClient.On("ParseTerraformInstance", tt.tfPath).Return(tt.tfState, nil)
-				tfClient.On("ParseHCLConfig", tt.mainFile).Return(tt.tfInstance, nil)
+			if tt.awsInstances != nil || tt.awsError != nil {
+				awsClient.On("GetAWSInstances").Return(tt.awsInstances, tt.awsError)
+			}
+			if tt.securityGroups != nil {
+				awsClient.On("GetSecurityGroups").Return(tt.securityGroups, nil)
 			}
+			tfClient.On("ParseTerraformInstance", tt.tfPath).Return(tt.tfState, nil)
+			tfClient.On("ParseHCLConfig", tt.mainFile).Return(tt.tfConfig, nil)
 
 			// Create service instance
-			service := NewDriftService(awsClient, tfClient, logger)
+			service := NewDriftService(awsClient, tfClient, logger, tt.opts...)
 
 			// Create context
 			ctx := context.Background()
 
 			// Run the test
-			err := service.runDriftCheck(ctx, tt.tfPath, tt.mainFile)
+			report, err := service.runDriftCheck(ctx, tt.tfPath, tt.mainFile)
 
 			// Assert results
 			if tt.expectError {
 				assert.Error(t, err)
+				assert.Nil(t, report)
 				if tt.errorMsg != "" {
 					assert.Contains(t, err.Error(), tt.errorMsg)
 				}
 			} else {
 				assert.NoError(t, err)
+				require.NotNil(t, report)
+				assert.Equal(t, tt.expected.ResourcesChecked, report.ResourcesChecked)
+				assert.Equal(t, tt.expected.Drifts, report.Drifts)
+				assert.Equal(t, len(tt.expected.Drifts) > 0, report.HasDrift())
+				assert.False(t, report.CompletedAt.Before(report.StartedAt))
 			}
 
 			// Verify mock expectations
 			awsClient.AssertExpectations(t)
-			if tt.awsError == nil {
-				tfClient.AssertExpectations(t)
-			}
+			tfClient.AssertExpectations(t)
 		})
 	}
 }
+
+// blockingHandler is a stubHandler whose live resources never arrive before the
+// context ends
+type blockingHandler struct {
+	stubHandler
+}
+
+func (h *blockingHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDriftService_ComparisonTimeout(t *testing.T) {
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_s3_bucket", Name: "assets", Instances: []terafm.Instance{
+			{Attributes: terafm.InstanceAttributes{InstanceID: "assets", Tags: map[string]string{"Name": "assets"}}},
+		}},
+		{Type: "aws_iam_role", Name: "web", Instances: []terafm.Instance{
+			{Attributes: terafm.InstanceAttributes{InstanceID: "web"}},
+		}},
+		{Type: "aws_iam_policy", Name: "logs", Instances: []terafm.Instance{
+			{Attributes: terafm.InstanceAttributes{InstanceID: "logs"}},
+		}},
+	}}
+
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(tfState, nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+
+	service := NewDriftService(new(MockAWSClient), tfClient, zap.NewNop(),
+		WithHomeRegion("us-east-1"),
+		WithComparisonTimeout(20*time.Millisecond),
+		WithHandler(&stubHandler{
+			types: []string{"aws_s3_bucket"},
+			live:  []LiveResource{{ID: "assets", Tags: map[string]string{"Name": "assets-renamed"}}},
+		}),
+		WithHandler(&blockingHandler{stubHandler{types: []string{"aws_iam_role"}}}),
+		WithHandler(&stubHandler{types: []string{"aws_iam_policy"}}),
+	)
+	report, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	// The drift found before the deadline is kept
+	assert.True(t, report.Partial)
+	assert.Equal(t, 1, report.ResourcesChecked)
+	assert.Equal(t, []Drift{
+		{Address: "aws_s3_bucket.assets", ResourceID: "assets", Region: "us-east-1", Attribute: "tags.Name", Expected: "assets", Actual: "assets-renamed", Source: SourceState, Severity: SeverityLow, Category: CategoryOutOfBand},
+	}, report.Drifts)
+	assert.Equal(t, []UnfinishedCheck{
+		{Region: "us-east-1", ResourceType: "aws_iam_role", Reason: "timed out"},
+		{Region: "us-east-1", ResourceType: "aws_iam_policy", Reason: "timed out"},
+	}, report.Unfinished)
+}
+
+// newInstanceResourceBlock builds an aws_instance resource block as the HCL parser would
+func newInstanceResourceBlock(name string, inst *terafm.TFInstance) terafm.ResourceBlock {
+	tags := make(map[string]cty.Value, len(inst.Tags))
+	for k, v := range inst.Tags {
+		tags[k] = cty.StringVal(v)
+	}
+	tagsVal := cty.MapValEmpty(cty.String)
+	if len(tags) > 0 {
+		tagsVal = cty.MapVal(tags)
+	}
+
+	attrs := map[string]*terafm.Attribute{
+		"ami":           {Name: "ami", Value: cty.StringVal(inst.AMI)},
+		"instance_type": {Name: "instance_type", Value: cty.StringVal(inst.InstanceType)},
+		"tags":          {Name: "tags", Value: tagsVal},
+	}
+	return terafm.ResourceBlock{
+		Type:       "aws_instance",
+		Name:       name,
+		Attributes: attrs,
+		Instances: []*terafm.ResourceInstance{
+			{Address: "aws_instance." + name, Attributes: attrs},
+		},
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"go.uber.org/zap"
+	"sort"
 	"sync"
 
 	awsm "Savannahtakehomeassi/awsd/models"
 	terafm "Savannahtakehomeassi/teraform/models"
 )
 
-func compareAWSInstanceWithTerraform(ctx context.Context, awsInstance *awsm.AWSInstance, tfState *terafm.TerraformState) ([]string, error) {
+// compareAWSInstanceWithTerraform compares a live instance with its Terraform state
+// entry. detached are the security groups of the state no longer attached to it.
+func compareAWSInstanceWithTerraform(ctx context.Context, awsInstance *awsm.AWSInstance, match *StateEntry,
+	detached []awsm.SecurityGroup) ([]Drift, error) {
 	logger := zap.L().With(
 		zap.String("function", "compareAWSInstanceWithTerraform"),
 		zap.String("instance_id", awsInstance.InstanceID),
@@ -20,12 +24,11 @@ func compareAWSInstanceWithTerraform(ctx context.Context, awsInstance *awsm.AWSI
 		zap.String("operation", "comparison_start"),
 	)
 
-	driftCh := make(chan string)
-	var driftDetected []string
+	driftCh := make(chan Drift)
+	drifts := []Drift{}
 	var wg sync.WaitGroup
 
-	tfInstance := findMatchingTFInstance(tfState)
-	if tfInstance == nil {
+	if match == nil || match.Instance == nil {
 		err := fmt.Errorf("no matching Terraform instance found for AWS instance %s", awsInstance.InstanceID)
 		logger.Error("Failed to find matching Terraform instance",
 			zap.String("operation", "instance_match"),
@@ -33,6 +36,7 @@ func compareAWSInstanceWithTerraform(ctx context.Context, awsInstance *awsm.AWSI
 		)
 		return nil, err
 	}
+	tfInstance := match.Instance
 
 	// Run comparisons
 	run := func(f func()) {
@@ -46,7 +50,7 @@ func compareAWSInstanceWithTerraform(ctx context.Context, awsInstance *awsm.AWSI
 	run(func() { compareBasicFields(ctx, awsInstance, tfInstance, driftCh) })
 	run(func() { compareTags(ctx, awsInstance, tfInstance, driftCh) })
 	run(func() { compareBlockDevices(ctx, awsInstance, tfInstance, driftCh) })
-	run(func() { compareSecurityGroups(ctx, awsInstance, tfInstance, driftCh) })
+	run(func() { compareSecurityGroups(ctx, awsInstance, tfInstance, detached, driftCh) })
 	run(func() { compareNetworkInterfaces(ctx, awsInstance, tfInstance, driftCh) })
 
 	// Close channel after all goroutines complete
@@ -65,28 +69,31 @@ func compareAWSInstanceWithTerraform(ctx context.Context, awsInstance *awsm.AWSI
 			return nil, ctx.Err()
 		case drift, ok := <-driftCh:
 			if !ok {
-				if len(driftDetected) == 0 {
-					driftDetected = append(driftDetected, "No drift detected between AWS instance and Terraform state.")
-				}
 				logger.Info("Comparison completed",
 					zap.String("operation", "comparison_complete"),
-					zap.Int("drift_count", len(driftDetected)),
+					zap.Int("drift_count", len(drifts)),
 				)
-				return driftDetected, nil
+				return drifts, nil
 			}
-			driftDetected = append(driftDetected, drift)
+			drift.Address = match.Address
+			drift.ResourceID = awsInstance.InstanceID
+			drift.Source = SourceState
+			drift.Category = CategoryOutOfBand
+			drifts = append(drifts, drift)
 		}
 	}
 }
 
-// compareInstances for aws and tfInstance for hcl
-func compareInstances(awsInst *awsm.AWSInstance, tfInst *terafm.TFInstance) ([]string, error) {
+// compareInstances for aws and tfInstance for hcl. The drifts are left uncategorised
+// until classifyDrifts has weighed them against the state. Attributes the config
+// omits are not compared.
+func compareInstances(awsInst *awsm.AWSInstance, tfInst *terafm.TFInstance) ([]Drift, error) {
 	logger := zap.L().With(
 		zap.String("function", "compareInstances"),
 		zap.String("instance_id", awsInst.InstanceID),
 	)
 
-	var drifts []string
+	drifts := []Drift{}
 
 	logger.Info("Starting HCL comparison",
 		zap.String("operation", "hcl_comparison_start"),
@@ -101,36 +108,37 @@ func compareInstances(awsInst *awsm.AWSInstance, tfInst *terafm.TFInstance) ([]s
 		return nil, err
 	}
 
-	if awsInst.InstanceType != tfInst.InstanceType {
-		drifts = append(drifts, fmt.Sprintf("Drift in instance %s: instance_type mismatch (AWS: %s, TF: %s)", awsInst.InstanceID, awsInst.InstanceType, tfInst.InstanceType))
-		logger.Info("Instance type drift detected",
-			zap.String("operation", "hcl_comparison"),
-			zap.String("aws_type", awsInst.InstanceType),
-			zap.String("tf_type", tfInst.InstanceType),
+	add := func(d Drift) {
+		d.Address = tfInst.Address
+		d.ResourceID = awsInst.InstanceID
+		d.Source = SourceConfig
+		drifts = append(drifts, d)
+		logger.Info("HCL drift detected",
+			append([]zap.Field{zap.String("operation", "hcl_comparison")}, d.logFields()...)...,
 		)
 	}
-	if awsInst.AMI != tfInst.AMI {
-		drifts = append(drifts, fmt.Sprintf("Drift in instance %s: AMI mismatch (AWS: %s, TF: %s)", awsInst.InstanceID, awsInst.AMI, tfInst.AMI))
-		logger.Info("AMI drift detected",
-			zap.String("operation", "hcl_comparison"),
-			zap.String("aws_ami", awsInst.AMI),
-			zap.String("tf_ami", tfInst.AMI),
-		)
+
+	if tfInst.IsUnknown("instance_type") {
+		logSkippedUnknown(logger, "instance_type")
+	} else if tfInst.InstanceType != "" && awsInst.InstanceType != tfInst.InstanceType {
+		add(attributeDrift("instance_type", tfInst.InstanceType, awsInst.InstanceType))
+	}
+	if tfInst.IsUnknown("ami") {
+		logSkippedUnknown(logger, "ami")
+	} else if tfInst.AMI != "" && awsInst.AMI != tfInst.AMI {
+		add(attributeDrift("ami", tfInst.AMI, awsInst.AMI))
 	}
-	for k, v := range tfInst.Tags {
+	if tfInst.IsUnknown("tags") {
+		logSkippedUnknown(logger, "tags")
+	}
+	for _, k := range sortedKeys(tfInst.Tags) {
+		v := tfInst.Tags[k]
 		if awsVal, ok := awsInst.Tags[k]; !ok || awsVal != v {
-			drifts = append(drifts, fmt.Sprintf("Drift in instance %s: tag %s mismatch (AWS: %s, TF: %s)", awsInst.InstanceID, k, awsVal, v))
-			logger.Info("Tag drift detected",
-				zap.String("operation", "hcl_comparison"),
-				zap.String("tag_key", k),
-				zap.String("aws_value", awsVal),
-				zap.String("tf_value", v),
-			)
+			add(attributeDrift("tags."+k, v, awsVal))
 		}
 	}
 
 	if len(drifts) == 0 {
-		drifts = append(drifts, "No drift detected between AWS instance and Terraform state.")
 		logger.Info("No drift detected in HCL comparison",
 			zap.String("operation", "hcl_comparison_complete"),
 			zap.String("status", "no_drift"),
@@ -146,78 +154,63 @@ func compareInstances(awsInst *awsm.AWSInstance, tfInst *terafm.TFInstance) ([]s
 	return drifts, nil
 }
 
-func findMatchingTFInstance(tfState *terafm.TerraformState) *terafm.Instance {
-	logger := zap.L().With(
-		zap.String("function", "findMatchingTFInstance"),
-	)
-
-	for _, resource := range tfState.Resources {
-		if resource.Type == "aws_instance" && len(resource.Instances) > 0 {
-			logger.Info("Found matching Terraform instance",
-				zap.String("operation", "instance_match"),
-				zap.String("resource_type", resource.Type),
-			)
-			return &resource.Instances[0]
-		}
+// sortedKeys returns the keys of m in sorted order so drifts are reported deterministically
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	return nil
+	sort.Strings(keys)
+	return keys
 }
 
-func compareTags(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- string) {
-	for k, v := range tf.Attributes.Tags {
-		if awsVal, ok := aws.Tags[k]; !ok || awsVal != v {
-			ch <- fmt.Sprintf("Tag drift detected: %s (AWS: %s, TF: %s)", k, awsVal, v)
-		}
-	}
+// logSkippedUnknown records an HCL attribute that was left out of the comparison
+// because its value couldn't be resolved
+func logSkippedUnknown(logger *zap.Logger, attribute string) {
+	logger.Info("Skipping unresolved HCL attribute",
+		zap.String("operation", "hcl_comparison"),
+		zap.String("attribute", attribute),
+		zap.String("status", "unknown"),
+	)
 }
 
-func compareBlockDevices(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- string) {
-	// Compare root block device
-	if len(tf.Attributes.RootBlockDevice) > 0 {
-		tfRoot := tf.Attributes.RootBlockDevice[0]
-		for _, awsDevice := range aws.BlockDeviceMappings {
-			if awsDevice.DeviceName == tfRoot.DeviceName {
-				if awsDevice.VolumeId != tfRoot.VolumeID {
-					ch <- fmt.Sprintf("Root block device volume ID drift detected: AWS=%s, TF=%s", awsDevice.VolumeId, tfRoot.VolumeID)
-				}
-				break
-			}
-		}
+func compareTags(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- Drift) {
+	for _, drift := range tagDrifts(tf.Attributes.Tags, aws.Tags) {
+		ch <- drift
 	}
 }
 
-func compareSecurityGroups(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- string) {
-	// Compare security groups
-	awsSGs := make(map[string]bool)
-	for _, sg := range aws.SecurityGroups {
-		awsSGs[sg.GroupId] = true
-	}
-
-	for _, tfSG := range tf.Attributes.VpcSecurityGroupIDs {
-		if !awsSGs[tfSG] {
-			ch <- fmt.Sprintf("Security group drift detected: TF security group %s not found in AWS", tfSG)
+// tagDrifts compares the tags recorded in Terraform with the live tags. Live tags
+// Terraform doesn't set aren't drift; they may come from default_tags or AWS itself.
+func tagDrifts(expected, actual map[string]string) []Drift {
+	var drifts []Drift
+	for _, k := range sortedKeys(expected) {
+		v := expected[k]
+		if awsVal, ok := actual[k]; !ok || awsVal != v {
+			drifts = append(drifts, attributeDrift("tags."+k, v, awsVal))
 		}
 	}
+	return drifts
 }
 
-func compareNetworkInterfaces(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- string) {
+func compareNetworkInterfaces(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- Drift) {
 	// Compare network interfaces
 	if len(aws.NetworkInterfaces) > 0 {
 		awsPrimary := aws.NetworkInterfaces[0]
 		if awsPrimary.PrivateIpAddress != tf.Attributes.PrivateIP {
-			ch <- fmt.Sprintf("Private IP drift detected: AWS=%s, TF=%s", awsPrimary.PrivateIpAddress, tf.Attributes.PrivateIP)
+			ch <- attributeDrift("private_ip", tf.Attributes.PrivateIP, awsPrimary.PrivateIpAddress)
 		}
 		if awsPrimary.PublicIpAddress != tf.Attributes.PublicIP {
-			ch <- fmt.Sprintf("Public IP drift detected: AWS=%s, TF=%s", awsPrimary.PublicIpAddress, tf.Attributes.PublicIP)
+			ch <- attributeDrift("public_ip", tf.Attributes.PublicIP, awsPrimary.PublicIpAddress)
 		}
 	}
 }
 
-func compareBasicFields(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- string) {
+func compareBasicFields(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance, ch chan<- Drift) {
 	if aws.InstanceType != tf.Attributes.InstanceType {
-		ch <- fmt.Sprintf("InstanceType drift detected: AWS=%s, Terraform=%s", aws.InstanceType, tf.Attributes.InstanceType)
+		ch <- attributeDrift("instance_type", tf.Attributes.InstanceType, aws.InstanceType)
 	}
 	if aws.AMI != tf.Attributes.AMI {
-		ch <- fmt.Sprintf("AMI drift detected: AWS=%s, Terraform=%s", aws.AMI, tf.Attributes.AMI)
+		ch <- attributeDrift("ami", tf.Attributes.AMI, aws.AMI)
 	}
 }
@@ -1,6 +1,7 @@
 package driftChecker
 
 import (
+	"context"
 	"testing"
 
 	"go.uber.org/zap"
@@ -39,15 +40,21 @@ func TestCompareInstances(t *testing.T) {
 	// Test no drift case
 	drift, err := compareInstances(awsInstance, tfState)
 	assert.NoError(t, err)
-	assert.Len(t, drift, 1)
-	assert.Equal(t, "No drift detected between AWS instance and Terraform state.", drift[0])
+	assert.Empty(t, drift)
 
 	// Test drift in instance type
 	tfState.InstanceType = "t2.large"
 	drift, err = compareInstances(awsInstance, tfState)
 	assert.NoError(t, err)
 	assert.Len(t, drift, 1)
-	assert.Equal(t, "Drift in instance i-12345: instance_type mismatch (AWS: t2.micro, TF: t2.large)", drift[0])
+	assert.Equal(t, Drift{
+		ResourceID: "i-12345",
+		Attribute:  "instance_type",
+		Expected:   "t2.large",
+		Actual:     "t2.micro",
+		Source:     SourceConfig,
+		Severity:   SeverityMedium,
+	}, drift[0])
 }
 
 func TestCompareInstances_NewFormat(t *testing.T) {
@@ -61,7 +68,7 @@ func TestCompareInstances_NewFormat(t *testing.T) {
 		name     string
 		aws      *awsm.AWSInstance
 		tf       *terafm.TFInstance
-		expected []string
+		expected []Drift
 	}{
 		{
 			name: "no drift",
@@ -77,7 +84,7 @@ func TestCompareInstances_NewFormat(t *testing.T) {
 				AMI:          "ami-12345",
 				Tags:         map[string]string{"env": "production"},
 			},
-			expected: []string{"No drift detected between AWS instance and Terraform state."},
+			expected: []Drift{},
 		},
 		{
 			name: "instance type drift",
@@ -89,11 +96,39 @@ func TestCompareInstances_NewFormat(t *testing.T) {
 			},
 			tf: &terafm.TFInstance{
 				ID:           "i-12345",
+				Address:      "aws_instance.web",
 				InstanceType: "t2.large",
 				AMI:          "ami-12345",
 				Tags:         map[string]string{"env": "production"},
 			},
-			expected: []string{"Drift in instance i-12345: instance_type mismatch (AWS: t2.micro, TF: t2.large)"},
+			expected: []Drift{{
+				Address:    "aws_instance.web",
+				ResourceID: "i-12345",
+				Attribute:  "instance_type",
+				Expected:   "t2.large",
+				Actual:     "t2.micro",
+				Source:     SourceConfig,
+				Severity:   SeverityMedium,
+			}},
+		},
+		{
+			name: "tag drift",
+			aws: &awsm.AWSInstance{
+				InstanceID:   "i-12345",
+				InstanceType: "t2.micro",
+				AMI:          "ami-12345",
+				Tags:         map[string]string{"env": "staging"},
+			},
+			tf: &terafm.TFInstance{
+				Address:      "aws_instance.web",
+				InstanceType: "t2.micro",
+				AMI:          "ami-12345",
+				Tags:         map[string]string{"env": "production", "team": "platform"},
+			},
+			expected: []Drift{
+				{Address: "aws_instance.web", ResourceID: "i-12345", Attribute: "tags.env", Expected: "production", Actual: "staging", Source: SourceConfig, Severity: SeverityLow},
+				{Address: "aws_instance.web", ResourceID: "i-12345", Attribute: "tags.team", Expected: "platform", Actual: "", Source: SourceConfig, Severity: SeverityLow},
+			},
 		},
 	}
 
@@ -120,3 +155,170 @@ func TestCompareInstances_NewFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareInstances_SkipsUnknownAttributes(t *testing.T) {
+	awsInstance := &awsm.AWSInstance{
+		InstanceID:   "i-12345",
+		InstanceType: "t2.micro",
+		AMI:          "ami-live",
+		Tags:         map[string]string{"Name": "web", "Owner": "team-a"},
+	}
+
+	tfInstance := &terafm.TFInstance{
+		InstanceType: "t2.micro",
+		Tags:         map[string]string{"Name": "web"},
+		Unknown:      map[string]bool{"ami": true, "tags.Owner": true},
+	}
+
+	drift, err := compareInstances(awsInstance, tfInstance)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	// A known value that differs is still reported
+	tfInstance.InstanceType = "t2.large"
+	drift, err = compareInstances(awsInstance, tfInstance)
+	require.NoError(t, err)
+	require.Len(t, drift, 1)
+	assert.Equal(t, "instance_type", drift[0].Attribute)
+	assert.Equal(t, "t2.large", drift[0].Expected)
+	assert.Equal(t, "t2.micro", drift[0].Actual)
+}
+
+func TestCompareInstances_SkipsOmittedAttributes(t *testing.T) {
+	awsInstance := &awsm.AWSInstance{
+		InstanceID:   "i-12345",
+		InstanceType: "t2.micro",
+		AMI:          "ami-live",
+		Tags:         map[string]string{"Name": "web"},
+	}
+
+	// The config leaves instance_type and ami to a launch template
+	tfInstance := &terafm.TFInstance{
+		Tags: map[string]string{"Name": "web"},
+	}
+
+	drift, err := compareInstances(awsInstance, tfInstance)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	// A declared value that differs is still reported
+	tfInstance.AMI = "ami-config"
+	drift, err = compareInstances(awsInstance, tfInstance)
+	require.NoError(t, err)
+	require.Len(t, drift, 1)
+	assert.Equal(t, "ami", drift[0].Attribute)
+	assert.Equal(t, "ami-config", drift[0].Expected)
+	assert.Equal(t, "ami-live", drift[0].Actual)
+}
+
+func TestCompareAWSInstanceWithTerraform(t *testing.T) {
+	awsInstance := &awsm.AWSInstance{
+		InstanceID:   "i-12345",
+		InstanceType: "t2.large",
+		AMI:          "ami-12345",
+		Tags:         map[string]string{"Name": "web"},
+		SecurityGroups: []awsm.SecurityGroup{
+			{GroupId: "sg-1"},
+		},
+		NetworkInterfaces: []awsm.NetworkInterface{
+			{PrivateIpAddress: "10.0.0.1"},
+		},
+	}
+	match := &StateEntry{
+		Address: "aws_instance.web",
+		Instance: &terafm.Instance{Attributes: terafm.InstanceAttributes{
+			InstanceID:          "i-12345",
+			InstanceType:        "t2.micro",
+			AMI:                 "ami-12345",
+			PrivateIP:           "10.0.0.1",
+			Tags:                map[string]string{"Name": "web"},
+			VpcSecurityGroupIDs: []string{"sg-1", "sg-2"},
+		}},
+	}
+
+	drifts, err := compareAWSInstanceWithTerraform(context.Background(), awsInstance, match, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Drift{
+		{Address: "aws_instance.web", ResourceID: "i-12345", Attribute: "instance_type", Expected: "t2.micro", Actual: "t2.large", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand},
+		{Address: "aws_instance.web", ResourceID: "i-12345", Attribute: "vpc_security_group_ids", Expected: "sg-2", Actual: "", Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand, Change: ChangeRemoved},
+	}, drifts)
+
+	_, err = compareAWSInstanceWithTerraform(context.Background(), awsInstance, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestDriftReport(t *testing.T) {
+	report := newDriftReport()
+	assert.False(t, report.HasDrift())
+
+	report.Add(
+		Drift{Address: "aws_instance.web", Attribute: "tags.Name", Source: SourceConfig},
+		Drift{Address: "aws_instance.db", Attribute: "ami", Source: SourceState},
+		Drift{Address: "aws_instance.web", Attribute: "ami", Source: SourceState},
+	)
+	report.complete()
+
+	assert.True(t, report.HasDrift())
+	assert.Equal(t, []string{"aws_instance.db", "aws_instance.web", "aws_instance.web"},
+		[]string{report.Drifts[0].Address, report.Drifts[1].Address, report.Drifts[2].Address})
+	assert.Equal(t, SourceState, report.Drifts[1].Source, "state drifts are listed before config drifts")
+	assert.Len(t, report.ForAddress("aws_instance.web"), 2)
+	assert.Empty(t, report.ForAddress("aws_instance.missing"))
+}
+
+func TestSeverityFor(t *testing.T) {
+	assert.Equal(t, SeverityMedium, severityFor("instance_type"))
+	assert.Equal(t, SeverityLow, severityFor("tags.Name"))
+	assert.Equal(t, SeverityHigh, severityFor("vpc_security_group_ids"))
+}
+
+func TestClassifyDrifts(t *testing.T) {
+	stateDrifts := []Drift{
+		{Attribute: "instance_type", Expected: "t2.micro", Actual: "t2.large", Source: SourceState},
+	}
+	configDrifts := []Drift{
+		// Config, state and live all differ: the live change is what needs chasing
+		{Attribute: "instance_type", Expected: "t3.micro", Actual: "t2.large", Source: SourceConfig},
+		// State matches live, config was edited but not applied
+		{Attribute: "ami", Expected: "ami-new", Actual: "ami-old", Source: SourceConfig},
+		{Attribute: "tags.Owner", Expected: "team-a", Actual: "", Source: SourceConfig},
+	}
+
+	drifts := classifyDrifts(stateDrifts, configDrifts)
+	assert.Equal(t, []Drift{
+		{Attribute: "instance_type", Expected: "t2.micro", Actual: "t2.large", Source: SourceState, Category: CategoryOutOfBand},
+		{Attribute: "ami", Expected: "ami-new", Actual: "ami-old", Source: SourceConfig, Category: CategoryConfigNotApplied},
+		{Attribute: "tags.Owner", Expected: "team-a", Actual: "", Source: SourceConfig, Category: CategoryConfigNotApplied},
+	}, drifts)
+
+	assert.Empty(t, classifyDrifts(nil, nil))
+}
+
+func TestUnmanagedFilter_Ignores(t *testing.T) {
+	filter := UnmanagedFilter{
+		IgnoreTags:  map[string]string{"aws:autoscaling:groupName": "", "drift": "ignore"},
+		IgnoreNames: []string{"bastion-*", "i-0abc*"},
+	}
+
+	tests := []struct {
+		name       string
+		resourceID string
+		tags       map[string]string
+		expected   bool
+	}{
+		{name: "tag key with any value", resourceID: "i-1", tags: map[string]string{"aws:autoscaling:groupName": "workers"}, expected: true},
+		{name: "tag key and value", resourceID: "i-1", tags: map[string]string{"drift": "ignore"}, expected: true},
+		{name: "tag value differs", resourceID: "i-1", tags: map[string]string{"drift": "check"}, expected: false},
+		{name: "name pattern", resourceID: "i-1", tags: map[string]string{"Name": "bastion-eu"}, expected: true},
+		{name: "ID pattern", resourceID: "i-0abc123", expected: true},
+		{name: "no match", resourceID: "i-1", tags: map[string]string{"Name": "web"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, filter.Ignores(tt.resourceID, tt.tags))
+		})
+	}
+
+	assert.False(t, UnmanagedFilter{}.Ignores("i-1", map[string]string{"Name": "web"}))
+}
@@ -0,0 +1,347 @@
+package driftChecker
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// The IAM handlers don't report unmanaged roles and policies: AWS services create
+// service-linked roles in every account that Terraform doesn't manage.
+
+// roleState is everything the Terraform state records about one role, merged from the
+// aws_iam_role and its aws_iam_role_policy and aws_iam_role_policy_attachment
+// resources. A role only referenced by those resources is checked for them alone.
+type roleState struct {
+	Managed            bool
+	Path               string
+	Description        string
+	AssumeRolePolicy   string
+	MaxSessionDuration int
+	Tags               map[string]string
+	// InlinePolicies maps each inline policy name to its document
+	InlinePolicies map[string]string
+	// AttachedPolicies is the set of attached managed policy ARNs
+	AttachedPolicies map[string]bool
+	// AllInline and AllAttached report whether the aws_iam_role records every inline
+	// policy and attachment of the role, so live ones missing from the state are drift
+	AllInline   bool
+	AllAttached bool
+	// PolicyAddresses and AttachmentAddresses map an inline policy name or a policy
+	// ARN to the address of the resource managing it
+	PolicyAddresses     map[string]string
+	AttachmentAddresses map[string]string
+}
+
+// IAMRoleHandler compares aws_iam_role resources, merged with their inline policy and
+// policy attachment resources, with the live roles
+type IAMRoleHandler struct {
+	client IAMClient
+	logger *zap.Logger
+}
+
+// NewIAMRoleHandler creates the aws_iam_role handler
+func NewIAMRoleHandler(client IAMClient, logger *zap.Logger) *IAMRoleHandler {
+	return &IAMRoleHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *IAMRoleHandler) Types() []string {
+	return []string{"aws_iam_role", "aws_iam_role_policy", "aws_iam_role_policy_attachment"}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *IAMRoleHandler) ReportsUnmanaged() bool {
+	return false
+}
+
+// Global implements GlobalHandler, IAM isn't regional
+func (h *IAMRoleHandler) Global() bool {
+	return true
+}
+
+// FetchLive lists the live roles by name. Their policies are read by Compare, only for
+// the roles Terraform manages.
+func (h *IAMRoleHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	roles, err := h.client.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]LiveResource, 0, len(roles))
+	for _, role := range roles {
+		live = append(live, LiveResource{ID: role.RoleName, Value: role})
+	}
+	return live, nil
+}
+
+// MapState returns one entry per role, with its inline policies and attachments merged
+func (h *IAMRoleHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	byName := make(map[string]*StateEntry)
+	var entries []*StateEntry
+	role := func(name string, source *StateEntry) *roleState {
+		entry, ok := byName[name]
+		if !ok {
+			entry = &StateEntry{
+				Address:  source.Address,
+				ID:       name,
+				Resource: source.Resource,
+				Instance: source.Instance,
+				Value: &roleState{
+					InlinePolicies:      make(map[string]string),
+					AttachedPolicies:    make(map[string]bool),
+					PolicyAddresses:     make(map[string]string),
+					AttachmentAddresses: make(map[string]string),
+				},
+			}
+			byName[name] = entry
+			entries = append(entries, entry)
+		}
+		return entry.Value.(*roleState)
+	}
+
+	roles := mapStateEntries(tfState, "aws_iam_role", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.IAMRoleAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return firstNonEmpty(attrs.Name, attrs.ID), &attrs, err
+	})
+	for _, source := range roles {
+		if source.ID == "" {
+			continue
+		}
+		attrs := source.Value.(*terafm.IAMRoleAttributes)
+		state := role(source.ID, source)
+		state.Managed = true
+		state.Path = attrs.Path
+		state.Description = attrs.Description
+		state.AssumeRolePolicy = attrs.AssumeRolePolicy
+		state.MaxSessionDuration = attrs.MaxSessionDuration
+		state.Tags = attrs.Tags
+		state.AllInline = attrs.InlinePolicies != nil
+		state.AllAttached = attrs.ManagedPolicyArns != nil
+		for _, policy := range attrs.InlinePolicies {
+			if policy.Name != "" {
+				state.InlinePolicies[policy.Name] = policy.Policy
+			}
+		}
+		for _, arn := range attrs.ManagedPolicyArns {
+			state.AttachedPolicies[arn] = true
+		}
+	}
+
+	policies := mapStateEntries(tfState, "aws_iam_role_policy", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.IAMRolePolicyAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.Role, &attrs, err
+	})
+	for _, source := range policies {
+		attrs := source.Value.(*terafm.IAMRolePolicyAttributes)
+		if attrs.Role == "" || attrs.Name == "" {
+			continue
+		}
+		state := role(attrs.Role, source)
+		state.InlinePolicies[attrs.Name] = attrs.Policy
+		state.PolicyAddresses[attrs.Name] = source.Address
+	}
+
+	attachments := mapStateEntries(tfState, "aws_iam_role_policy_attachment", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.IAMRolePolicyAttachmentAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.Role, &attrs, err
+	})
+	for _, source := range attachments {
+		attrs := source.Value.(*terafm.IAMRolePolicyAttachmentAttributes)
+		if attrs.Role == "" || attrs.PolicyARN == "" {
+			continue
+		}
+		state := role(attrs.Role, source)
+		state.AttachedPolicies[attrs.PolicyARN] = true
+		state.AttachmentAddresses[attrs.PolicyARN] = source.Address
+	}
+	return entries
+}
+
+// Compare reads the policies of a live role and compares its trust policy,
+// settings, tags, inline policies and attached managed policies with the state.
+// Policy documents are compared semantically.
+func (h *IAMRoleHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	role, err := h.client.GetRole(ctx, live.ID)
+	if err != nil {
+		return nil, err
+	}
+	state := entry.Value.(*roleState)
+
+	var drifts []Drift
+	if state.Managed {
+		expected, actual := comparablePolicy(state.AssumeRolePolicy), comparablePolicy(role.AssumeRolePolicy)
+		if expected != actual {
+			drifts = append(drifts, attributeDrift("assume_role_policy", expected, actual))
+		}
+		if role.Description != state.Description {
+			drifts = append(drifts, attributeDrift("description", state.Description, role.Description))
+		}
+		if state.Path != "" && role.Path != state.Path {
+			drifts = append(drifts, attributeDrift("path", state.Path, role.Path))
+		}
+		if state.MaxSessionDuration > 0 && int(role.MaxSessionDuration) != state.MaxSessionDuration {
+			drifts = append(drifts, attributeDrift("max_session_duration",
+				strconv.Itoa(state.MaxSessionDuration), strconv.Itoa(int(role.MaxSessionDuration))))
+		}
+		drifts = append(drifts, tagDrifts(state.Tags, role.Tags)...)
+	}
+	drifts = stampStateDrifts(drifts, entry, role.RoleName)
+
+	policyDrifts := compareInlinePolicies(state, role.InlinePolicies)
+	policyDrifts = append(policyDrifts, compareAttachedPolicies(state, role.AttachedPolicyArns)...)
+	for _, drift := range stampStateDrifts(policyDrifts, entry, role.RoleName) {
+		if address, ok := state.PolicyAddresses[drift.Details["policy_name"]]; ok {
+			drift.Address = address
+		}
+		if address, ok := state.AttachmentAddresses[drift.Details["policy_arn"]]; ok {
+			drift.Address = address
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// compareInlinePolicies diffs the inline policies of a role with the state by name
+func compareInlinePolicies(state *roleState, live map[string]string) []Drift {
+	var drifts []Drift
+	add := func(name, expected, actual string, change Change) {
+		drift := attributeDrift("inline_policy", expected, actual)
+		drift.Change = change
+		drift.Details = map[string]string{"policy_name": name}
+		drifts = append(drifts, drift)
+	}
+
+	for _, name := range sortedKeys(state.InlinePolicies) {
+		expected := comparablePolicy(state.InlinePolicies[name])
+		document, ok := live[name]
+		switch {
+		case !ok:
+			add(name, expected, "", ChangeRemoved)
+		case comparablePolicy(document) != expected:
+			add(name, expected, comparablePolicy(document), ChangeModified)
+		}
+	}
+	if state.AllInline {
+		for _, name := range sortedKeys(live) {
+			if _, ok := state.InlinePolicies[name]; !ok {
+				add(name, "", comparablePolicy(live[name]), ChangeAdded)
+			}
+		}
+	}
+	return drifts
+}
+
+// compareAttachedPolicies diffs the managed policies attached to a role with the state
+func compareAttachedPolicies(state *roleState, live []string) []Drift {
+	attached := make(map[string]bool, len(live))
+	for _, arn := range live {
+		attached[arn] = true
+	}
+
+	var drifts []Drift
+	add := func(arn, expected, actual string, change Change) {
+		drift := attributeDrift("managed_policy_arns", expected, actual)
+		drift.Change = change
+		drift.Details = map[string]string{"policy_arn": arn}
+		drifts = append(drifts, drift)
+	}
+
+	expected := make([]string, 0, len(state.AttachedPolicies))
+	for arn := range state.AttachedPolicies {
+		expected = append(expected, arn)
+	}
+	sort.Strings(expected)
+	for _, arn := range expected {
+		if !attached[arn] {
+			add(arn, arn, "", ChangeRemoved)
+		}
+	}
+	if state.AllAttached {
+		for _, arn := range sortedUnique(live) {
+			if !state.AttachedPolicies[arn] {
+				add(arn, "", arn, ChangeAdded)
+			}
+		}
+	}
+	return drifts
+}
+
+// IAMPolicyHandler compares aws_iam_policy resources with the live customer managed
+// policies
+type IAMPolicyHandler struct {
+	client IAMClient
+	logger *zap.Logger
+}
+
+// NewIAMPolicyHandler creates the aws_iam_policy handler
+func NewIAMPolicyHandler(client IAMClient, logger *zap.Logger) *IAMPolicyHandler {
+	return &IAMPolicyHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *IAMPolicyHandler) Types() []string {
+	return []string{"aws_iam_policy"}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *IAMPolicyHandler) ReportsUnmanaged() bool {
+	return false
+}
+
+// Global implements GlobalHandler, IAM isn't regional
+func (h *IAMPolicyHandler) Global() bool {
+	return true
+}
+
+// FetchLive lists the live customer managed policies by ARN
+func (h *IAMPolicyHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	policies, err := h.client.ListPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]LiveResource, 0, len(policies))
+	for _, policy := range policies {
+		live = append(live, LiveResource{ID: policy.Arn, Value: policy})
+	}
+	return live, nil
+}
+
+// MapState implements ResourceHandler
+func (h *IAMPolicyHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	return mapStateEntries(tfState, "aws_iam_policy", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.IAMPolicyAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return firstNonEmpty(attrs.ARN, attrs.ID), &attrs, err
+	})
+}
+
+// Compare reads the default version of a live policy and compares its document,
+// description, path and tags with the state
+func (h *IAMPolicyHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	policy, err := h.client.GetPolicy(ctx, live.ID)
+	if err != nil {
+		return nil, err
+	}
+	state := entry.Value.(*terafm.IAMPolicyAttributes)
+
+	var drifts []Drift
+	expected, actual := comparablePolicy(state.Policy), comparablePolicy(policy.Document)
+	if expected != actual {
+		drifts = append(drifts, attributeDrift("policy", expected, actual))
+	}
+	if policy.Description != state.Description {
+		drifts = append(drifts, attributeDrift("description", state.Description, policy.Description))
+	}
+	if state.Path != "" && policy.Path != state.Path {
+		drifts = append(drifts, attributeDrift("path", state.Path, policy.Path))
+	}
+	drifts = append(drifts, tagDrifts(state.Tags, policy.Tags)...)
+	return stampStateDrifts(drifts, entry, policy.Arn), nil
+}
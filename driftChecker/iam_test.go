@@ -0,0 +1,241 @@
+package driftChecker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+const (
+	ec2TrustPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+	readLogsPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["logs:GetLogEvents","logs:DescribeLogStreams"],"Resource":"*"}]}`
+)
+
+// iamState is a role with an inline policy of its own, one managed by an
+// aws_iam_role_policy and a managed policy attached by an
+// aws_iam_role_policy_attachment, plus a customer managed policy
+func iamState() *terafm.TerraformState {
+	return &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_iam_role", Name: "web", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "web", "name": "web", "arn": "arn:aws:iam::123:role/web", "path": "/", "description": "web servers",
+			"assume_role_policy": ` + quoteJSON(ec2TrustPolicy) + `,
+			"max_session_duration": 3600,
+			"inline_policy": [{"name": "read-logs", "policy": ` + quoteJSON(readLogsPolicy) + `}],
+			"managed_policy_arns": ["arn:aws:iam::aws:policy/ReadOnlyAccess"],
+			"tags": {"env": "prod"}
+		}`)}},
+		{Type: "aws_iam_role_policy", Name: "s3", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "web:s3", "role": "web", "name": "s3",
+			"policy": "{\"Version\":\"2012-10-17\",\"Statement\":{\"Effect\":\"Allow\",\"Action\":\"s3:GetObject\",\"Resource\":\"*\"}}"
+		}`)}},
+		{Type: "aws_iam_role_policy_attachment", Name: "ssm", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "web-ssm", "role": "web", "policy_arn": "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"
+		}`)}},
+		{Type: "aws_iam_policy", Name: "logs", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "arn:aws:iam::123:policy/logs", "arn": "arn:aws:iam::123:policy/logs", "name": "logs", "path": "/",
+			"description": "", "policy": ` + quoteJSON(readLogsPolicy) + `, "tags": {}
+		}`)}},
+	}}
+}
+
+// quoteJSON encodes a document as a JSON string, the way the state stores policies
+func quoteJSON(document string) string {
+	return fmt.Sprintf("%q", document)
+}
+
+// liveWebRole is the live role matching iamState, with its documents written
+// differently but meaning the same
+func liveWebRole() *awsm.AWSRole {
+	return &awsm.AWSRole{
+		RoleName:           "web",
+		Arn:                "arn:aws:iam::123:role/web",
+		Path:               "/",
+		Description:        "web servers",
+		MaxSessionDuration: 3600,
+		AssumeRolePolicy: `{"Version": "2012-10-17", "Statement": [{"Action": ["sts:AssumeRole"],
+			"Principal": {"Service": ["ec2.amazonaws.com"]}, "Effect": "Allow"}]}`,
+		InlinePolicies: map[string]string{
+			"read-logs": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["logs:DescribeLogStreams","logs:GetLogEvents"],"Resource":["*"]}]}`,
+			"s3":        `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+		},
+		AttachedPolicyArns: []string{"arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore", "arn:aws:iam::aws:policy/ReadOnlyAccess"},
+		Tags:               map[string]string{"env": "prod"},
+	}
+}
+
+func TestIAMRoleHandler_MapState(t *testing.T) {
+	entries := NewIAMRoleHandler(nil, zap.NewNop()).MapState(iamState())
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, "aws_iam_role.web", entries[0].Address)
+	assert.Equal(t, "web", entries[0].ID)
+	state := entries[0].Value.(*roleState)
+	assert.True(t, state.Managed)
+	assert.True(t, state.AllInline)
+	assert.True(t, state.AllAttached)
+	assert.Equal(t, []string{"read-logs", "s3"}, sortedKeys(state.InlinePolicies))
+	assert.Equal(t, map[string]bool{
+		"arn:aws:iam::aws:policy/ReadOnlyAccess":               true,
+		"arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore": true,
+	}, state.AttachedPolicies)
+	assert.Equal(t, map[string]string{"s3": "aws_iam_role_policy.s3"}, state.PolicyAddresses)
+	assert.Equal(t, map[string]string{"arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore": "aws_iam_role_policy_attachment.ssm"}, state.AttachmentAddresses)
+}
+
+func TestIAMRoleHandler_Compare(t *testing.T) {
+	const address = "aws_iam_role.web"
+	adminAccess := "arn:aws:iam::aws:policy/AdministratorAccess"
+	ssmCore := "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"
+
+	tests := []struct {
+		name     string
+		modify   func(role *awsm.AWSRole)
+		expected []Drift
+	}{
+		{
+			name:   "equivalent documents",
+			modify: func(role *awsm.AWSRole) {},
+		},
+		{
+			name: "trust policy opened to another account",
+			modify: func(role *awsm.AWSRole) {
+				role.AssumeRolePolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com","AWS":"arn:aws:iam::999:root"},"Action":"sts:AssumeRole"}]}`
+			},
+			expected: []Drift{
+				{Address: address, ResourceID: "web", Attribute: "assume_role_policy",
+					Expected: `{"Statement":[{"Action":["sts:AssumeRole"],"Effect":"Allow","Principal":{"Service":["ec2.amazonaws.com"]}}],"Version":"2012-10-17"}`,
+					Actual:   `{"Statement":[{"Action":["sts:AssumeRole"],"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::999:root"],"Service":["ec2.amazonaws.com"]}}],"Version":"2012-10-17"}`,
+					Source:   SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand},
+			},
+		},
+		{
+			name: "managed policy attached and detached by hand",
+			modify: func(role *awsm.AWSRole) {
+				role.AttachedPolicyArns = []string{"arn:aws:iam::aws:policy/ReadOnlyAccess", adminAccess}
+			},
+			expected: []Drift{
+				{Address: "aws_iam_role_policy_attachment.ssm", ResourceID: "web", Attribute: "managed_policy_arns", Expected: ssmCore, Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand, Change: ChangeRemoved, Details: map[string]string{"policy_arn": ssmCore}},
+				{Address: address, ResourceID: "web", Attribute: "managed_policy_arns", Actual: adminAccess, Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand, Change: ChangeAdded, Details: map[string]string{"policy_arn": adminAccess}},
+			},
+		},
+		{
+			name: "inline policies edited, deleted and added",
+			modify: func(role *awsm.AWSRole) {
+				role.InlinePolicies = map[string]string{
+					"read-logs": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"logs:*","Resource":"*"}]}`,
+					"console":   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`,
+				}
+				role.Tags["env"] = "dev"
+			},
+			expected: []Drift{
+				{Address: address, ResourceID: "web", Attribute: "tags.env", Expected: "prod", Actual: "dev", Source: SourceState, Severity: SeverityLow, Category: CategoryOutOfBand},
+				{Address: address, ResourceID: "web", Attribute: "inline_policy",
+					Expected: `{"Statement":[{"Action":["logs:DescribeLogStreams","logs:GetLogEvents"],"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`,
+					Actual:   `{"Statement":[{"Action":["logs:*"],"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`,
+					Source:   SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand, Change: ChangeModified, Details: map[string]string{"policy_name": "read-logs"}},
+				{Address: "aws_iam_role_policy.s3", ResourceID: "web", Attribute: "inline_policy",
+					Expected: `{"Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`,
+					Source:   SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand, Change: ChangeRemoved, Details: map[string]string{"policy_name": "s3"}},
+				{Address: address, ResourceID: "web", Attribute: "inline_policy",
+					Actual: `{"Statement":[{"Action":["*"],"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`,
+					Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand, Change: ChangeAdded, Details: map[string]string{"policy_name": "console"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := liveWebRole()
+			tt.modify(role)
+			client := new(MockIAMClient)
+			client.On("GetRole", "web").Return(role, nil)
+
+			handler := NewIAMRoleHandler(client, zap.NewNop())
+			drifts := compareFirst(t, handler, iamState(), LiveResource{ID: "web"})
+			assert.Equal(t, tt.expected, drifts)
+		})
+	}
+}
+
+func TestIAMRoleHandler_AttachmentsOnly(t *testing.T) {
+	// Without the aws_iam_role the state doesn't know every attachment, so only the
+	// attachment resources are checked
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{iamState().Resources[2]}}
+	role := liveWebRole()
+	role.AttachedPolicyArns = []string{"arn:aws:iam::aws:policy/AdministratorAccess"}
+	client := new(MockIAMClient)
+	client.On("GetRole", "web").Return(role, nil)
+
+	drifts := compareFirst(t, NewIAMRoleHandler(client, zap.NewNop()), tfState, LiveResource{ID: "web"})
+
+	require.Len(t, drifts, 1)
+	assert.Equal(t, "aws_iam_role_policy_attachment.ssm", drifts[0].Address)
+	assert.Equal(t, ChangeRemoved, drifts[0].Change)
+}
+
+func TestIAMPolicyHandler_Compare(t *testing.T) {
+	const arn = "arn:aws:iam::123:policy/logs"
+
+	tests := []struct {
+		name     string
+		document string
+		expected []Drift
+	}{
+		{
+			name:     "statement reordered",
+			document: `{"Statement":[{"Resource":"*","Action":["logs:DescribeLogStreams","logs:GetLogEvents"],"Effect":"Allow"}],"Version":"2012-10-17"}`,
+		},
+		{
+			name:     "action widened",
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"logs:*","Resource":"*"}]}`,
+			expected: []Drift{
+				{Address: "aws_iam_policy.logs", ResourceID: arn, Attribute: "policy",
+					Expected: `{"Statement":[{"Action":["logs:DescribeLogStreams","logs:GetLogEvents"],"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`,
+					Actual:   `{"Statement":[{"Action":["logs:*"],"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`,
+					Source:   SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := new(MockIAMClient)
+			client.On("GetPolicy", arn).Return(&awsm.AWSPolicy{PolicyName: "logs", Arn: arn, Path: "/", Document: tt.document}, nil)
+
+			drifts := compareFirst(t, NewIAMPolicyHandler(client, zap.NewNop()), iamState(), LiveResource{ID: arn})
+			assert.Equal(t, tt.expected, drifts)
+		})
+	}
+}
+
+func TestDriftService_IAM(t *testing.T) {
+	iamClient := new(MockIAMClient)
+	iamClient.On("ListRoles").Return([]*awsm.AWSRole{{RoleName: "web"}, {RoleName: "AWSServiceRoleForSupport"}}, nil)
+	iamClient.On("GetRole", "web").Return(liveWebRole(), nil)
+	iamClient.On("ListPolicies").Return([]*awsm.AWSPolicy{}, nil)
+
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(iamState(), nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+
+	service := NewDriftService(new(MockAWSClient), tfClient, zap.NewNop(),
+		WithHandler(NewIAMRoleHandler(iamClient, zap.NewNop())),
+		WithHandler(NewIAMPolicyHandler(iamClient, zap.NewNop())),
+	)
+	report, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	// The service-linked role isn't reported and the deleted policy is missing
+	assert.Equal(t, 1, report.ResourcesChecked)
+	assert.Equal(t, []Drift{
+		{Address: "aws_iam_policy.logs", ResourceID: "arn:aws:iam::123:policy/logs", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+	}, report.Drifts)
+	iamClient.AssertExpectations(t)
+}
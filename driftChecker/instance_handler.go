@@ -0,0 +1,233 @@
+package driftChecker
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	"Savannahtakehomeassi/errors"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// InstanceHandler compares aws_instance resources with live EC2 instances, against
+// both the state and the HCL config
+type InstanceHandler struct {
+	client AWSClient
+	logger *zap.Logger
+
+	// groups lists the live security groups of the current check, for the instances
+	// whose groups differ from the state
+	mu     sync.Mutex
+	groups *securityGroupLookup
+}
+
+// NewInstanceHandler creates the aws_instance handler
+func NewInstanceHandler(client AWSClient, logger *zap.Logger) *InstanceHandler {
+	return &InstanceHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *InstanceHandler) Types() []string {
+	return []string{"aws_instance"}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *InstanceHandler) ReportsUnmanaged() bool {
+	return true
+}
+
+// FetchLive returns the live instances. Terminated instances are left out, so their
+// state entries are reported as missing.
+func (h *InstanceHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	instances, err := h.client.GetAWSInstances(ctx)
+	if err != nil {
+		return nil, errors.New(errors.ErrAWSInstance, "Failed to get AWS instances",
+			map[string]interface{}{
+				"operation": "get_aws_instances",
+			}, err)
+	}
+	h.logger.Info("Successfully retrieved AWS instance details",
+		zap.String("operation", "get_aws_instances"),
+		zap.Int("instance_count", len(instances)),
+	)
+	h.mu.Lock()
+	h.groups = newSecurityGroupLookup(h.client)
+	h.mu.Unlock()
+
+	live := make([]LiveResource, 0, len(instances))
+	for _, instance := range instances {
+		if instance.IsTerminated() {
+			h.logger.Info("Skipping terminated AWS instance",
+				zap.String("operation", "instance_match"),
+				zap.String("instance_id", instance.InstanceID),
+				zap.String("state", instance.State),
+			)
+			continue
+		}
+		live = append(live, LiveResource{ID: instance.InstanceID, Tags: instance.Tags, Value: instance})
+	}
+	return live, nil
+}
+
+// MapState returns every managed aws_instance instance in the Terraform state, in the
+// root module and in child modules. The instance ID falls back to the one in the ARN.
+func (h *InstanceHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	return mapStateEntries(tfState, "aws_instance", func(instance *terafm.Instance) (string, interface{}, error) {
+		id := instance.Attributes.InstanceID
+		if id == "" {
+			id = instanceIDFromARN(instance.Attributes.ARN)
+		}
+		return id, nil, nil
+	})
+}
+
+// Compare compares a single live instance against its Terraform state entry and the
+// HCL config, classifying each drifted attribute as out-of-band or not applied
+func (h *InstanceHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	awsInstance := live.Value.(*awsm.AWSInstance)
+
+	// Channels for collecting results
+	type result struct {
+		drift []Drift
+		err   error
+	}
+	results := make(chan result, 2)
+
+	var detached []awsm.SecurityGroup
+	if entry.Instance != nil {
+		detached = detachedSecurityGroups(ctx, h.securityGroups(), h.logger, awsInstance, entry.Instance)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			results <- result{nil, errors.New(errors.ErrDriftChecker, "drift check cancelled",
+				map[string]interface{}{
+					"operation":   "drift_check",
+					"context":     "cancelled",
+					"instance_id": awsInstance.InstanceID,
+				}, nil)}
+			return
+		default:
+			drift, err := compareAWSInstanceWithTerraform(ctx, awsInstance, entry, detached)
+			results <- result{drift, err}
+		}
+	}()
+
+	configInstance := h.findConfigInstance(entry, tfConfig)
+	if configInstance != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-ctx.Done():
+				results <- result{nil, errors.New(errors.ErrDriftChecker, "HCL drift check cancelled",
+					map[string]interface{}{
+						"operation":   "hcl_drift_check",
+						"context":     "cancelled",
+						"instance_id": awsInstance.InstanceID,
+					}, nil)}
+				return
+			default:
+				drift, err := compareInstances(awsInstance, configInstance.TFInstance())
+				results <- result{drift, err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Handle results safely
+	var firstErr error
+	var stateDrifts, configDrifts []Drift
+	for res := range results {
+		if res.err != nil {
+			h.logger.Error("Drift check failed",
+				zap.String("operation", "drift_check"),
+				zap.String("instance_id", awsInstance.InstanceID),
+				zap.String("address", entry.Address),
+				zap.Error(res.err),
+			)
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+		for _, drift := range res.drift {
+			if drift.Source == SourceState {
+				stateDrifts = append(stateDrifts, drift)
+			} else {
+				configDrifts = append(configDrifts, drift)
+			}
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return classifyDrifts(stateDrifts, configDrifts), nil
+}
+
+// securityGroups returns the security group lookup of the current check, or a new one
+// when Compare is called without FetchLive
+func (h *InstanceHandler) securityGroups() *securityGroupLookup {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.groups == nil {
+		return newSecurityGroupLookup(h.client)
+	}
+	return h.groups
+}
+
+// findConfigInstance returns the expanded HCL resource instance with the same index key
+// as the state entry, or nil when the config doesn't declare it
+func (h *InstanceHandler) findConfigInstance(entry *StateEntry, tfConfig *terafm.Config) *terafm.ResourceInstance {
+	// Only the root module's configuration is loaded
+	if entry.Resource.Module != "" {
+		h.logger.Info("Terraform resource declared in a child module, skipping config comparison",
+			zap.String("operation", "hcl_drift_check"),
+			zap.String("address", entry.Address),
+			zap.String("module", entry.Resource.Module),
+		)
+		return nil
+	}
+
+	resourceBlock := tfConfig.FindResource(entry.Resource.Type, entry.Resource.Name)
+	if resourceBlock == nil {
+		h.logger.Warn("Terraform resource not declared in HCL config, skipping config comparison",
+			zap.String("operation", "hcl_drift_check"),
+			zap.String("address", entry.Address),
+		)
+		return nil
+	}
+
+	configInstance := resourceBlock.FindInstance(entry.Instance.IndexKey)
+	if configInstance == nil {
+		h.logger.Warn("Terraform resource instance not expanded in HCL config, skipping config comparison",
+			zap.String("operation", "hcl_drift_check"),
+			zap.String("address", entry.Address),
+		)
+	}
+	return configInstance
+}
+
+// instanceIDFromARN extracts the instance ID from an EC2 instance ARN
+// (arn:aws:ec2:region:account:instance/i-123)
+func instanceIDFromARN(arn string) string {
+	const marker = ":instance/"
+	i := strings.LastIndex(arn, marker)
+	if i == -1 {
+		return ""
+	}
+	return arn[i+len(marker):]
+}
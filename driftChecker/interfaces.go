@@ -8,17 +8,36 @@ import (
 
 // AWSClient defines the interface for AWS operations
 type AWSClient interface {
-	GetAWSInstance() (*awsm.AWSInstance, error)
+	GetAWSInstances(ctx context.Context) ([]*awsm.AWSInstance, error)
+	GetSecurityGroups(ctx context.Context) ([]*awsm.AWSSecurityGroup, error)
+	GetVpcs(ctx context.Context) ([]*awsm.AWSVpc, error)
+	GetSubnets(ctx context.Context) ([]*awsm.AWSSubnet, error)
+	GetRouteTables(ctx context.Context) ([]*awsm.AWSRouteTable, error)
+	GetInternetGateways(ctx context.Context) ([]*awsm.AWSInternetGateway, error)
+}
+
+// S3Client defines the interface for S3 operations
+type S3Client interface {
+	ListBuckets(ctx context.Context) ([]string, error)
+	GetBucket(ctx context.Context, name string) (*awsm.AWSBucket, error)
+}
+
+// IAMClient defines the interface for IAM operations
+type IAMClient interface {
+	ListRoles(ctx context.Context) ([]*awsm.AWSRole, error)
+	GetRole(ctx context.Context, name string) (*awsm.AWSRole, error)
+	ListPolicies(ctx context.Context) ([]*awsm.AWSPolicy, error)
+	GetPolicy(ctx context.Context, arn string) (*awsm.AWSPolicy, error)
 }
 
 // TerraformClient defines the interface for Terraform operations
 type TerraformClient interface {
-	ParseTerraformInstance(path string) (*terafm.TerraformState, error)
-	ParseHCLConfig(path string) (*terafm.TFInstance, error)
+	ParseTerraformInstance(ctx context.Context, path string) (*terafm.TerraformState, error)
+	ParseHCLConfig(ctx context.Context, path string) (*terafm.Config, error)
 }
 
 // DriftChecker defines the interface for drift checking operations
 type DriftChecker interface {
 	RunLoop(ctx context.Context, tfSpath, mainfile string, interval int) error
-	runDriftCheck(ctx context.Context, tfPath, mainFile string) error
+	runDriftCheck(ctx context.Context, tfPath, mainFile string) (*DriftReport, error)
 }
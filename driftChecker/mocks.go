@@ -1,6 +1,8 @@
 package driftChecker
 
 import (
+	"context"
+
 	"github.com/stretchr/testify/mock"
 
 	awsm "Savannahtakehomeassi/awsd/models"
@@ -12,13 +14,122 @@ type MockAWSClient struct {
 	mock.Mock
 }
 
-// GetAWSInstance mocks the GetAWSInstance method
-func (m *MockAWSClient) GetAWSInstance() (*awsm.AWSInstance, error) {
+// GetAWSInstances mocks the GetAWSInstances method
+func (m *MockAWSClient) GetAWSInstances(ctx context.Context) ([]*awsm.AWSInstance, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSInstance), args.Error(1)
+}
+
+// GetSecurityGroups mocks the GetSecurityGroups method
+func (m *MockAWSClient) GetSecurityGroups(ctx context.Context) ([]*awsm.AWSSecurityGroup, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSSecurityGroup), args.Error(1)
+}
+
+// GetVpcs mocks the GetVpcs method
+func (m *MockAWSClient) GetVpcs(ctx context.Context) ([]*awsm.AWSVpc, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSVpc), args.Error(1)
+}
+
+// GetSubnets mocks the GetSubnets method
+func (m *MockAWSClient) GetSubnets(ctx context.Context) ([]*awsm.AWSSubnet, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSSubnet), args.Error(1)
+}
+
+// GetRouteTables mocks the GetRouteTables method
+func (m *MockAWSClient) GetRouteTables(ctx context.Context) ([]*awsm.AWSRouteTable, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSRouteTable), args.Error(1)
+}
+
+// GetInternetGateways mocks the GetInternetGateways method
+func (m *MockAWSClient) GetInternetGateways(ctx context.Context) ([]*awsm.AWSInternetGateway, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSInternetGateway), args.Error(1)
+}
+
+// MockS3Client is a mock implementation of S3Client
+type MockS3Client struct {
+	mock.Mock
+}
+
+// ListBuckets mocks the ListBuckets method
+func (m *MockS3Client) ListBuckets(ctx context.Context) ([]string, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*awsm.AWSInstance), args.Error(1)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// GetBucket mocks the GetBucket method
+func (m *MockS3Client) GetBucket(ctx context.Context, name string) (*awsm.AWSBucket, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*awsm.AWSBucket), args.Error(1)
+}
+
+// MockIAMClient is a mock implementation of IAMClient
+type MockIAMClient struct {
+	mock.Mock
+}
+
+// ListRoles mocks the ListRoles method
+func (m *MockIAMClient) ListRoles(ctx context.Context) ([]*awsm.AWSRole, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSRole), args.Error(1)
+}
+
+// GetRole mocks the GetRole method
+func (m *MockIAMClient) GetRole(ctx context.Context, name string) (*awsm.AWSRole, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*awsm.AWSRole), args.Error(1)
+}
+
+// ListPolicies mocks the ListPolicies method
+func (m *MockIAMClient) ListPolicies(ctx context.Context) ([]*awsm.AWSPolicy, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*awsm.AWSPolicy), args.Error(1)
+}
+
+// GetPolicy mocks the GetPolicy method
+func (m *MockIAMClient) GetPolicy(ctx context.Context, arn string) (*awsm.AWSPolicy, error) {
+	args := m.Called(arn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*awsm.AWSPolicy), args.Error(1)
 }
 
 // MockTerraformClient is a mock implementation of TerraformClient
@@ -27,7 +138,7 @@ type MockTerraformClient struct {
 }
 
 // ParseTerraformInstance mocks the ParseTerraformInstance method
-func (m *MockTerraformClient) ParseTerraformInstance(path string) (*terafm.TerraformState, error) {
+func (m *MockTerraformClient) ParseTerraformInstance(ctx context.Context, path string) (*terafm.TerraformState, error) {
 	args := m.Called(path)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -36,11 +147,11 @@ func (m *MockTerraformClient) ParseTerraformInstance(path string) (*terafm.Terra
 }
 
 // ParseHCLConfig mocks the ParseHCLConfig method
-func (m *MockTerraformClient) ParseHCLConfig(path string) (*terafm.TFInstance, error) {
+func (m *MockTerraformClient) ParseHCLConfig(ctx context.Context, path string) (*terafm.Config, error) {
 	args := m.Called(path)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 
-	return args.Get(0).(*terafm.TFInstance), args.Error(1)
+	return args.Get(0).(*terafm.Config), args.Error(1)
 }
@@ -0,0 +1,370 @@
+package driftChecker
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// Live route origins that Terraform never manages: the local route created with the
+// table and routes propagated from a virtual private gateway
+const (
+	routeOriginCreateRouteTable = "CreateRouteTable"
+	routeOriginPropagated       = "EnableVgwRoutePropagation"
+)
+
+// The networking handlers don't report unmanaged resources: every account has a
+// default VPC with its subnets, route table and internet gateway that Terraform
+// usually doesn't manage.
+
+// VpcHandler compares aws_vpc resources with the live VPCs
+type VpcHandler struct {
+	client AWSClient
+	logger *zap.Logger
+}
+
+// NewVpcHandler creates the aws_vpc handler
+func NewVpcHandler(client AWSClient, logger *zap.Logger) *VpcHandler {
+	return &VpcHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *VpcHandler) Types() []string {
+	return []string{"aws_vpc"}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *VpcHandler) ReportsUnmanaged() bool {
+	return false
+}
+
+// FetchLive implements ResourceHandler
+func (h *VpcHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	vpcs, err := h.client.GetVpcs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]LiveResource, 0, len(vpcs))
+	for _, vpc := range vpcs {
+		live = append(live, LiveResource{ID: vpc.VpcId, Tags: vpc.Tags, Value: vpc})
+	}
+	return live, nil
+}
+
+// MapState implements ResourceHandler
+func (h *VpcHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	return mapStateEntries(tfState, "aws_vpc", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.VpcAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.ID, &attrs, err
+	})
+}
+
+// Compare compares the CIDR blocks, tenancy and tags of a VPC with the state
+func (h *VpcHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	vpc := live.Value.(*awsm.AWSVpc)
+	state := entry.Value.(*terafm.VpcAttributes)
+
+	var drifts []Drift
+	if vpc.CidrBlock != state.CidrBlock {
+		drifts = append(drifts, attributeDrift("cidr_block", state.CidrBlock, vpc.CidrBlock))
+	}
+	if vpc.Ipv6CidrBlock != state.Ipv6CidrBlock {
+		drifts = append(drifts, attributeDrift("ipv6_cidr_block", state.Ipv6CidrBlock, vpc.Ipv6CidrBlock))
+	}
+	if state.InstanceTenancy != "" && vpc.InstanceTenancy != state.InstanceTenancy {
+		drifts = append(drifts, attributeDrift("instance_tenancy", state.InstanceTenancy, vpc.InstanceTenancy))
+	}
+	drifts = append(drifts, tagDrifts(state.Tags, vpc.Tags)...)
+	return stampStateDrifts(drifts, entry, vpc.VpcId), nil
+}
+
+// SubnetHandler compares aws_subnet resources with the live subnets
+type SubnetHandler struct {
+	client AWSClient
+	logger *zap.Logger
+}
+
+// NewSubnetHandler creates the aws_subnet handler
+func NewSubnetHandler(client AWSClient, logger *zap.Logger) *SubnetHandler {
+	return &SubnetHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *SubnetHandler) Types() []string {
+	return []string{"aws_subnet"}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *SubnetHandler) ReportsUnmanaged() bool {
+	return false
+}
+
+// FetchLive implements ResourceHandler
+func (h *SubnetHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	subnets, err := h.client.GetSubnets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]LiveResource, 0, len(subnets))
+	for _, subnet := range subnets {
+		live = append(live, LiveResource{ID: subnet.SubnetId, Tags: subnet.Tags, Value: subnet})
+	}
+	return live, nil
+}
+
+// MapState implements ResourceHandler
+func (h *SubnetHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	return mapStateEntries(tfState, "aws_subnet", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.SubnetAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.ID, &attrs, err
+	})
+}
+
+// Compare compares the VPC, CIDR blocks, availability zone, public IP mapping and tags
+// of a subnet with the state
+func (h *SubnetHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	subnet := live.Value.(*awsm.AWSSubnet)
+	state := entry.Value.(*terafm.SubnetAttributes)
+
+	var drifts []Drift
+	if subnet.VpcId != state.VpcID {
+		drifts = append(drifts, attributeDrift("vpc_id", state.VpcID, subnet.VpcId))
+	}
+	if subnet.CidrBlock != state.CidrBlock {
+		drifts = append(drifts, attributeDrift("cidr_block", state.CidrBlock, subnet.CidrBlock))
+	}
+	if subnet.Ipv6CidrBlock != state.Ipv6CidrBlock {
+		drifts = append(drifts, attributeDrift("ipv6_cidr_block", state.Ipv6CidrBlock, subnet.Ipv6CidrBlock))
+	}
+	if subnet.AvailabilityZone != state.AvailabilityZone {
+		drifts = append(drifts, attributeDrift("availability_zone", state.AvailabilityZone, subnet.AvailabilityZone))
+	}
+	if subnet.MapPublicIpOnLaunch != state.MapPublicIPOnLaunch {
+		drifts = append(drifts, attributeDrift("map_public_ip_on_launch",
+			strconv.FormatBool(state.MapPublicIPOnLaunch), strconv.FormatBool(subnet.MapPublicIpOnLaunch)))
+	}
+	drifts = append(drifts, tagDrifts(state.Tags, subnet.Tags)...)
+	return stampStateDrifts(drifts, entry, subnet.SubnetId), nil
+}
+
+// InternetGatewayHandler compares aws_internet_gateway resources with the live gateways
+type InternetGatewayHandler struct {
+	client AWSClient
+	logger *zap.Logger
+}
+
+// NewInternetGatewayHandler creates the aws_internet_gateway handler
+func NewInternetGatewayHandler(client AWSClient, logger *zap.Logger) *InternetGatewayHandler {
+	return &InternetGatewayHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *InternetGatewayHandler) Types() []string {
+	return []string{"aws_internet_gateway"}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *InternetGatewayHandler) ReportsUnmanaged() bool {
+	return false
+}
+
+// FetchLive implements ResourceHandler
+func (h *InternetGatewayHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	gateways, err := h.client.GetInternetGateways(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]LiveResource, 0, len(gateways))
+	for _, gateway := range gateways {
+		live = append(live, LiveResource{ID: gateway.InternetGatewayId, Tags: gateway.Tags, Value: gateway})
+	}
+	return live, nil
+}
+
+// MapState implements ResourceHandler
+func (h *InternetGatewayHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	return mapStateEntries(tfState, "aws_internet_gateway", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.InternetGatewayAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.ID, &attrs, err
+	})
+}
+
+// Compare compares the VPC attachment and tags of a gateway with the state
+func (h *InternetGatewayHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	gateway := live.Value.(*awsm.AWSInternetGateway)
+	state := entry.Value.(*terafm.InternetGatewayAttributes)
+
+	var drifts []Drift
+	if gateway.VpcId != state.VpcID {
+		drifts = append(drifts, attributeDrift("vpc_id", state.VpcID, gateway.VpcId))
+	}
+	drifts = append(drifts, tagDrifts(state.Tags, gateway.Tags)...)
+	return stampStateDrifts(drifts, entry, gateway.InternetGatewayId), nil
+}
+
+// routeTableState is everything the Terraform state records about one route table. A
+// table managed by an aws_route_table has all its routes in the state; one only
+// referenced by aws_route resources is checked for those routes alone.
+type routeTableState struct {
+	Managed bool
+	// Routes maps each destination to its target
+	Routes map[string]string
+	Tags   map[string]string
+}
+
+// RouteTableHandler compares aws_route_table resources, merged with their aws_route
+// resources, with the live route tables
+type RouteTableHandler struct {
+	client AWSClient
+	logger *zap.Logger
+}
+
+// NewRouteTableHandler creates the aws_route_table handler
+func NewRouteTableHandler(client AWSClient, logger *zap.Logger) *RouteTableHandler {
+	return &RouteTableHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *RouteTableHandler) Types() []string {
+	return []string{"aws_route_table", "aws_route"}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *RouteTableHandler) ReportsUnmanaged() bool {
+	return false
+}
+
+// FetchLive implements ResourceHandler
+func (h *RouteTableHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	routeTables, err := h.client.GetRouteTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]LiveResource, 0, len(routeTables))
+	for _, routeTable := range routeTables {
+		live = append(live, LiveResource{ID: routeTable.RouteTableId, Tags: routeTable.Tags, Value: routeTable})
+	}
+	return live, nil
+}
+
+// MapState returns one entry per route table, with the routes of the table and its
+// aws_route resources merged
+func (h *RouteTableHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	byID := make(map[string]*StateEntry)
+	var entries []*StateEntry
+	table := func(id string, source *StateEntry) *routeTableState {
+		entry, ok := byID[id]
+		if !ok {
+			entry = &StateEntry{
+				Address:  source.Address,
+				ID:       id,
+				Resource: source.Resource,
+				Instance: source.Instance,
+				Value:    &routeTableState{Routes: make(map[string]string)},
+			}
+			byID[id] = entry
+			entries = append(entries, entry)
+		}
+		return entry.Value.(*routeTableState)
+	}
+
+	tables := mapStateEntries(tfState, "aws_route_table", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.RouteTableAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.ID, &attrs, err
+	})
+	for _, source := range tables {
+		attrs := source.Value.(*terafm.RouteTableAttributes)
+		if attrs.ID == "" {
+			continue
+		}
+		state := table(attrs.ID, source)
+		state.Managed = true
+		state.Tags = attrs.Tags
+		for _, route := range attrs.Routes {
+			state.Routes[route.Destination()] = route.Target()
+		}
+	}
+
+	routes := mapStateEntries(tfState, "aws_route", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.RouteAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.RouteTableID, &attrs, err
+	})
+	for _, source := range routes {
+		attrs := source.Value.(*terafm.RouteAttributes)
+		if attrs.RouteTableID == "" {
+			continue
+		}
+		state := table(attrs.RouteTableID, source)
+		if _, ok := state.Routes[attrs.Destination()]; !ok {
+			state.Routes[attrs.Destination()] = attrs.Target()
+		}
+	}
+	return entries
+}
+
+// Compare diffs the routes of a live route table with the state by destination,
+// reporting routes added, removed or pointed at a different target. The local route
+// and propagated routes are left out.
+func (h *RouteTableHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	routeTable := live.Value.(*awsm.AWSRouteTable)
+	state := entry.Value.(*routeTableState)
+
+	liveRoutes := make(map[string]string, len(routeTable.Routes))
+	for _, route := range routeTable.Routes {
+		if route.Origin == routeOriginCreateRouteTable || route.Origin == routeOriginPropagated || route.Target == "local" {
+			continue
+		}
+		liveRoutes[route.Destination] = route.Target
+	}
+
+	var drifts []Drift
+	add := func(destination, expected, actual string, change Change) {
+		drift := attributeDrift("route", expected, actual)
+		drift.Change = change
+		drift.Details = map[string]string{
+			"destination": destination,
+			"vpc_id":      routeTable.VpcId,
+		}
+		drifts = append(drifts, drift)
+	}
+
+	for _, destination := range sortedKeys(state.Routes) {
+		expected := state.Routes[destination]
+		actual, ok := liveRoutes[destination]
+		switch {
+		case !ok:
+			add(destination, formatRoute(destination, expected), "", ChangeRemoved)
+		case actual != expected:
+			add(destination, formatRoute(destination, expected), formatRoute(destination, actual), ChangeModified)
+		}
+	}
+	if state.Managed {
+		var added []string
+		for destination := range liveRoutes {
+			if _, ok := state.Routes[destination]; !ok {
+				added = append(added, destination)
+			}
+		}
+		sort.Strings(added)
+		for _, destination := range added {
+			add(destination, "", formatRoute(destination, liveRoutes[destination]), ChangeAdded)
+		}
+		drifts = append(drifts, tagDrifts(state.Tags, routeTable.Tags)...)
+	}
+	return stampStateDrifts(drifts, entry, routeTable.RouteTableId), nil
+}
+
+// formatRoute formats a route for drift values, e.g. "0.0.0.0/0 via igw-123"
+func formatRoute(destination, target string) string {
+	return destination + " via " + target
+}
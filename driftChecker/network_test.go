@@ -0,0 +1,220 @@
+package driftChecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// compareFirst maps the state with the handler and compares its first entry with live
+func compareFirst(t *testing.T, handler ResourceHandler, tfState *terafm.TerraformState, live LiveResource) []Drift {
+	entries := handler.MapState(tfState)
+	require.NotEmpty(t, entries)
+	drifts, err := handler.Compare(context.Background(), live, entries[0], nil)
+	require.NoError(t, err)
+	return drifts
+}
+
+func TestVpcHandler_Compare(t *testing.T) {
+	handler := NewVpcHandler(nil, zap.NewNop())
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_vpc", Name: "main", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "vpc-1", "cidr_block": "10.0.0.0/16", "ipv6_cidr_block": "", "instance_tenancy": "default",
+			"tags": {"Name": "main", "env": "prod"}
+		}`)}},
+	}}
+
+	tests := []struct {
+		name     string
+		live     *awsm.AWSVpc
+		expected []Drift
+	}{
+		{
+			name: "no drift",
+			live: &awsm.AWSVpc{VpcId: "vpc-1", CidrBlock: "10.0.0.0/16", InstanceTenancy: "default",
+				Tags: map[string]string{"Name": "main", "env": "prod", "owner": "console"}},
+		},
+		{
+			name: "ipv6 block associated and tag changed",
+			live: &awsm.AWSVpc{VpcId: "vpc-1", CidrBlock: "10.0.0.0/16", Ipv6CidrBlock: "2600:1f18::/56", InstanceTenancy: "default",
+				Tags: map[string]string{"Name": "main", "env": "staging"}},
+			expected: []Drift{
+				{Address: "aws_vpc.main", ResourceID: "vpc-1", Attribute: "ipv6_cidr_block", Actual: "2600:1f18::/56", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand},
+				{Address: "aws_vpc.main", ResourceID: "vpc-1", Attribute: "tags.env", Expected: "prod", Actual: "staging", Source: SourceState, Severity: SeverityLow, Category: CategoryOutOfBand},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drifts := compareFirst(t, handler, tfState, LiveResource{ID: tt.live.VpcId, Tags: tt.live.Tags, Value: tt.live})
+			assert.Equal(t, tt.expected, drifts)
+		})
+	}
+}
+
+func TestSubnetHandler_Compare(t *testing.T) {
+	handler := NewSubnetHandler(nil, zap.NewNop())
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_subnet", Name: "private", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "subnet-1", "vpc_id": "vpc-1", "cidr_block": "10.0.1.0/24", "availability_zone": "us-east-1a",
+			"map_public_ip_on_launch": false, "tags": {"Name": "private"}
+		}`)}},
+	}}
+	live := &awsm.AWSSubnet{SubnetId: "subnet-1", VpcId: "vpc-1", CidrBlock: "10.0.1.0/24", AvailabilityZone: "us-east-1a",
+		MapPublicIpOnLaunch: true, Tags: map[string]string{"Name": "private"}}
+
+	drifts := compareFirst(t, handler, tfState, LiveResource{ID: live.SubnetId, Value: live})
+	assert.Equal(t, []Drift{
+		{Address: "aws_subnet.private", ResourceID: "subnet-1", Attribute: "map_public_ip_on_launch", Expected: "false", Actual: "true", Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand},
+	}, drifts)
+}
+
+func TestInternetGatewayHandler_Compare(t *testing.T) {
+	handler := NewInternetGatewayHandler(nil, zap.NewNop())
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_internet_gateway", Name: "gw", Instances: []terafm.Instance{decodeStateInstance(`{"id": "igw-1", "vpc_id": "vpc-1"}`)}},
+	}}
+	live := &awsm.AWSInternetGateway{InternetGatewayId: "igw-1"}
+
+	drifts := compareFirst(t, handler, tfState, LiveResource{ID: live.InternetGatewayId, Value: live})
+	assert.Equal(t, []Drift{
+		{Address: "aws_internet_gateway.gw", ResourceID: "igw-1", Attribute: "vpc_id", Expected: "vpc-1", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand},
+	}, drifts)
+}
+
+func TestRouteTableHandler_MapState(t *testing.T) {
+	handler := NewRouteTableHandler(nil, zap.NewNop())
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_route_table", Name: "public", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "rtb-public", "vpc_id": "vpc-1",
+			"route": [{"cidr_block": "0.0.0.0/0", "gateway_id": "igw-1", "nat_gateway_id": ""}],
+			"tags": {"Name": "public"}
+		}`)}},
+		{Type: "aws_route", Name: "peer", Instances: []terafm.Instance{decodeStateInstance(`{
+			"route_table_id": "rtb-public", "destination_cidr_block": "172.16.0.0/12", "vpc_peering_connection_id": "pcx-1"
+		}`)}},
+		{Type: "aws_route", Name: "main_nat", Instances: []terafm.Instance{decodeStateInstance(`{
+			"route_table_id": "rtb-main", "destination_cidr_block": "0.0.0.0/0", "nat_gateway_id": "nat-1"
+		}`)}},
+	}}
+
+	entries := handler.MapState(tfState)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "aws_route_table.public", entries[0].Address)
+	assert.Equal(t, &routeTableState{
+		Managed: true,
+		Routes:  map[string]string{"0.0.0.0/0": "igw-1", "172.16.0.0/12": "pcx-1"},
+		Tags:    map[string]string{"Name": "public"},
+	}, entries[0].Value)
+
+	assert.Equal(t, "aws_route.main_nat", entries[1].Address)
+	assert.Equal(t, "rtb-main", entries[1].ID)
+	assert.Equal(t, &routeTableState{Routes: map[string]string{"0.0.0.0/0": "nat-1"}}, entries[1].Value)
+}
+
+func TestRouteTableHandler_Compare(t *testing.T) {
+	handler := NewRouteTableHandler(nil, zap.NewNop())
+	local := awsm.Route{Destination: "10.0.0.0/16", Target: "local", Origin: "CreateRouteTable"}
+	propagated := awsm.Route{Destination: "192.168.0.0/16", Target: "vgw-1", Origin: "EnableVgwRoutePropagation"}
+	details := func(destination string) map[string]string {
+		return map[string]string{"destination": destination, "vpc_id": "vpc-1"}
+	}
+	stamp := func(d Drift) Drift {
+		d.Address = "aws_route_table.public"
+		d.ResourceID = "rtb-1"
+		d.Source = SourceState
+		d.Category = CategoryOutOfBand
+		d.Severity = SeverityHigh
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		state    *routeTableState
+		routes   []awsm.Route
+		expected []Drift
+	}{
+		{
+			name:   "no drift",
+			state:  &routeTableState{Managed: true, Routes: map[string]string{"0.0.0.0/0": "igw-1"}},
+			routes: []awsm.Route{local, propagated, {Destination: "0.0.0.0/0", Target: "igw-1", Origin: "CreateRoute"}},
+		},
+		{
+			name:   "default route pointed elsewhere",
+			state:  &routeTableState{Managed: true, Routes: map[string]string{"0.0.0.0/0": "igw-1"}},
+			routes: []awsm.Route{local, {Destination: "0.0.0.0/0", Target: "nat-1", Origin: "CreateRoute"}},
+			expected: []Drift{
+				stamp(Drift{Attribute: "route", Expected: "0.0.0.0/0 via igw-1", Actual: "0.0.0.0/0 via nat-1", Change: ChangeModified, Details: details("0.0.0.0/0")}),
+			},
+		},
+		{
+			name:   "route removed and another added",
+			state:  &routeTableState{Managed: true, Routes: map[string]string{"0.0.0.0/0": "igw-1"}},
+			routes: []awsm.Route{local, {Destination: "172.16.0.0/12", Target: "pcx-1", Origin: "CreateRoute"}},
+			expected: []Drift{
+				stamp(Drift{Attribute: "route", Expected: "0.0.0.0/0 via igw-1", Change: ChangeRemoved, Details: details("0.0.0.0/0")}),
+				stamp(Drift{Attribute: "route", Actual: "172.16.0.0/12 via pcx-1", Change: ChangeAdded, Details: details("172.16.0.0/12")}),
+			},
+		},
+		{
+			name:   "aws_route only table ignores other routes",
+			state:  &routeTableState{Routes: map[string]string{"0.0.0.0/0": "nat-1"}},
+			routes: []awsm.Route{local, {Destination: "0.0.0.0/0", Target: "nat-1"}, {Destination: "172.16.0.0/12", Target: "pcx-1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live := &awsm.AWSRouteTable{RouteTableId: "rtb-1", VpcId: "vpc-1", Routes: tt.routes}
+			entry := &StateEntry{Address: "aws_route_table.public", ID: "rtb-1", Value: tt.state}
+			drifts, err := handler.Compare(context.Background(), LiveResource{ID: "rtb-1", Value: live}, entry, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, drifts)
+		})
+	}
+}
+
+func TestDriftService_NetworkHandlers(t *testing.T) {
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_vpc", Name: "main", Instances: []terafm.Instance{decodeStateInstance(`{"id": "vpc-1", "cidr_block": "10.0.0.0/16"}`)}},
+		{Type: "aws_subnet", Name: "gone", Instances: []terafm.Instance{decodeStateInstance(`{"id": "subnet-gone", "vpc_id": "vpc-1"}`)}},
+		{Type: "aws_route", Name: "main_nat", Instances: []terafm.Instance{decodeStateInstance(`{
+			"route_table_id": "rtb-main", "destination_cidr_block": "0.0.0.0/0", "nat_gateway_id": "nat-1"
+		}`)}},
+	}}
+
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(tfState, nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+	awsClient := new(MockAWSClient)
+	awsClient.On("GetVpcs").Return([]*awsm.AWSVpc{
+		{VpcId: "vpc-1", CidrBlock: "10.0.0.0/16"},
+		{VpcId: "vpc-default", CidrBlock: "172.31.0.0/16", IsDefault: true},
+	}, nil)
+	awsClient.On("GetSubnets").Return([]*awsm.AWSSubnet{}, nil)
+	awsClient.On("GetRouteTables").Return([]*awsm.AWSRouteTable{
+		{RouteTableId: "rtb-main", VpcId: "vpc-1", Routes: []awsm.Route{{Destination: "0.0.0.0/0", Target: "nat-1"}}},
+	}, nil)
+
+	service := NewDriftService(awsClient, tfClient, zap.NewNop())
+	report, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.ResourcesChecked)
+	// A table known only from its aws_route resources is listed under that type
+	assert.Contains(t, report.Resources, CheckedResource{
+		Address: "aws_route.main_nat", Type: "aws_route", ResourceID: "rtb-main", Status: ResourceInSync,
+	})
+	assert.Equal(t, []Drift{
+		{Address: "aws_subnet.gone", ResourceID: "subnet-gone", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+	}, report.Drifts)
+	awsClient.AssertExpectations(t)
+}
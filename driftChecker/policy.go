@@ -0,0 +1,113 @@
+package driftChecker
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Statement keys whose value may be a single string or a list of strings
+var policyListKeys = map[string]bool{
+	"Action":      true,
+	"NotAction":   true,
+	"Resource":    true,
+	"NotResource": true,
+}
+
+// normalizePolicy rewrites an IAM policy document into a canonical form, so documents
+// that only differ in whitespace, key order, statement order, list order or a single
+// string written instead of a one-element list compare equal. An empty document
+// normalizes to "".
+func normalizePolicy(document string) (string, error) {
+	if strings.TrimSpace(document) == "" {
+		return "", nil
+	}
+	var policy map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return "", err
+	}
+
+	if statements, ok := policy["Statement"]; ok {
+		var canonical []interface{}
+		for _, statement := range asList(statements) {
+			if fields, ok := statement.(map[string]interface{}); ok {
+				statement = canonicalStatement(fields)
+			}
+			canonical = append(canonical, statement)
+		}
+		policy["Statement"] = sortedSet(canonical)
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// comparablePolicy returns the normalized form of a policy for comparison and drift
+// values. A document that isn't valid JSON is compared as written.
+func comparablePolicy(document string) string {
+	normalized, err := normalizePolicy(document)
+	if err != nil {
+		return strings.TrimSpace(document)
+	}
+	return normalized
+}
+
+// canonicalStatement turns the list-valued keys of a statement, the principals and the
+// condition values into sorted lists
+func canonicalStatement(statement map[string]interface{}) map[string]interface{} {
+	for key, value := range statement {
+		switch {
+		case policyListKeys[key]:
+			statement[key] = sortedSet(asList(value))
+		case key == "Principal" || key == "NotPrincipal":
+			if principals, ok := value.(map[string]interface{}); ok {
+				for kind, ids := range principals {
+					principals[kind] = sortedSet(asList(ids))
+				}
+			}
+		case key == "Condition":
+			if operators, ok := value.(map[string]interface{}); ok {
+				for _, conditions := range operators {
+					if keys, ok := conditions.(map[string]interface{}); ok {
+						for conditionKey, values := range keys {
+							keys[conditionKey] = sortedSet(asList(values))
+						}
+					}
+				}
+			}
+		}
+	}
+	return statement
+}
+
+// asList wraps a single value in a list
+func asList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{value}
+}
+
+// sortedSet sorts the values by their JSON encoding and drops duplicates
+func sortedSet(values []interface{}) []interface{} {
+	encoded := make(map[string]interface{}, len(values))
+	keys := make([]string, 0, len(values))
+	for _, value := range values {
+		data, _ := json.Marshal(value)
+		if _, ok := encoded[string(data)]; ok {
+			continue
+		}
+		encoded[string(data)] = value
+		keys = append(keys, string(data))
+	}
+	sort.Strings(keys)
+
+	result := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, encoded[key])
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package driftChecker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePolicy(t *testing.T) {
+	policy := `{
+		"Version": "2012-10-17",
+		"Statement": [
+			{"Sid": "Read", "Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::111:root", "arn:aws:iam::222:root"]},
+			 "Action": ["s3:ListBucket", "s3:GetObject"], "Resource": ["arn:aws:s3:::logs", "arn:aws:s3:::logs/*"]},
+			{"Sid": "TLS", "Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:s3:::logs/*",
+			 "Condition": {"Bool": {"aws:SecureTransport": "false"}}}
+		]
+	}`
+
+	tests := []struct {
+		name     string
+		document string
+		equal    bool
+	}{
+		{
+			name:     "whitespace and key order",
+			document: `{"Statement":[{"Resource":["arn:aws:s3:::logs","arn:aws:s3:::logs/*"],"Action":["s3:ListBucket","s3:GetObject"],"Principal":{"AWS":["arn:aws:iam::111:root","arn:aws:iam::222:root"]},"Effect":"Allow","Sid":"Read"},{"Sid":"TLS","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::logs/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}],"Version":"2012-10-17"}`,
+			equal:    true,
+		},
+		{
+			name: "statement and list order, single strings as lists",
+			document: `{"Version": "2012-10-17", "Statement": [
+				{"Sid": "TLS", "Effect": "Deny", "Principal": "*", "Action": ["s3:*"], "Resource": ["arn:aws:s3:::logs/*"],
+				 "Condition": {"Bool": {"aws:SecureTransport": ["false"]}}},
+				{"Sid": "Read", "Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::222:root", "arn:aws:iam::111:root"]},
+				 "Action": ["s3:GetObject", "s3:ListBucket", "s3:GetObject"], "Resource": ["arn:aws:s3:::logs/*", "arn:aws:s3:::logs"]}
+			]}`,
+			equal: true,
+		},
+		{
+			name: "action added",
+			document: `{"Version": "2012-10-17", "Statement": [
+				{"Sid": "Read", "Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::111:root", "arn:aws:iam::222:root"]},
+				 "Action": ["s3:ListBucket", "s3:GetObject", "s3:PutObject"], "Resource": ["arn:aws:s3:::logs", "arn:aws:s3:::logs/*"]},
+				{"Sid": "TLS", "Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:s3:::logs/*",
+				 "Condition": {"Bool": {"aws:SecureTransport": "false"}}}
+			]}`,
+			equal: false,
+		},
+	}
+
+	expected, err := normalizePolicy(policy)
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := normalizePolicy(tt.document)
+			require.NoError(t, err)
+			assert.Equal(t, tt.equal, normalized == expected)
+		})
+	}
+}
+
+func TestNormalizePolicy_SingleStatement(t *testing.T) {
+	single, err := normalizePolicy(`{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}}`)
+	require.NoError(t, err)
+	list, err := normalizePolicy(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["*"]}]}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, list, single)
+	assert.Equal(t, `{"Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`, single)
+}
+
+func TestNormalizePolicy_Invalid(t *testing.T) {
+	empty, err := normalizePolicy("  ")
+	require.NoError(t, err)
+	assert.Equal(t, "", empty)
+
+	_, err = normalizePolicy(`{"Statement": [`)
+	assert.Error(t, err)
+	assert.Equal(t, `{"Statement": [`, comparablePolicy(` {"Statement": [ `))
+}
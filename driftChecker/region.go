@@ -0,0 +1,202 @@
+package driftChecker
+
+import (
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// zoneRegion matches the region prefix of an availability zone name, e.g. us-east-1
+// in us-east-1a or us-west-2 in the local zone us-west-2-lax-1a
+var zoneRegion = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+`)
+
+// regionScope is a region scanned by the service, with the handlers bound to its
+// clients
+type regionScope struct {
+	name     string
+	registry *Registry
+}
+
+// WithAccount names the AWS account the service checks. Its drifts, report and logs
+// carry the name. Pass it before the options that create handlers so that their logs
+// carry it too.
+func WithAccount(name string) Option {
+	return func(s *DriftService) {
+		s.account = name
+		s.logger = s.logger.With(zap.String("account", name))
+	}
+}
+
+// WithHomeRegion names the region of the clients passed to NewDriftService. State
+// resources whose region can't be told are checked there.
+func WithHomeRegion(region string) Option {
+	return func(s *DriftService) {
+		s.region = region
+	}
+}
+
+// WithRegion scans an additional region with the built-in handlers bound to its AWS
+// client and the given regional handlers, e.g. an S3 handler for the region. Handlers
+// of global services such as IAM are registered once, with WithHandler.
+func WithRegion(region string, awsClient AWSClient, handlers ...ResourceHandler) Option {
+	return func(s *DriftService) {
+		registry := newBuiltinRegistry(awsClient, s.logger)
+		for _, handler := range handlers {
+			registry.Register(handler)
+		}
+		s.regions = append(s.regions, &regionScope{name: region, registry: registry})
+	}
+}
+
+// WithProviderRegions maps Terraform provider aliases to the region they target, e.g.
+// eu for resources of provider["registry.terraform.io/hashicorp/aws"].eu
+func WithProviderRegions(aliases map[string]string) Option {
+	return func(s *DriftService) {
+		s.providerRegions = aliases
+	}
+}
+
+// scopes returns the scanned regions, the home region first
+func (s *DriftService) scopes() []*regionScope {
+	return append([]*regionScope{{name: s.region, registry: s.registry}}, s.regions...)
+}
+
+// splitState routes the instances of the state to the scanned regions and returns a
+// state per region holding only its resources. Instances routed to a region that
+// isn't scanned are logged and skipped.
+func (s *DriftService) splitState(tfState *terafm.TerraformState, scopes []*regionScope) map[string]*terafm.TerraformState {
+	states := make(map[string]*terafm.TerraformState, len(scopes))
+	if tfState == nil {
+		return states
+	}
+	for _, scope := range scopes {
+		regional := *tfState
+		regional.Resources = nil
+		states[scope.name] = &regional
+	}
+
+	router := newRegionRouter(tfState, s.region, s.providerRegions, s.registry)
+	for i := range tfState.Resources {
+		resource := &tfState.Resources[i]
+		byRegion := make(map[string][]terafm.Instance)
+		for j := range resource.Instances {
+			instance := &resource.Instances[j]
+			region := router.regionOf(resource, instance)
+			if _, ok := states[region]; !ok {
+				s.logger.Warn("Terraform resource is in a region that isn't scanned, skipping",
+					zap.String("operation", "region_routing"),
+					zap.String("address", resource.InstanceAddress(instance)),
+					zap.String("region", region),
+				)
+				continue
+			}
+			byRegion[region] = append(byRegion[region], *instance)
+		}
+		for region, instances := range byRegion {
+			regional := *resource
+			regional.Instances = instances
+			states[region].Resources = append(states[region].Resources, regional)
+		}
+	}
+	return states
+}
+
+// regionRouter tells the region of state resources. Resources of global services,
+// handled by the home registry's global handlers, stay in the default region. Otherwise
+// an instance's own attributes win; instances without a region hint follow the
+// provider alias mapping, then the region learned from the other instances of their
+// provider, then the default region.
+type regionRouter struct {
+	defaultRegion string
+	aliases       map[string]string
+	learned       map[string]string
+	home          *Registry
+}
+
+// newRegionRouter creates a router, learning the region of each provider from the
+// instances of the state that record one. home is the registry of the default region.
+func newRegionRouter(tfState *terafm.TerraformState, defaultRegion string, aliases map[string]string, home *Registry) *regionRouter {
+	r := &regionRouter{
+		defaultRegion: defaultRegion,
+		aliases:       aliases,
+		learned:       make(map[string]string),
+		home:          home,
+	}
+	for i := range tfState.Resources {
+		resource := &tfState.Resources[i]
+		if _, ok := r.learned[resource.Provider]; ok || r.global(resource.Type) {
+			continue
+		}
+		for j := range resource.Instances {
+			if region := instanceRegion(&resource.Instances[j]); region != "" {
+				r.learned[resource.Provider] = region
+				break
+			}
+		}
+	}
+	return r
+}
+
+// regionOf returns the region of a resource instance
+func (r *regionRouter) regionOf(resource *terafm.Resource, instance *terafm.Instance) string {
+	if r.global(resource.Type) {
+		return r.defaultRegion
+	}
+	if region := instanceRegion(instance); region != "" {
+		return region
+	}
+	if alias := providerAlias(resource.Provider); alias != "" {
+		if region, ok := r.aliases[alias]; ok {
+			return region
+		}
+	}
+	if region, ok := r.learned[resource.Provider]; ok {
+		return region
+	}
+	return r.defaultRegion
+}
+
+// global reports whether a resource type belongs to a global service
+func (r *regionRouter) global(resourceType string) bool {
+	return r.home != nil && r.home.IsGlobal(resourceType)
+}
+
+// instanceRegion returns the region recorded in an instance's region, arn or
+// availability_zone attribute, or "" when it has none. IAM ARNs have no region.
+func instanceRegion(instance *terafm.Instance) string {
+	if region, ok := instance.Attribute("region").Primitive(); ok && region != "" {
+		return region
+	}
+	if arn, ok := instance.Attribute("arn").Primitive(); ok {
+		if region := regionFromARN(arn); region != "" {
+			return region
+		}
+	}
+	if zone, ok := instance.Attribute("availability_zone").Primitive(); ok {
+		return zoneRegion.FindString(zone)
+	}
+	return ""
+}
+
+// regionFromARN returns the region field of an ARN, e.g. us-east-1 in
+// arn:aws:ec2:us-east-1:123456789012:instance/i-0abc
+func regionFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[3]
+}
+
+// providerAlias returns the alias of a state provider address, e.g. eu in
+// provider["registry.terraform.io/hashicorp/aws"].eu, or "" for a default provider
+func providerAlias(provider string) string {
+	i := strings.LastIndex(provider, "]")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimPrefix(provider[i+1:], ".")
+}
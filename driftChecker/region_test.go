@@ -0,0 +1,142 @@
+package driftChecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+const (
+	defaultProvider = `provider["registry.terraform.io/hashicorp/aws"]`
+	euProvider      = `provider["registry.terraform.io/hashicorp/aws"].eu`
+	apacProvider    = `provider["registry.terraform.io/hashicorp/aws"].apac`
+)
+
+func TestRegionRouter(t *testing.T) {
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_instance", Name: "web", Provider: apacProvider, Instances: []terafm.Instance{
+			decodeStateInstance(`{"id": "i-1", "availability_zone": "ap-southeast-2b"}`),
+		}},
+	}}
+	home := NewRegistry(NewIAMRoleHandler(new(MockIAMClient), zap.NewNop()))
+	router := newRegionRouter(tfState, "us-east-1", map[string]string{"eu": "eu-west-1"}, home)
+
+	tests := []struct {
+		name       string
+		provider   string
+		attributes string
+		expected   string
+	}{
+		{"region attribute", defaultProvider, `{"region": "eu-central-1", "arn": "arn:aws:s3:::logs"}`, "eu-central-1"},
+		{"ARN", defaultProvider, `{"arn": "arn:aws:ec2:eu-north-1:123:vpc/vpc-1"}`, "eu-north-1"},
+		{"availability zone", defaultProvider, `{"availability_zone": "us-west-2c"}`, "us-west-2"},
+		{"local zone", defaultProvider, `{"availability_zone": "us-west-2-lax-1a"}`, "us-west-2"},
+		{"global ARN falls back to the provider alias", euProvider, `{"arn": "arn:aws:iam::123:role/web"}`, "eu-west-1"},
+		{"region learned from the provider's other instances", apacProvider, `{"id": "sg-1"}`, "ap-southeast-2"},
+		{"default provider without a hint", defaultProvider, `{"id": "sg-2"}`, "us-east-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &terafm.Resource{Provider: tt.provider}
+			instance := decodeStateInstance(tt.attributes)
+			assert.Equal(t, tt.expected, router.regionOf(resource, &instance))
+		})
+	}
+
+	// Resources of global services stay in the default region, whatever their provider
+	for _, provider := range []string{euProvider, apacProvider} {
+		resource := &terafm.Resource{Type: "aws_iam_role", Provider: provider}
+		instance := decodeStateInstance(`{"id": "web", "arn": "arn:aws:iam::123:role/web"}`)
+		assert.Equal(t, "us-east-1", router.regionOf(resource, &instance), provider)
+	}
+}
+
+func TestProviderAlias(t *testing.T) {
+	assert.Equal(t, "eu", providerAlias(euProvider))
+	assert.Equal(t, "", providerAlias(defaultProvider))
+	assert.Equal(t, "", providerAlias(""))
+}
+
+func TestDriftService_Regions(t *testing.T) {
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_vpc", Name: "main", Provider: defaultProvider, Instances: []terafm.Instance{
+			decodeStateInstance(`{"id": "vpc-1", "cidr_block": "10.0.0.0/16", "arn": "arn:aws:ec2:us-east-1:123:vpc/vpc-1"}`),
+		}},
+		{Type: "aws_vpc", Name: "eu", Provider: euProvider, Instances: []terafm.Instance{
+			decodeStateInstance(`{"id": "vpc-eu", "cidr_block": "10.1.0.0/16"}`),
+		}},
+		{Type: "aws_vpc", Name: "sydney", Provider: defaultProvider, Instances: []terafm.Instance{
+			decodeStateInstance(`{"id": "vpc-syd", "cidr_block": "10.2.0.0/16", "arn": "arn:aws:ec2:ap-southeast-2:123:vpc/vpc-syd"}`),
+		}},
+	}}
+
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(tfState, nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+	usClient := new(MockAWSClient)
+	usClient.On("GetVpcs").Return([]*awsm.AWSVpc{}, nil)
+	euClient := new(MockAWSClient)
+	euClient.On("GetVpcs").Return([]*awsm.AWSVpc{{VpcId: "vpc-eu", CidrBlock: "10.9.0.0/16"}}, nil)
+
+	service := NewDriftService(usClient, tfClient, zap.NewNop(),
+		WithAccount("prod"),
+		WithHomeRegion("us-east-1"),
+		WithRegion("eu-west-1", euClient),
+		WithProviderRegions(map[string]string{"eu": "eu-west-1"}),
+	)
+	report, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	assert.Equal(t, "prod", report.Account)
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, report.Regions)
+	assert.Equal(t, 1, report.ResourcesChecked)
+	assert.Equal(t, []Drift{
+		{Address: "aws_vpc.eu", ResourceID: "vpc-eu", Account: "prod", Region: "eu-west-1", Attribute: "cidr_block", Expected: "10.1.0.0/16", Actual: "10.9.0.0/16", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand},
+		{Address: "aws_vpc.main", ResourceID: "vpc-1", Account: "prod", Region: "us-east-1", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+	}, report.Drifts)
+	usClient.AssertExpectations(t)
+	euClient.AssertExpectations(t)
+}
+
+func TestDriftService_GlobalServiceUnderAliasedProvider(t *testing.T) {
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_iam_role", Name: "eu", Provider: euProvider, Instances: []terafm.Instance{
+			decodeStateInstance(`{"id": "eu-app", "name": "eu-app", "arn": "arn:aws:iam::123:role/eu-app"}`),
+		}},
+		{Type: "aws_vpc", Name: "eu", Provider: euProvider, Instances: []terafm.Instance{
+			decodeStateInstance(`{"id": "vpc-eu", "cidr_block": "10.1.0.0/16"}`),
+		}},
+	}}
+
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(tfState, nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+	usClient := new(MockAWSClient)
+	euClient := new(MockAWSClient)
+	euClient.On("GetVpcs").Return([]*awsm.AWSVpc{{VpcId: "vpc-eu", CidrBlock: "10.1.0.0/16"}}, nil)
+	iamClient := new(MockIAMClient)
+	iamClient.On("ListRoles").Return([]*awsm.AWSRole{}, nil)
+
+	service := NewDriftService(usClient, tfClient, zap.NewNop(),
+		WithHomeRegion("us-east-1"),
+		WithRegion("eu-west-1", euClient),
+		WithProviderRegions(map[string]string{"eu": "eu-west-1"}),
+		WithHandler(NewIAMRoleHandler(iamClient, zap.NewNop())),
+	)
+	report, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	// The role is checked once, in the home region where IAM is registered
+	assert.Equal(t, []Drift{
+		{Address: "aws_iam_role.eu", ResourceID: "eu-app", Region: "us-east-1", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+	}, report.Drifts)
+	iamClient.AssertExpectations(t)
+	euClient.AssertExpectations(t)
+}
@@ -0,0 +1,190 @@
+package driftChecker
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// ResourceHandler plugs a Terraform resource type into the drift check. The service
+// runs a handler whenever the state has resources of one of its types, pairs the live
+// resources it fetches with its state entries by AWS ID and compares each pair.
+type ResourceHandler interface {
+	// Types returns the Terraform resource types the handler reads from the state. The
+	// first is the type it compares, e.g. aws_security_group; any others are companion
+	// types merged into it, e.g. aws_security_group_rule.
+	Types() []string
+	// FetchLive lists the live AWS resources of the type
+	FetchLive(ctx context.Context) ([]LiveResource, error)
+	// MapState returns the state entries of the type. Entries without an ID can't be
+	// paired and are only used for their address.
+	MapState(tfState *terafm.TerraformState) []*StateEntry
+	// Compare returns the drifts between a live resource and its state entry, and the
+	// HCL config where the handler supports it. The drifts carry the entry's address
+	// and the live resource's ID.
+	Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error)
+	// ReportsUnmanaged reports whether live resources without a state entry are drift
+	ReportsUnmanaged() bool
+}
+
+// GlobalHandler is implemented by the handlers of global services such as IAM. Their
+// resources are checked in the home region, whatever region their provider targets.
+type GlobalHandler interface {
+	ResourceHandler
+	Global() bool
+}
+
+// LiveResource is a live AWS resource fetched by a handler. Value holds the handler's
+// model, e.g. *awsm.AWSInstance.
+type LiveResource struct {
+	ID    string
+	Tags  map[string]string
+	Value interface{}
+}
+
+// StateEntry is a resource instance of the Terraform state mapped by a handler. Value
+// holds handler-specific data, e.g. the merged rules of a security group.
+type StateEntry struct {
+	Address  string
+	ID       string
+	Resource *terafm.Resource
+	Instance *terafm.Instance
+	Value    interface{}
+}
+
+// mapStateEntries returns an entry per managed instance of the resource type. decode
+// returns the instance's AWS ID and the handler's view of its attributes; instances
+// that fail to decode are logged and skipped.
+func mapStateEntries(tfState *terafm.TerraformState, resourceType string,
+	decode func(instance *terafm.Instance) (string, interface{}, error)) []*StateEntry {
+	var entries []*StateEntry
+	if tfState == nil {
+		return entries
+	}
+	for i := range tfState.Resources {
+		resource := &tfState.Resources[i]
+		if !resource.IsManaged() || resource.Type != resourceType {
+			continue
+		}
+		for j := range resource.Instances {
+			instance := &resource.Instances[j]
+			address := resource.InstanceAddress(instance)
+			id, value, err := decode(instance)
+			if err != nil {
+				zap.L().Warn("Failed to decode Terraform state, skipping",
+					zap.String("function", "mapStateEntries"),
+					zap.String("operation", "state_decode"),
+					zap.String("address", address),
+					zap.Error(err),
+				)
+				continue
+			}
+			entries = append(entries, &StateEntry{
+				Address:  address,
+				ID:       id,
+				Resource: resource,
+				Instance: instance,
+				Value:    value,
+			})
+		}
+	}
+	return entries
+}
+
+// stampStateDrifts fills in the resource of drifts found against the state
+func stampStateDrifts(drifts []Drift, entry *StateEntry, resourceID string) []Drift {
+	for i := range drifts {
+		drifts[i].Address = entry.Address
+		drifts[i].ResourceID = resourceID
+		drifts[i].Source = SourceState
+		drifts[i].Category = CategoryOutOfBand
+	}
+	return drifts
+}
+
+// Registry holds the resource handlers by Terraform type
+type Registry struct {
+	handlers []ResourceHandler
+	byType   map[string]ResourceHandler
+}
+
+// NewRegistry creates a registry with the given handlers
+func NewRegistry(handlers ...ResourceHandler) *Registry {
+	r := &Registry{byType: make(map[string]ResourceHandler)}
+	for _, handler := range handlers {
+		r.Register(handler)
+	}
+	return r
+}
+
+// Register adds a handler for its types, replacing the handlers already registered
+// for any of them
+func (r *Registry) Register(handler ResourceHandler) {
+	replaced := make(map[ResourceHandler]bool)
+	for _, resourceType := range handler.Types() {
+		if existing, ok := r.byType[resourceType]; ok {
+			replaced[existing] = true
+		}
+	}
+
+	handlers := r.handlers[:0]
+	for _, existing := range r.handlers {
+		if !replaced[existing] {
+			handlers = append(handlers, existing)
+		}
+	}
+	r.handlers = append(handlers, handler)
+
+	for resourceType, existing := range r.byType {
+		if replaced[existing] {
+			delete(r.byType, resourceType)
+		}
+	}
+	for _, resourceType := range handler.Types() {
+		r.byType[resourceType] = handler
+	}
+}
+
+// Handler returns the handler registered for a Terraform type, or nil
+func (r *Registry) Handler(resourceType string) ResourceHandler {
+	return r.byType[resourceType]
+}
+
+// IsGlobal reports whether a Terraform type is handled by the handler of a global
+// service
+func (r *Registry) IsGlobal(resourceType string) bool {
+	handler, ok := r.byType[resourceType].(GlobalHandler)
+	return ok && handler.Global()
+}
+
+// handlersFor returns the handlers of the managed resource types found in the state,
+// in registration order
+func (r *Registry) handlersFor(tfState *terafm.TerraformState) []ResourceHandler {
+	if tfState == nil {
+		return nil
+	}
+	found := make(map[ResourceHandler]bool)
+	for i := range tfState.Resources {
+		resource := &tfState.Resources[i]
+		if handler, ok := r.byType[resource.Type]; ok && resource.IsManaged() {
+			found[handler] = true
+		}
+	}
+
+	var handlers []ResourceHandler
+	for _, handler := range r.handlers {
+		if found[handler] {
+			handlers = append(handlers, handler)
+		}
+	}
+	return handlers
+}
+
+// WithHandler registers an additional resource handler, or replaces a built-in one
+func WithHandler(handler ResourceHandler) Option {
+	return func(s *DriftService) {
+		s.registry.Register(handler)
+	}
+}
@@ -0,0 +1,121 @@
+package driftChecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// stubHandler is a ResourceHandler over a fixed set of live and state resources
+type stubHandler struct {
+	types     []string
+	live      []LiveResource
+	unmanaged bool
+}
+
+func (h *stubHandler) Types() []string { return h.types }
+
+func (h *stubHandler) ReportsUnmanaged() bool { return h.unmanaged }
+
+func (h *stubHandler) FetchLive(ctx context.Context) ([]LiveResource, error) { return h.live, nil }
+
+func (h *stubHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	var entries []*StateEntry
+	for i := range tfState.Resources {
+		resource := &tfState.Resources[i]
+		if resource.Type != h.types[0] {
+			continue
+		}
+		for j := range resource.Instances {
+			instance := &resource.Instances[j]
+			entries = append(entries, &StateEntry{
+				Address:  resource.InstanceAddress(instance),
+				ID:       instance.Attributes.InstanceID,
+				Resource: resource,
+				Instance: instance,
+			})
+		}
+	}
+	return entries
+}
+
+func (h *stubHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	expected := entry.Instance.Attributes.Tags["Name"]
+	if live.Tags["Name"] == expected {
+		return nil, nil
+	}
+	drift := attributeDrift("tags.Name", expected, live.Tags["Name"])
+	drift.Address = entry.Address
+	drift.ResourceID = live.ID
+	drift.Source = SourceState
+	drift.Category = CategoryOutOfBand
+	return []Drift{drift}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	instances := NewInstanceHandler(nil, zap.NewNop())
+	groups := NewSecurityGroupHandler(nil, zap.NewNop())
+	registry := NewRegistry(instances, groups)
+
+	assert.Equal(t, instances, registry.Handler("aws_instance"))
+	assert.Equal(t, groups, registry.Handler("aws_security_group_rule"))
+	assert.Nil(t, registry.Handler("aws_s3_bucket"))
+
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_vpc_security_group_ingress_rule", Name: "ssh"},
+		{Type: "aws_instance", Name: "web"},
+		{Mode: terafm.ModeData, Type: "aws_s3_bucket", Name: "logs"},
+		{Type: "aws_s3_bucket", Name: "assets"},
+	}}
+	assert.Equal(t, []ResourceHandler{instances, groups}, registry.handlersFor(tfState))
+	assert.Empty(t, registry.handlersFor(nil))
+
+	// A handler for an already registered type replaces the built-in one
+	custom := &stubHandler{types: []string{"aws_instance"}}
+	registry.Register(custom)
+	assert.Equal(t, custom, registry.Handler("aws_instance"))
+	assert.Equal(t, []ResourceHandler{groups, custom}, registry.handlersFor(tfState))
+}
+
+func TestDriftService_CustomHandler(t *testing.T) {
+	handler := &stubHandler{
+		types:     []string{"aws_s3_bucket"},
+		unmanaged: true,
+		live: []LiveResource{
+			{ID: "assets", Tags: map[string]string{"Name": "assets-renamed"}},
+			{ID: "scratch", Tags: map[string]string{"Name": "scratch"}},
+		},
+	}
+	tfState := &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_s3_bucket", Name: "assets", Instances: []terafm.Instance{
+			{Attributes: terafm.InstanceAttributes{InstanceID: "assets", Tags: map[string]string{"Name": "assets"}}},
+		}},
+		{Type: "aws_s3_bucket", Name: "logs", Instances: []terafm.Instance{
+			{Attributes: terafm.InstanceAttributes{InstanceID: "logs"}},
+		}},
+	}}
+
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(tfState, nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+	awsClient := new(MockAWSClient)
+
+	service := NewDriftService(awsClient, tfClient, zap.NewNop(), WithHandler(handler))
+	report, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.ResourcesChecked)
+	assert.Equal(t, []Drift{
+		{ResourceID: "scratch", ResourceType: "aws_s3_bucket", Source: SourceState, Severity: SeverityMedium, Category: CategoryUnmanaged},
+		{Address: "aws_s3_bucket.assets", ResourceID: "assets", Attribute: "tags.Name", Expected: "assets", Actual: "assets-renamed", Source: SourceState, Severity: SeverityLow, Category: CategoryOutOfBand},
+		{Address: "aws_s3_bucket.logs", ResourceID: "logs", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+	}, report.Drifts)
+
+	// The built-in handlers aren't run without their resource types in the state
+	awsClient.AssertExpectations(t)
+}
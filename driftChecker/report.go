@@ -0,0 +1,160 @@
+package driftChecker
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/errors"
+)
+
+// ReportSchemaVersion is the version of the JSON report schema. It is bumped whenever
+// a field is removed or changes meaning; new fields don't bump it.
+const ReportSchemaVersion = 1
+
+// Report is the machine-readable outcome of a run: the drift report of every account
+// checked and the errors of the accounts whose check failed
+type Report struct {
+	SchemaVersion int            `json:"schema_version"`
+	StartedAt     time.Time      `json:"started_at"`
+	CompletedAt   time.Time      `json:"completed_at"`
+	Accounts      []*DriftReport `json:"accounts"`
+	Errors        []ReportError  `json:"errors"`
+}
+
+// ReportError is the failure of the check of an account
+type ReportError struct {
+	Account string `json:"account,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewReport starts an empty report of a run started at startedAt
+func NewReport(startedAt time.Time) *Report {
+	return &Report{
+		SchemaVersion: ReportSchemaVersion,
+		StartedAt:     startedAt.UTC(),
+		Accounts:      []*DriftReport{},
+		Errors:        []ReportError{},
+	}
+}
+
+// Add adds the drift report of an account
+func (r *Report) Add(report *DriftReport) {
+	r.Accounts = append(r.Accounts, report)
+}
+
+// AddError records the failed check of an account
+func (r *Report) AddError(account string, err error) {
+	r.Errors = append(r.Errors, ReportError{Account: account, Message: err.Error()})
+}
+
+// Complete stamps the completion time of the run
+func (r *Report) Complete() {
+	r.CompletedAt = time.Now().UTC()
+}
+
+// HasDrift reports whether drift was found in any account
+func (r *Report) HasDrift() bool {
+	for _, report := range r.Accounts {
+		if report.HasDrift() {
+			return true
+		}
+	}
+	return false
+}
+
+// Partial reports whether the check of any account failed or didn't finish
+func (r *Report) Partial() bool {
+	if len(r.Errors) > 0 {
+		return true
+	}
+	for _, report := range r.Accounts {
+		if report.Partial {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode writes the report as indented JSON
+func (r *Report) Encode(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteReport writes the report to the file at path, or to stdout when path is "-".
+// The file is replaced atomically, so readers never see a report being written, and
+// gets mode 0644, so other users and log shippers can read it.
+func WriteReport(report *Report, path string) error {
+	if path == "-" {
+		return report.Encode(os.Stdout)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return reportWriteError(path, err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := report.Encode(file); err != nil {
+		file.Close()
+		return reportWriteError(path, err)
+	}
+	// CreateTemp creates the file readable by its owner only
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		return reportWriteError(path, err)
+	}
+	if err := file.Close(); err != nil {
+		return reportWriteError(path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return reportWriteError(path, err)
+	}
+	return nil
+}
+
+// reportWriteError wraps a failure to write the report file
+func reportWriteError(path string, err error) error {
+	return errors.New(errors.ErrDriftChecker, "Failed to write drift report",
+		map[string]interface{}{
+			"operation": "report_write",
+			"path":      path,
+		}, err)
+}
+
+// WithReportPath makes RunLoop write the JSON report of every check to the file at
+// path, or to stdout when path is "-"
+func WithReportPath(path string) Option {
+	return func(s *DriftService) {
+		s.reportPath = path
+	}
+}
+
+// writeReport writes the report of a check of the loop, when a report path is set. A
+// report that can't be written is logged; it doesn't fail the check.
+func (s *DriftService) writeReport(startedAt time.Time, driftReport *DriftReport, checkErr error) {
+	if s.reportPath == "" {
+		return
+	}
+	report := NewReport(startedAt)
+	if checkErr != nil {
+		report.AddError(s.account, checkErr)
+	} else {
+		report.Add(driftReport)
+	}
+	report.Complete()
+
+	if err := WriteReport(report, s.reportPath); err != nil {
+		s.logger.Warn("Failed to write drift report",
+			zap.String("operation", "report_write"),
+			zap.String("path", s.reportPath),
+			zap.Error(err),
+		)
+	}
+}
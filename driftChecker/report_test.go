@@ -0,0 +1,107 @@
+package driftChecker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+func TestWriteReport(t *testing.T) {
+	tfState := &terafm.TerraformState{
+		Serial:           42,
+		Lineage:          "3f2a9c1e-lineage",
+		TerraformVersion: "1.7.5",
+		Resources: []terafm.Resource{
+			{Type: "aws_vpc", Name: "main", Instances: []terafm.Instance{
+				decodeStateInstance(`{"id": "vpc-1", "cidr_block": "10.0.0.0/16"}`),
+			}},
+			{Type: "aws_vpc", Name: "edge", Instances: []terafm.Instance{
+				decodeStateInstance(`{"id": "vpc-2", "cidr_block": "10.1.0.0/16"}`),
+			}},
+			{Type: "aws_vpc", Name: "legacy", Instances: []terafm.Instance{
+				decodeStateInstance(`{"id": "vpc-3", "cidr_block": "10.2.0.0/16"}`),
+			}},
+		},
+	}
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(tfState, nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+	awsClient := new(MockAWSClient)
+	awsClient.On("GetVpcs").Return([]*awsm.AWSVpc{
+		{VpcId: "vpc-1", CidrBlock: "10.0.0.0/16"},
+		{VpcId: "vpc-2", CidrBlock: "10.9.0.0/16"},
+	}, nil)
+
+	service := NewDriftService(awsClient, tfClient, zap.NewNop(), WithAccount("prod"), WithHomeRegion("us-east-1"))
+	driftReport, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	report := NewReport(time.Now())
+	report.Add(driftReport)
+	report.AddError("staging", errors.New("access denied"))
+	report.Complete()
+	assert.True(t, report.HasDrift())
+	assert.True(t, report.Partial())
+
+	path := filepath.Join(t.TempDir(), "drift-report.json")
+	require.NoError(t, WriteReport(report, path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var decoded struct {
+		SchemaVersion int `json:"schema_version"`
+		Accounts      []struct {
+			Account   string            `json:"account"`
+			Regions   []string          `json:"regions"`
+			State     StateMetadata     `json:"state"`
+			Resources []CheckedResource `json:"resources"`
+			Drifts    []struct {
+				Address   string `json:"address"`
+				Attribute string `json:"attribute"`
+				Expected  string `json:"expected"`
+				Actual    string `json:"actual"`
+				Source    string `json:"source"`
+			} `json:"drifts"`
+		} `json:"accounts"`
+		Errors []ReportError `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	assert.Equal(t, ReportSchemaVersion, decoded.SchemaVersion)
+	require.Len(t, decoded.Accounts, 1)
+	account := decoded.Accounts[0]
+	assert.Equal(t, "prod", account.Account)
+	assert.Equal(t, []string{"us-east-1"}, account.Regions)
+	assert.Equal(t, StateMetadata{Path: "terraform.tfstate", Serial: 42, Lineage: "3f2a9c1e-lineage", TerraformVersion: "1.7.5"}, account.State)
+	assert.Equal(t, []CheckedResource{
+		{Address: "aws_vpc.edge", Type: "aws_vpc", ResourceID: "vpc-2", Region: "us-east-1", Status: ResourceDrifted},
+		{Address: "aws_vpc.legacy", Type: "aws_vpc", ResourceID: "vpc-3", Region: "us-east-1", Status: ResourceMissing},
+		{Address: "aws_vpc.main", Type: "aws_vpc", ResourceID: "vpc-1", Region: "us-east-1", Status: ResourceInSync},
+	}, account.Resources)
+	require.Len(t, account.Drifts, 2)
+	assert.Equal(t, "aws_vpc.edge", account.Drifts[0].Address)
+	assert.Equal(t, "cidr_block", account.Drifts[0].Attribute)
+	assert.Equal(t, "10.1.0.0/16", account.Drifts[0].Expected)
+	assert.Equal(t, "10.9.0.0/16", account.Drifts[0].Actual)
+	assert.Equal(t, "state", account.Drifts[0].Source)
+	assert.Equal(t, []ReportError{{Account: "staging", Message: "access denied"}}, decoded.Errors)
+
+	// Only the report is left in the directory, the temporary file is renamed over it
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
@@ -0,0 +1,448 @@
+package driftChecker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// bucketState is everything the Terraform state records about one bucket, merged
+// from the aws_s3_bucket and its companion resources. Settings the state doesn't
+// record are nil or empty and left unchecked. Sources maps a top-level attribute to
+// the address of the companion resource that manages it.
+type bucketState struct {
+	Managed           bool
+	Versioning        *bucketVersioning
+	Encryption        *awsm.BucketEncryptionRule
+	PublicAccessBlock *awsm.BucketPublicAccessBlock
+	// LifecycleRules maps each rule ID to its canonical form; nil when the state has
+	// no lifecycle configuration
+	LifecycleRules map[string]string
+	Policy         string
+	Tags           map[string]string
+	Sources        map[string]string
+}
+
+// bucketVersioning is the versioning of a bucket as Terraform records it
+type bucketVersioning struct {
+	Enabled   bool
+	MFADelete bool
+}
+
+// S3BucketHandler compares aws_s3_bucket resources, merged with their companion
+// resources, with the live buckets. Unmanaged buckets are reported; as FetchLive only
+// lists bucket names, the unmanaged filter matches them by name alone.
+type S3BucketHandler struct {
+	client S3Client
+	logger *zap.Logger
+}
+
+// NewS3BucketHandler creates the aws_s3_bucket handler
+func NewS3BucketHandler(client S3Client, logger *zap.Logger) *S3BucketHandler {
+	return &S3BucketHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *S3BucketHandler) Types() []string {
+	return []string{
+		"aws_s3_bucket",
+		"aws_s3_bucket_versioning",
+		"aws_s3_bucket_server_side_encryption_configuration",
+		"aws_s3_bucket_public_access_block",
+		"aws_s3_bucket_lifecycle_configuration",
+		"aws_s3_bucket_policy",
+	}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *S3BucketHandler) ReportsUnmanaged() bool {
+	return true
+}
+
+// FetchLive lists the live buckets. Their configuration is read by Compare, only for
+// the buckets Terraform manages.
+func (h *S3BucketHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	names, err := h.client.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]LiveResource, 0, len(names))
+	for _, name := range names {
+		live = append(live, LiveResource{ID: name})
+	}
+	return live, nil
+}
+
+// MapState returns one entry per bucket, with the settings of the aws_s3_bucket and
+// its companion resources merged. A companion resource overrides the matching inline
+// arguments of the bucket, and drift in its setting is reported at its address.
+func (h *S3BucketHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	byName := make(map[string]*StateEntry)
+	var entries []*StateEntry
+	bucket := func(name string, source *StateEntry) *bucketState {
+		entry, ok := byName[name]
+		if !ok {
+			entry = &StateEntry{
+				Address:  source.Address,
+				ID:       name,
+				Resource: source.Resource,
+				Instance: source.Instance,
+				Value:    &bucketState{Sources: make(map[string]string)},
+			}
+			byName[name] = entry
+			entries = append(entries, entry)
+		}
+		return entry.Value.(*bucketState)
+	}
+	companions := func(resourceType string, decode func(instance *terafm.Instance) (string, interface{}, error),
+		apply func(state *bucketState, value interface{})) {
+		for _, source := range mapStateEntries(tfState, resourceType, decode) {
+			if source.ID == "" {
+				continue
+			}
+			state := bucket(source.ID, source)
+			state.Sources[bucketCompanionAttributes[resourceType]] = source.Address
+			apply(state, source.Value)
+		}
+	}
+
+	buckets := mapStateEntries(tfState, "aws_s3_bucket", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.S3BucketAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return firstNonEmpty(attrs.Bucket, attrs.ID), &attrs, err
+	})
+	for _, source := range buckets {
+		if source.ID == "" {
+			continue
+		}
+		attrs := source.Value.(*terafm.S3BucketAttributes)
+		state := bucket(source.ID, source)
+		state.Managed = true
+		state.Tags = attrs.Tags
+		if len(attrs.Versioning) > 0 {
+			state.Versioning = &bucketVersioning{
+				Enabled:   attrs.Versioning[0].Enabled,
+				MFADelete: attrs.Versioning[0].MFADelete,
+			}
+		}
+		if len(attrs.ServerSideEncryptionConfiguration) > 0 {
+			state.Encryption = stateEncryptionRule(attrs.ServerSideEncryptionConfiguration[0].Rules)
+		}
+		if len(attrs.LifecycleRules) > 0 {
+			state.LifecycleRules = make(map[string]string)
+			for _, rule := range attrs.LifecycleRules {
+				state.LifecycleRules[rule.ID] = formatLifecycleRule(inlineLifecycleRule(rule))
+			}
+		}
+		state.Policy = attrs.Policy
+	}
+
+	companions("aws_s3_bucket_versioning", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.S3BucketVersioningAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.Bucket, &attrs, err
+	}, func(state *bucketState, value interface{}) {
+		attrs := value.(*terafm.S3BucketVersioningAttributes)
+		state.Versioning = &bucketVersioning{}
+		if len(attrs.VersioningConfiguration) > 0 {
+			state.Versioning.Enabled = attrs.VersioningConfiguration[0].Status == "Enabled"
+			state.Versioning.MFADelete = attrs.VersioningConfiguration[0].MFADelete == "Enabled"
+		}
+	})
+	companions("aws_s3_bucket_server_side_encryption_configuration", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.S3BucketEncryptionAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.Bucket, &attrs, err
+	}, func(state *bucketState, value interface{}) {
+		state.Encryption = stateEncryptionRule(value.(*terafm.S3BucketEncryptionAttributes).Rules)
+	})
+	companions("aws_s3_bucket_public_access_block", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.S3BucketPublicAccessBlockAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.Bucket, &attrs, err
+	}, func(state *bucketState, value interface{}) {
+		attrs := value.(*terafm.S3BucketPublicAccessBlockAttributes)
+		state.PublicAccessBlock = &awsm.BucketPublicAccessBlock{
+			BlockPublicAcls:       attrs.BlockPublicAcls,
+			BlockPublicPolicy:     attrs.BlockPublicPolicy,
+			IgnorePublicAcls:      attrs.IgnorePublicAcls,
+			RestrictPublicBuckets: attrs.RestrictPublicBuckets,
+		}
+	})
+	companions("aws_s3_bucket_lifecycle_configuration", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.S3BucketLifecycleAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.Bucket, &attrs, err
+	}, func(state *bucketState, value interface{}) {
+		state.LifecycleRules = make(map[string]string)
+		for _, rule := range value.(*terafm.S3BucketLifecycleAttributes).Rules {
+			state.LifecycleRules[rule.ID] = formatLifecycleRule(companionLifecycleRule(rule))
+		}
+	})
+	companions("aws_s3_bucket_policy", func(instance *terafm.Instance) (string, interface{}, error) {
+		var attrs terafm.S3BucketPolicyAttributes
+		err := instance.DecodeAttributes(&attrs)
+		return attrs.Bucket, &attrs, err
+	}, func(state *bucketState, value interface{}) {
+		state.Policy = value.(*terafm.S3BucketPolicyAttributes).Policy
+	})
+
+	return entries
+}
+
+// bucketCompanionAttributes maps each companion resource type to the top-level drift
+// attribute of the setting it manages
+var bucketCompanionAttributes = map[string]string{
+	"aws_s3_bucket_versioning":                           "versioning",
+	"aws_s3_bucket_server_side_encryption_configuration": "server_side_encryption_configuration",
+	"aws_s3_bucket_public_access_block":                  "public_access_block",
+	"aws_s3_bucket_lifecycle_configuration":              "lifecycle_rule",
+	"aws_s3_bucket_policy":                               "policy",
+}
+
+// Compare reads the configuration of a live bucket and compares its versioning,
+// encryption, public access block, lifecycle rules, policy and tags with the state
+func (h *S3BucketHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	bucket, err := h.client.GetBucket(ctx, live.ID)
+	if err != nil {
+		return nil, err
+	}
+	state := entry.Value.(*bucketState)
+
+	var drifts []Drift
+	if state.Versioning != nil {
+		drifts = append(drifts, boolDrifts("versioning", []boolAttribute{
+			{"enabled", state.Versioning.Enabled, bucket.Versioning == "Enabled"},
+			{"mfa_delete", state.Versioning.MFADelete, bucket.MFADelete == "Enabled"},
+		})...)
+	}
+	if state.Encryption != nil {
+		var actual awsm.BucketEncryptionRule
+		if len(bucket.Encryption) > 0 {
+			actual = bucket.Encryption[0]
+		}
+		if actual.SSEAlgorithm != state.Encryption.SSEAlgorithm {
+			drifts = append(drifts, attributeDrift("server_side_encryption_configuration.sse_algorithm",
+				state.Encryption.SSEAlgorithm, actual.SSEAlgorithm))
+		}
+		if actual.KMSMasterKeyID != state.Encryption.KMSMasterKeyID {
+			drifts = append(drifts, attributeDrift("server_side_encryption_configuration.kms_master_key_id",
+				state.Encryption.KMSMasterKeyID, actual.KMSMasterKeyID))
+		}
+		drifts = append(drifts, boolDrifts("server_side_encryption_configuration", []boolAttribute{
+			{"bucket_key_enabled", state.Encryption.BucketKeyEnabled, actual.BucketKeyEnabled},
+		})...)
+	}
+	if state.PublicAccessBlock != nil {
+		drifts = append(drifts, comparePublicAccessBlock(*state.PublicAccessBlock, bucket.PublicAccessBlock)...)
+	}
+	if state.LifecycleRules != nil {
+		drifts = append(drifts, compareLifecycleRules(state.LifecycleRules, bucket.LifecycleRules)...)
+	}
+	if state.Policy != "" {
+		expected, actual := comparablePolicy(state.Policy), comparablePolicy(bucket.Policy)
+		if expected != actual {
+			drifts = append(drifts, attributeDrift("policy", expected, actual))
+		}
+	}
+	if state.Managed {
+		drifts = append(drifts, tagDrifts(state.Tags, bucket.Tags)...)
+	}
+
+	drifts = stampStateDrifts(drifts, entry, bucket.Name)
+	for i := range drifts {
+		top := drifts[i].Attribute
+		if j := strings.Index(top, "."); j != -1 {
+			top = top[:j]
+		}
+		if address, ok := state.Sources[top]; ok {
+			drifts[i].Address = address
+		}
+	}
+	return drifts, nil
+}
+
+// boolAttribute is a boolean setting compared under a parent attribute
+type boolAttribute struct {
+	name     string
+	expected bool
+	actual   bool
+}
+
+// boolDrifts reports the boolean settings that differ, as parent.name
+func boolDrifts(parent string, attributes []boolAttribute) []Drift {
+	var drifts []Drift
+	for _, attribute := range attributes {
+		if attribute.expected != attribute.actual {
+			drifts = append(drifts, attributeDrift(parent+"."+attribute.name,
+				strconv.FormatBool(attribute.expected), strconv.FormatBool(attribute.actual)))
+		}
+	}
+	return drifts
+}
+
+// comparePublicAccessBlock compares the public access block of a bucket with the state.
+// A bucket without a block has every setting off. Turning off a setting Terraform has
+// on opens the bucket up, so it is critical.
+func comparePublicAccessBlock(expected awsm.BucketPublicAccessBlock, actual *awsm.BucketPublicAccessBlock) []Drift {
+	if actual == nil {
+		actual = &awsm.BucketPublicAccessBlock{}
+	}
+	drifts := boolDrifts("public_access_block", []boolAttribute{
+		{"block_public_acls", expected.BlockPublicAcls, actual.BlockPublicAcls},
+		{"block_public_policy", expected.BlockPublicPolicy, actual.BlockPublicPolicy},
+		{"ignore_public_acls", expected.IgnorePublicAcls, actual.IgnorePublicAcls},
+		{"restrict_public_buckets", expected.RestrictPublicBuckets, actual.RestrictPublicBuckets},
+	})
+	for i := range drifts {
+		if drifts[i].Expected == "true" {
+			drifts[i].Severity = SeverityCritical
+		}
+	}
+	return drifts
+}
+
+// compareLifecycleRules diffs the lifecycle rules of a bucket with the state by rule ID
+func compareLifecycleRules(expected map[string]string, rules []awsm.BucketLifecycleRule) []Drift {
+	actual := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		actual[rule.ID] = formatLifecycleRule(rule)
+	}
+
+	var drifts []Drift
+	add := func(id, expected, actual string, change Change) {
+		drift := attributeDrift("lifecycle_rule", expected, actual)
+		drift.Change = change
+		drift.Details = map[string]string{"rule_id": id}
+		drifts = append(drifts, drift)
+	}
+	for _, id := range sortedKeys(expected) {
+		live, ok := actual[id]
+		switch {
+		case !ok:
+			add(id, expected[id], "", ChangeRemoved)
+		case live != expected[id]:
+			add(id, expected[id], live, ChangeModified)
+		}
+	}
+	for _, id := range sortedKeys(actual) {
+		if _, ok := expected[id]; !ok {
+			add(id, "", actual[id], ChangeAdded)
+		}
+	}
+	return drifts
+}
+
+// formatLifecycleRule formats a lifecycle rule for comparison and drift values, e.g.
+// "Enabled prefix=logs/ expiration=90d transition=30d:STANDARD_IA"
+func formatLifecycleRule(rule awsm.BucketLifecycleRule) string {
+	parts := []string{rule.Status}
+	if rule.Prefix != "" {
+		parts = append(parts, "prefix="+rule.Prefix)
+	}
+	if rule.ExpirationDays > 0 {
+		parts = append(parts, fmt.Sprintf("expiration=%dd", rule.ExpirationDays))
+	}
+	if rule.NoncurrentVersionExpirationDays > 0 {
+		parts = append(parts, fmt.Sprintf("noncurrent_expiration=%dd", rule.NoncurrentVersionExpirationDays))
+	}
+	if rule.AbortIncompleteMultipartUploadDays > 0 {
+		parts = append(parts, fmt.Sprintf("abort_multipart=%dd", rule.AbortIncompleteMultipartUploadDays))
+	}
+	transitions := make([]string, 0, len(rule.Transitions))
+	for _, transition := range rule.Transitions {
+		transitions = append(transitions, fmt.Sprintf("transition=%dd:%s", transition.Days, transition.StorageClass))
+	}
+	sort.Strings(transitions)
+	return strings.Join(append(parts, transitions...), " ")
+}
+
+// inlineLifecycleRule maps a lifecycle_rule block of an aws_s3_bucket onto the live model
+func inlineLifecycleRule(block terafm.S3BucketLifecycleRuleBlock) awsm.BucketLifecycleRule {
+	rule := awsm.BucketLifecycleRule{
+		ID:                                 block.ID,
+		Status:                             "Disabled",
+		Prefix:                             block.Prefix,
+		AbortIncompleteMultipartUploadDays: int32(block.AbortIncompleteMultipartUploadDays),
+	}
+	if block.Enabled {
+		rule.Status = "Enabled"
+	}
+	if len(block.Expiration) > 0 {
+		rule.ExpirationDays = int32(block.Expiration[0].Days)
+	}
+	if len(block.NoncurrentVersionExpiration) > 0 {
+		rule.NoncurrentVersionExpirationDays = int32(block.NoncurrentVersionExpiration[0].Days)
+	}
+	for _, transition := range block.Transitions {
+		rule.Transitions = append(rule.Transitions, awsm.BucketLifecycleTransition{
+			Days:         int32(transition.Days),
+			StorageClass: transition.StorageClass,
+		})
+	}
+	return rule
+}
+
+// companionLifecycleRule maps a rule of an aws_s3_bucket_lifecycle_configuration onto
+// the live model
+func companionLifecycleRule(block terafm.S3BucketLifecycleRuleState) awsm.BucketLifecycleRule {
+	rule := awsm.BucketLifecycleRule{
+		ID:     block.ID,
+		Status: block.Status,
+		Prefix: block.Prefix,
+	}
+	if len(block.Filter) > 0 && rule.Prefix == "" {
+		rule.Prefix = block.Filter[0].Prefix
+		if rule.Prefix == "" && len(block.Filter[0].And) > 0 {
+			rule.Prefix = block.Filter[0].And[0].Prefix
+		}
+	}
+	if len(block.Expiration) > 0 {
+		rule.ExpirationDays = int32(block.Expiration[0].Days)
+	}
+	if len(block.NoncurrentVersionExpiration) > 0 {
+		expiration := block.NoncurrentVersionExpiration[0]
+		rule.NoncurrentVersionExpirationDays = int32(max(expiration.NoncurrentDays, expiration.Days))
+	}
+	if len(block.AbortIncompleteMultipartUpload) > 0 {
+		rule.AbortIncompleteMultipartUploadDays = int32(block.AbortIncompleteMultipartUpload[0].DaysAfterInitiation)
+	}
+	for _, transition := range block.Transitions {
+		rule.Transitions = append(rule.Transitions, awsm.BucketLifecycleTransition{
+			Days:         int32(transition.Days),
+			StorageClass: transition.StorageClass,
+		})
+	}
+	return rule
+}
+
+// stateEncryptionRule returns the first default encryption rule of the state, or nil
+// when there is none
+func stateEncryptionRule(rules []terafm.S3BucketEncryptionRule) *awsm.BucketEncryptionRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	rule := &awsm.BucketEncryptionRule{BucketKeyEnabled: rules[0].BucketKeyEnabled}
+	if len(rules[0].ApplyServerSideEncryptionByDefault) > 0 {
+		rule.SSEAlgorithm = rules[0].ApplyServerSideEncryptionByDefault[0].SSEAlgorithm
+		rule.KMSMasterKeyID = rules[0].ApplyServerSideEncryptionByDefault[0].KMSMasterKeyID
+	}
+	return rule
+}
+
+// firstNonEmpty returns the first of the values that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
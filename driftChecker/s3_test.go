@@ -0,0 +1,231 @@
+package driftChecker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// s3State is a bucket managed with inline arguments plus a public access block, a
+// lifecycle configuration and a policy managed by companion resources
+func s3State() *terafm.TerraformState {
+	return &terafm.TerraformState{Resources: []terafm.Resource{
+		{Type: "aws_s3_bucket_policy", Name: "logs", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "logs", "bucket": "logs",
+			"policy": "{\"Version\":\"2012-10-17\",\"Statement\":[{\"Effect\":\"Deny\",\"Principal\":\"*\",\"Action\":\"s3:*\",\"Resource\":\"arn:aws:s3:::logs/*\"}]}"
+		}`)}},
+		{Type: "aws_s3_bucket", Name: "logs", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "logs", "bucket": "logs", "arn": "arn:aws:s3:::logs",
+			"versioning": [{"enabled": true, "mfa_delete": false}],
+			"server_side_encryption_configuration": [{"rule": [{
+				"apply_server_side_encryption_by_default": [{"sse_algorithm": "aws:kms", "kms_master_key_id": "alias/s3"}],
+				"bucket_key_enabled": true
+			}]}],
+			"lifecycle_rule": [{"id": "inline", "enabled": true, "expiration": [{"days": 1}]}],
+			"policy": "",
+			"tags": {"env": "prod"}
+		}`)}},
+		{Type: "aws_s3_bucket_public_access_block", Name: "logs", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "logs", "bucket": "logs",
+			"block_public_acls": true, "block_public_policy": true, "ignore_public_acls": true, "restrict_public_buckets": true
+		}`)}},
+		{Type: "aws_s3_bucket_lifecycle_configuration", Name: "logs", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "logs", "bucket": "logs",
+			"rule": [
+				{"id": "expire-logs", "status": "Enabled", "filter": [{"prefix": "logs/"}],
+				 "expiration": [{"days": 90}], "transition": [{"days": 30, "storage_class": "STANDARD_IA"}]},
+				{"id": "cleanup", "status": "Enabled", "filter": [{"prefix": ""}],
+				 "abort_incomplete_multipart_upload": [{"days_after_initiation": 7}]}
+			]
+		}`)}},
+		{Type: "aws_s3_bucket_versioning", Name: "assets", Instances: []terafm.Instance{decodeStateInstance(`{
+			"id": "assets", "bucket": "assets",
+			"versioning_configuration": [{"status": "Suspended", "mfa_delete": "Disabled"}]
+		}`)}},
+	}}
+}
+
+// liveLogsBucket is the live bucket matching s3State
+func liveLogsBucket() *awsm.AWSBucket {
+	return &awsm.AWSBucket{
+		Name:       "logs",
+		Versioning: "Enabled",
+		MFADelete:  "Disabled",
+		Encryption: []awsm.BucketEncryptionRule{
+			{SSEAlgorithm: "aws:kms", KMSMasterKeyID: "alias/s3", BucketKeyEnabled: true},
+		},
+		PublicAccessBlock: &awsm.BucketPublicAccessBlock{
+			BlockPublicAcls: true, BlockPublicPolicy: true, IgnorePublicAcls: true, RestrictPublicBuckets: true,
+		},
+		LifecycleRules: []awsm.BucketLifecycleRule{
+			{ID: "cleanup", Status: "Enabled", AbortIncompleteMultipartUploadDays: 7},
+			{ID: "expire-logs", Status: "Enabled", Prefix: "logs/", ExpirationDays: 90,
+				Transitions: []awsm.BucketLifecycleTransition{{Days: 30, StorageClass: "STANDARD_IA"}}},
+		},
+		Policy: `{
+			"Version": "2012-10-17",
+			"Statement": {"Effect": "Deny", "Principal": "*", "Action": ["s3:*"], "Resource": ["arn:aws:s3:::logs/*"]}
+		}`,
+		Tags: map[string]string{"env": "prod", "owner": "console"},
+	}
+}
+
+func TestS3BucketHandler_MapState(t *testing.T) {
+	handler := NewS3BucketHandler(nil, zap.NewNop())
+	entries := handler.MapState(s3State())
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, "aws_s3_bucket.logs", entries[0].Address)
+	assert.Equal(t, "logs", entries[0].ID)
+	assert.Equal(t, &bucketState{
+		Managed:    true,
+		Versioning: &bucketVersioning{Enabled: true},
+		Encryption: &awsm.BucketEncryptionRule{SSEAlgorithm: "aws:kms", KMSMasterKeyID: "alias/s3", BucketKeyEnabled: true},
+		PublicAccessBlock: &awsm.BucketPublicAccessBlock{
+			BlockPublicAcls: true, BlockPublicPolicy: true, IgnorePublicAcls: true, RestrictPublicBuckets: true,
+		},
+		LifecycleRules: map[string]string{
+			"expire-logs": "Enabled prefix=logs/ expiration=90d transition=30d:STANDARD_IA",
+			"cleanup":     "Enabled abort_multipart=7d",
+		},
+		Policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::logs/*"}]}`,
+		Tags:   map[string]string{"env": "prod"},
+		Sources: map[string]string{
+			"public_access_block": "aws_s3_bucket_public_access_block.logs",
+			"lifecycle_rule":      "aws_s3_bucket_lifecycle_configuration.logs",
+			"policy":              "aws_s3_bucket_policy.logs",
+		},
+	}, entries[0].Value)
+
+	// A bucket only referenced by a companion is checked for that setting alone
+	assert.Equal(t, "aws_s3_bucket_versioning.assets", entries[1].Address)
+	assert.Equal(t, &bucketState{
+		Versioning: &bucketVersioning{},
+		Sources:    map[string]string{"versioning": "aws_s3_bucket_versioning.assets"},
+	}, entries[1].Value)
+}
+
+func TestS3BucketHandler_Compare(t *testing.T) {
+	const address = "aws_s3_bucket.logs"
+
+	tests := []struct {
+		name     string
+		modify   func(bucket *awsm.AWSBucket)
+		expected []Drift
+	}{
+		{
+			name:   "no drift",
+			modify: func(bucket *awsm.AWSBucket) {},
+		},
+		{
+			name: "versioning suspended and encryption switched to AES256",
+			modify: func(bucket *awsm.AWSBucket) {
+				bucket.Versioning = "Suspended"
+				bucket.Encryption = []awsm.BucketEncryptionRule{{SSEAlgorithm: "AES256"}}
+			},
+			expected: []Drift{
+				{Address: address, ResourceID: "logs", Attribute: "versioning.enabled", Expected: "true", Actual: "false", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand},
+				{Address: address, ResourceID: "logs", Attribute: "server_side_encryption_configuration.sse_algorithm", Expected: "aws:kms", Actual: "AES256", Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand},
+				{Address: address, ResourceID: "logs", Attribute: "server_side_encryption_configuration.kms_master_key_id", Expected: "alias/s3", Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand},
+				{Address: address, ResourceID: "logs", Attribute: "server_side_encryption_configuration.bucket_key_enabled", Expected: "true", Actual: "false", Source: SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand},
+			},
+		},
+		{
+			name: "public access block removed",
+			modify: func(bucket *awsm.AWSBucket) {
+				bucket.PublicAccessBlock = nil
+			},
+			expected: []Drift{
+				{Address: "aws_s3_bucket_public_access_block.logs", ResourceID: "logs", Attribute: "public_access_block.block_public_acls", Expected: "true", Actual: "false", Source: SourceState, Severity: SeverityCritical, Category: CategoryOutOfBand},
+				{Address: "aws_s3_bucket_public_access_block.logs", ResourceID: "logs", Attribute: "public_access_block.block_public_policy", Expected: "true", Actual: "false", Source: SourceState, Severity: SeverityCritical, Category: CategoryOutOfBand},
+				{Address: "aws_s3_bucket_public_access_block.logs", ResourceID: "logs", Attribute: "public_access_block.ignore_public_acls", Expected: "true", Actual: "false", Source: SourceState, Severity: SeverityCritical, Category: CategoryOutOfBand},
+				{Address: "aws_s3_bucket_public_access_block.logs", ResourceID: "logs", Attribute: "public_access_block.restrict_public_buckets", Expected: "true", Actual: "false", Source: SourceState, Severity: SeverityCritical, Category: CategoryOutOfBand},
+			},
+		},
+		{
+			name: "lifecycle rules added, removed and changed",
+			modify: func(bucket *awsm.AWSBucket) {
+				bucket.LifecycleRules = []awsm.BucketLifecycleRule{
+					{ID: "expire-logs", Status: "Enabled", Prefix: "logs/", ExpirationDays: 30,
+						Transitions: []awsm.BucketLifecycleTransition{{Days: 30, StorageClass: "STANDARD_IA"}}},
+					{ID: "console", Status: "Disabled", ExpirationDays: 1},
+				}
+			},
+			expected: []Drift{
+				{Address: "aws_s3_bucket_lifecycle_configuration.logs", ResourceID: "logs", Attribute: "lifecycle_rule", Expected: "Enabled abort_multipart=7d", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand, Change: ChangeRemoved, Details: map[string]string{"rule_id": "cleanup"}},
+				{Address: "aws_s3_bucket_lifecycle_configuration.logs", ResourceID: "logs", Attribute: "lifecycle_rule", Expected: "Enabled prefix=logs/ expiration=90d transition=30d:STANDARD_IA", Actual: "Enabled prefix=logs/ expiration=30d transition=30d:STANDARD_IA", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand, Change: ChangeModified, Details: map[string]string{"rule_id": "expire-logs"}},
+				{Address: "aws_s3_bucket_lifecycle_configuration.logs", ResourceID: "logs", Attribute: "lifecycle_rule", Actual: "Disabled expiration=1d", Source: SourceState, Severity: SeverityMedium, Category: CategoryOutOfBand, Change: ChangeAdded, Details: map[string]string{"rule_id": "console"}},
+			},
+		},
+		{
+			name: "policy rewritten and tag changed",
+			modify: func(bucket *awsm.AWSBucket) {
+				bucket.Policy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::logs/*"}]}`
+				bucket.Tags["env"] = "staging"
+			},
+			expected: []Drift{
+				{Address: "aws_s3_bucket_policy.logs", ResourceID: "logs", Attribute: "policy",
+					Expected: `{"Statement":[{"Action":["s3:*"],"Effect":"Deny","Principal":"*","Resource":["arn:aws:s3:::logs/*"]}],"Version":"2012-10-17"}`,
+					Actual:   `{"Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Principal":"*","Resource":["arn:aws:s3:::logs/*"]}],"Version":"2012-10-17"}`,
+					Source:   SourceState, Severity: SeverityHigh, Category: CategoryOutOfBand},
+				{Address: address, ResourceID: "logs", Attribute: "tags.env", Expected: "prod", Actual: "staging", Source: SourceState, Severity: SeverityLow, Category: CategoryOutOfBand},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := liveLogsBucket()
+			tt.modify(bucket)
+			client := new(MockS3Client)
+			client.On("GetBucket", "logs").Return(bucket, nil)
+
+			handler := NewS3BucketHandler(client, zap.NewNop())
+			drifts := compareFirst(t, handler, s3State(), LiveResource{ID: "logs"})
+			assert.Equal(t, tt.expected, drifts)
+		})
+	}
+}
+
+func TestS3BucketHandler_CompareError(t *testing.T) {
+	client := new(MockS3Client)
+	client.On("GetBucket", "logs").Return(nil, fmt.Errorf("access denied"))
+	handler := NewS3BucketHandler(client, zap.NewNop())
+
+	entries := handler.MapState(s3State())
+	drifts, err := handler.Compare(context.Background(), LiveResource{ID: "logs"}, entries[0], nil)
+
+	assert.Nil(t, drifts)
+	assert.Error(t, err)
+}
+
+func TestDriftService_S3Buckets(t *testing.T) {
+	s3Client := new(MockS3Client)
+	s3Client.On("ListBuckets").Return([]string{"logs", "scratch", "cdk-assets-123"}, nil)
+	s3Client.On("GetBucket", "logs").Return(liveLogsBucket(), nil)
+
+	tfClient := new(MockTerraformClient)
+	tfClient.On("ParseTerraformInstance", "terraform.tfstate").Return(s3State(), nil)
+	tfClient.On("ParseHCLConfig", "main.tf").Return(&terafm.Config{}, nil)
+
+	service := NewDriftService(new(MockAWSClient), tfClient, zap.NewNop(),
+		WithHandler(NewS3BucketHandler(s3Client, zap.NewNop())),
+		WithUnmanagedFilter(UnmanagedFilter{IgnoreNames: []string{"cdk-*"}}),
+	)
+	report, err := service.runDriftCheck(context.Background(), "terraform.tfstate", "main.tf")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.ResourcesChecked)
+	assert.Equal(t, []Drift{
+		{ResourceID: "scratch", ResourceType: "aws_s3_bucket", Source: SourceState, Severity: SeverityMedium, Category: CategoryUnmanaged},
+		{Address: "aws_s3_bucket_versioning.assets", ResourceID: "assets", Source: SourceState, Severity: SeverityHigh, Category: CategoryMissing},
+	}, report.Drifts)
+	s3Client.AssertExpectations(t)
+}
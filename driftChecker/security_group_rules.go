@@ -0,0 +1,426 @@
+package driftChecker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+const (
+	directionIngress = "ingress"
+	directionEgress  = "egress"
+
+	// protocolAll is the normalised protocol of rules that allow all traffic
+	protocolAll = "all"
+)
+
+// protocolNames maps IANA protocol numbers to the names AWS and Terraform also accept
+var protocolNames = map[string]string{
+	"-1": protocolAll,
+	"1":  "icmp",
+	"6":  "tcp",
+	"17": "udp",
+	"58": "icmpv6",
+}
+
+// sgRule is a security group rule normalised into set form: one direction, protocol,
+// port range and source. Source is a CIDR, a prefix list ID or a security group ID.
+type sgRule struct {
+	Direction string
+	Protocol  string
+	FromPort  int
+	ToPort    int
+	Source    string
+}
+
+// newSGRule normalises a rule so the same rule compares equal whichever way AWS or
+// Terraform spells it, e.g. protocol "6" or "tcp", and ports -1 or null for all traffic
+func newSGRule(direction, protocol string, fromPort, toPort int, source string) sgRule {
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	if name, ok := protocolNames[protocol]; ok {
+		protocol = name
+	}
+	if protocol == "" {
+		protocol = protocolAll
+	}
+	if protocol == protocolAll {
+		fromPort, toPort = 0, 0
+	}
+	return sgRule{Direction: direction, Protocol: protocol, FromPort: fromPort, ToPort: toPort, Source: source}
+}
+
+// portRange formats the rule's ports, e.g. 22, 8000-8080 or all
+func (r sgRule) portRange() string {
+	switch {
+	case r.Protocol == protocolAll:
+		return "all"
+	case r.FromPort == r.ToPort:
+		return fmt.Sprint(r.FromPort)
+	default:
+		return fmt.Sprintf("%d-%d", r.FromPort, r.ToPort)
+	}
+}
+
+// String formats the rule for drift values, e.g. "tcp 22 from 0.0.0.0/0"
+func (r sgRule) String() string {
+	preposition := "from"
+	if r.Direction == directionEgress {
+		preposition = "to"
+	}
+	return fmt.Sprintf("%s %s %s %s", r.Protocol, r.portRange(), preposition, r.Source)
+}
+
+// isOpenIngress reports whether the rule lets in traffic from the whole internet
+func (r sgRule) isOpenIngress() bool {
+	return r.Direction == directionIngress && (r.Source == "0.0.0.0/0" || r.Source == "::/0")
+}
+
+// sameTarget reports whether two rules differ only in their port range
+func (r sgRule) sameTarget(other sgRule) bool {
+	return r.Direction == other.Direction && r.Protocol == other.Protocol && r.Source == other.Source
+}
+
+// sgRuleSet maps each rule to its description
+type sgRuleSet map[sgRule]string
+
+// add inserts one rule per source
+func (s sgRuleSet) add(direction, protocol string, fromPort, toPort int, description string, sources ...string) {
+	for _, source := range sources {
+		if source != "" {
+			s[newSGRule(direction, protocol, fromPort, toPort, source)] = description
+		}
+	}
+}
+
+// sorted returns the rules of the set in a deterministic order
+func (s sgRuleSet) sorted() []sgRule {
+	rules := make([]sgRule, 0, len(s))
+	for rule := range s {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].String() < rules[j].String() })
+	return rules
+}
+
+// liveRuleSet normalises the rules of a live security group
+func liveRuleSet(group *awsm.AWSSecurityGroup) sgRuleSet {
+	rules := make(sgRuleSet)
+	for direction, liveRules := range map[string][]awsm.SecurityGroupRule{
+		directionIngress: group.Ingress,
+		directionEgress:  group.Egress,
+	} {
+		for _, rule := range liveRules {
+			rules.add(direction, rule.Protocol, int(rule.FromPort), int(rule.ToPort), rule.Description,
+				rule.CidrIpv4, rule.CidrIpv6, rule.PrefixListId, rule.ReferencedGroupId)
+		}
+	}
+	return rules
+}
+
+// sgState is everything the Terraform state records about one security group. A group
+// managed by an aws_security_group has all its rules in the state; one only
+// referenced by rule resources is compared in the directions those resources cover.
+type sgState struct {
+	Address    string
+	Resource   *terafm.Resource
+	GroupID    string
+	Managed    bool
+	Directions map[string]bool
+	Rules      sgRuleSet
+}
+
+// compares reports whether the state accounts for all rules of the given direction
+func (s *sgState) compares(direction string) bool {
+	return s.Managed || s.Directions[direction]
+}
+
+// securityGroupIndex collects the security groups of the Terraform state by group ID
+type securityGroupIndex struct {
+	byID    map[string]*sgState
+	entries []*sgState
+}
+
+// newSecurityGroupIndex indexes the aws_security_group, aws_security_group_rule and
+// aws_vpc_security_group_*_rule resources of the state, merging the rules of each group
+func newSecurityGroupIndex(tfState *terafm.TerraformState) *securityGroupIndex {
+	logger := zap.L().With(
+		zap.String("function", "newSecurityGroupIndex"),
+	)
+
+	idx := &securityGroupIndex{byID: make(map[string]*sgState)}
+	if tfState == nil {
+		return idx
+	}
+
+	for i := range tfState.Resources {
+		resource := &tfState.Resources[i]
+		if !resource.IsManaged() {
+			continue
+		}
+		for j := range resource.Instances {
+			instance := &resource.Instances[j]
+			address := resource.InstanceAddress(instance)
+
+			var err error
+			switch resource.Type {
+			case "aws_security_group":
+				err = idx.addGroup(resource, address, instance)
+			case "aws_security_group_rule":
+				err = idx.addRule(resource, address, instance)
+			case "aws_vpc_security_group_ingress_rule":
+				err = idx.addVpcRule(resource, address, directionIngress, instance)
+			case "aws_vpc_security_group_egress_rule":
+				err = idx.addVpcRule(resource, address, directionEgress, instance)
+			default:
+				continue
+			}
+			if err != nil {
+				logger.Warn("Failed to decode security group state, skipping",
+					zap.String("operation", "security_group_index"),
+					zap.String("address", address),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	logger.Info("Terraform security groups indexed",
+		zap.String("operation", "security_group_index"),
+		zap.Int("security_group_count", len(idx.entries)),
+	)
+	return idx
+}
+
+// group returns the entry of a group ID, creating it for the resource at address
+func (idx *securityGroupIndex) group(id string, resource *terafm.Resource, address string) *sgState {
+	state, ok := idx.byID[id]
+	if !ok {
+		state = &sgState{
+			Address:    address,
+			Resource:   resource,
+			GroupID:    id,
+			Directions: make(map[string]bool),
+			Rules:      make(sgRuleSet),
+		}
+		idx.byID[id] = state
+		idx.entries = append(idx.entries, state)
+	}
+	return state
+}
+
+func (idx *securityGroupIndex) addGroup(resource *terafm.Resource, address string, instance *terafm.Instance) error {
+	var attrs terafm.SecurityGroupAttributes
+	if err := instance.DecodeAttributes(&attrs); err != nil {
+		return err
+	}
+	if attrs.ID == "" {
+		return nil
+	}
+
+	state := idx.group(attrs.ID, resource, address)
+	state.Address, state.Resource = address, resource
+	state.Managed = true
+	for direction, blocks := range map[string][]terafm.SecurityGroupRuleBlock{
+		directionIngress: attrs.Ingress,
+		directionEgress:  attrs.Egress,
+	} {
+		for _, block := range blocks {
+			sources := append(append(append(append([]string{}, block.CidrBlocks...), block.Ipv6CidrBlocks...),
+				block.PrefixListIDs...), block.SecurityGroups...)
+			if block.Self {
+				sources = append(sources, attrs.ID)
+			}
+			state.Rules.add(direction, block.Protocol, block.FromPort, block.ToPort, block.Description, sources...)
+		}
+	}
+	return nil
+}
+
+func (idx *securityGroupIndex) addRule(resource *terafm.Resource, address string, instance *terafm.Instance) error {
+	var attrs terafm.SecurityGroupRuleAttributes
+	if err := instance.DecodeAttributes(&attrs); err != nil {
+		return err
+	}
+	if attrs.SecurityGroupID == "" {
+		return nil
+	}
+
+	state := idx.group(attrs.SecurityGroupID, resource, address)
+	state.Directions[attrs.Type] = true
+	sources := append(append(append([]string{}, attrs.CidrBlocks...), attrs.Ipv6CidrBlocks...), attrs.PrefixListIDs...)
+	sources = append(sources, attrs.SourceSecurityGroupID)
+	if attrs.Self {
+		sources = append(sources, attrs.SecurityGroupID)
+	}
+	state.Rules.add(attrs.Type, attrs.Protocol, attrs.FromPort, attrs.ToPort, attrs.Description, sources...)
+	return nil
+}
+
+func (idx *securityGroupIndex) addVpcRule(resource *terafm.Resource, address, direction string, instance *terafm.Instance) error {
+	var attrs terafm.VpcSecurityGroupRuleAttributes
+	if err := instance.DecodeAttributes(&attrs); err != nil {
+		return err
+	}
+	if attrs.SecurityGroupID == "" {
+		return nil
+	}
+
+	state := idx.group(attrs.SecurityGroupID, resource, address)
+	state.Directions[direction] = true
+	state.Rules.add(direction, attrs.IPProtocol, attrs.FromPort, attrs.ToPort, attrs.Description,
+		attrs.CidrIPv4, attrs.CidrIPv6, attrs.PrefixListID, attrs.ReferencedSecurityGroupID)
+	return nil
+}
+
+// compareSecurityGroupRules diffs the rules of a live security group with the state.
+// A removed and an added rule for the same protocol and source are reported together
+// as a changed port range, as are rules whose description alone changed.
+func compareSecurityGroupRules(live *awsm.AWSSecurityGroup, state *sgState) []Drift {
+	liveRules := liveRuleSet(live)
+
+	var drifts []Drift
+	add := func(attribute string, rule sgRule, expected, actual string, change Change) {
+		drift := attributeDrift(attribute, expected, actual)
+		if change != ChangeRemoved && rule.isOpenIngress() {
+			drift.Severity = SeverityCritical
+		}
+		drift.Address = state.Address
+		drift.ResourceID = live.GroupId
+		drift.Source = SourceState
+		drift.Category = CategoryOutOfBand
+		drift.Change = change
+		drift.Details = ruleDetails(live, rule, liveRules[rule])
+		if change == ChangeRemoved {
+			drift.Details = ruleDetails(live, rule, state.Rules[rule])
+		}
+		drifts = append(drifts, drift)
+	}
+
+	for _, direction := range []string{directionIngress, directionEgress} {
+		if !state.compares(direction) {
+			continue
+		}
+
+		var removed, added []sgRule
+		for _, rule := range state.Rules.sorted() {
+			if rule.Direction != direction {
+				continue
+			}
+			description, ok := liveRules[rule]
+			switch {
+			case !ok:
+				removed = append(removed, rule)
+			case description != state.Rules[rule]:
+				add(direction+".description", rule, state.Rules[rule], description, ChangeModified)
+			}
+		}
+		for _, rule := range liveRules.sorted() {
+			if _, ok := state.Rules[rule]; !ok && rule.Direction == direction {
+				added = append(added, rule)
+			}
+		}
+
+		// Pair up port range changes
+		for _, old := range removed {
+			paired := false
+			for i, rule := range added {
+				if old.sameTarget(rule) {
+					add(direction, rule, old.String(), rule.String(), ChangeModified)
+					added = append(added[:i], added[i+1:]...)
+					paired = true
+					break
+				}
+			}
+			if !paired {
+				add(direction, old, old.String(), "", ChangeRemoved)
+			}
+		}
+		for _, rule := range added {
+			add(direction, rule, "", rule.String(), ChangeAdded)
+		}
+	}
+	return drifts
+}
+
+// ruleDetails describes a drifted rule of a security group
+func ruleDetails(group *awsm.AWSSecurityGroup, rule sgRule, description string) map[string]string {
+	details := map[string]string{
+		"group_name": group.GroupName,
+		"vpc_id":     group.VpcId,
+		"protocol":   rule.Protocol,
+		"port_range": rule.portRange(),
+		"source":     rule.Source,
+	}
+	if description != "" {
+		details["description"] = description
+	}
+	return details
+}
+
+// SecurityGroupHandler compares the rules of aws_security_group resources, merged with
+// their rule resources, with the live security groups. Only groups the state mentions
+// are checked; live groups outside it, such as default VPC groups, aren't reported.
+type SecurityGroupHandler struct {
+	client AWSClient
+	logger *zap.Logger
+}
+
+// NewSecurityGroupHandler creates the aws_security_group handler
+func NewSecurityGroupHandler(client AWSClient, logger *zap.Logger) *SecurityGroupHandler {
+	return &SecurityGroupHandler{client: client, logger: logger}
+}
+
+// Types implements ResourceHandler
+func (h *SecurityGroupHandler) Types() []string {
+	return []string{
+		"aws_security_group",
+		"aws_security_group_rule",
+		"aws_vpc_security_group_ingress_rule",
+		"aws_vpc_security_group_egress_rule",
+	}
+}
+
+// ReportsUnmanaged implements ResourceHandler
+func (h *SecurityGroupHandler) ReportsUnmanaged() bool {
+	return false
+}
+
+// FetchLive returns the live security groups with their rules
+func (h *SecurityGroupHandler) FetchLive(ctx context.Context) ([]LiveResource, error) {
+	groups, err := h.client.GetSecurityGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.logger.Info("Successfully retrieved AWS security groups",
+		zap.String("operation", "get_aws_security_groups"),
+		zap.Int("security_group_count", len(groups)),
+	)
+
+	live := make([]LiveResource, 0, len(groups))
+	for _, group := range groups {
+		live = append(live, LiveResource{ID: group.GroupId, Tags: group.Tags, Value: group})
+	}
+	return live, nil
+}
+
+// MapState returns one entry per security group, with the rules of the group and its
+// rule resources merged
+func (h *SecurityGroupHandler) MapState(tfState *terafm.TerraformState) []*StateEntry {
+	index := newSecurityGroupIndex(tfState)
+	entries := make([]*StateEntry, 0, len(index.entries))
+	for _, state := range index.entries {
+		entries = append(entries, &StateEntry{Address: state.Address, ID: state.GroupID, Resource: state.Resource, Value: state})
+	}
+	return entries
+}
+
+// Compare implements ResourceHandler
+func (h *SecurityGroupHandler) Compare(ctx context.Context, live LiveResource, entry *StateEntry, tfConfig *terafm.Config) ([]Drift, error) {
+	return compareSecurityGroupRules(live.Value.(*awsm.AWSSecurityGroup), entry.Value.(*sgState)), nil
+}
@@ -0,0 +1,212 @@
+package driftChecker
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// decodeStateInstance builds a state instance from its attributes JSON as the state
+// parser would
+func decodeStateInstance(attributes string) terafm.Instance {
+	var instance terafm.Instance
+	if err := json.Unmarshal([]byte(`{"attributes": `+attributes+`}`), &instance); err != nil {
+		panic("invalid state instance: " + err.Error())
+	}
+	return instance
+}
+
+func TestNewSGRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     sgRule
+		expected string
+	}{
+		{
+			name:     "protocol number",
+			a:        newSGRule(directionIngress, "6", 22, 22, "10.0.0.0/8"),
+			b:        newSGRule(directionIngress, "TCP", 22, 22, "10.0.0.0/8"),
+			expected: "tcp 22 from 10.0.0.0/8",
+		},
+		{
+			name:     "all traffic ports",
+			a:        newSGRule(directionEgress, "-1", -1, -1, "0.0.0.0/0"),
+			b:        newSGRule(directionEgress, "all", 0, 0, "0.0.0.0/0"),
+			expected: "all all to 0.0.0.0/0",
+		},
+		{
+			name:     "port range",
+			a:        newSGRule(directionIngress, "udp", 8000, 8080, "sg-1"),
+			b:        newSGRule(directionIngress, "17", 8000, 8080, "sg-1"),
+			expected: "udp 8000-8080 from sg-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.a, tt.b)
+			assert.Equal(t, tt.expected, tt.a.String())
+		})
+	}
+}
+
+func TestSecurityGroupIndex(t *testing.T) {
+	state := &terafm.TerraformState{Resources: []terafm.Resource{
+		{
+			Type: "aws_security_group",
+			Name: "web",
+			Instances: []terafm.Instance{decodeStateInstance(`{
+				"id": "sg-web",
+				"ingress": [
+					{"protocol": "tcp", "from_port": 443, "to_port": 443, "cidr_blocks": ["0.0.0.0/0"], "ipv6_cidr_blocks": ["::/0"], "description": "https"},
+					{"protocol": "tcp", "from_port": 8080, "to_port": 8080, "self": true}
+				],
+				"egress": [{"protocol": "-1", "from_port": 0, "to_port": 0, "cidr_blocks": ["0.0.0.0/0"]}]
+			}`)},
+		},
+		{
+			Type: "aws_security_group_rule",
+			Name: "ssh",
+			Instances: []terafm.Instance{decodeStateInstance(`{
+				"type": "ingress", "security_group_id": "sg-web", "source_security_group_id": "sg-bastion",
+				"protocol": "tcp", "from_port": 22, "to_port": 22
+			}`)},
+		},
+		{
+			Type: "aws_vpc_security_group_ingress_rule",
+			Name: "db",
+			Instances: []terafm.Instance{decodeStateInstance(`{
+				"security_group_id": "sg-db", "referenced_security_group_id": "sg-web",
+				"ip_protocol": "tcp", "from_port": 5432, "to_port": 5432
+			}`)},
+		},
+		{
+			Mode:      terafm.ModeData,
+			Type:      "aws_security_group",
+			Name:      "default",
+			Instances: []terafm.Instance{decodeStateInstance(`{"id": "sg-default"}`)},
+		},
+	}}
+
+	idx := newSecurityGroupIndex(state)
+	require.Len(t, idx.entries, 2)
+
+	web := idx.byID["sg-web"]
+	require.NotNil(t, web)
+	assert.Equal(t, "aws_security_group.web", web.Address)
+	assert.Equal(t, "aws_security_group", web.Resource.Type)
+	assert.True(t, web.Managed)
+	assert.Equal(t, sgRuleSet{
+		newSGRule(directionIngress, "tcp", 443, 443, "0.0.0.0/0"): "https",
+		newSGRule(directionIngress, "tcp", 443, 443, "::/0"):      "https",
+		newSGRule(directionIngress, "tcp", 8080, 8080, "sg-web"):  "",
+		newSGRule(directionIngress, "tcp", 22, 22, "sg-bastion"):  "",
+		newSGRule(directionEgress, "all", 0, 0, "0.0.0.0/0"):      "",
+	}, web.Rules)
+
+	db := idx.byID["sg-db"]
+	require.NotNil(t, db)
+	assert.Equal(t, "aws_vpc_security_group_ingress_rule.db", db.Address)
+	assert.Equal(t, "aws_vpc_security_group_ingress_rule", db.Resource.Type)
+	assert.False(t, db.Managed)
+	assert.True(t, db.compares(directionIngress))
+	assert.False(t, db.compares(directionEgress))
+
+	assert.Empty(t, newSecurityGroupIndex(nil).entries)
+}
+
+func TestCompareSecurityGroupRules(t *testing.T) {
+	group := func(ingress, egress []awsm.SecurityGroupRule) *awsm.AWSSecurityGroup {
+		return &awsm.AWSSecurityGroup{GroupId: "sg-web", GroupName: "web", VpcId: "vpc-1", Ingress: ingress, Egress: egress}
+	}
+	details := func(protocol, ports, source string) map[string]string {
+		return map[string]string{"group_name": "web", "vpc_id": "vpc-1", "protocol": protocol, "port_range": ports, "source": source}
+	}
+	stamp := func(d Drift) Drift {
+		d.Address = "aws_security_group.web"
+		d.ResourceID = "sg-web"
+		d.Source = SourceState
+		d.Category = CategoryOutOfBand
+		return d
+	}
+	https := sgRuleSet{newSGRule(directionIngress, "tcp", 443, 443, "10.0.0.0/8"): ""}
+	allEgress := awsm.SecurityGroupRule{Protocol: "-1", FromPort: -1, ToPort: -1, CidrIpv4: "0.0.0.0/0"}
+
+	tests := []struct {
+		name     string
+		live     *awsm.AWSSecurityGroup
+		state    *sgState
+		expected []Drift
+	}{
+		{
+			name:  "no drift",
+			live:  group([]awsm.SecurityGroupRule{{Protocol: "6", FromPort: 443, ToPort: 443, CidrIpv4: "10.0.0.0/8"}}, nil),
+			state: &sgState{Address: "aws_security_group.web", GroupID: "sg-web", Managed: true, Rules: https},
+		},
+		{
+			name: "ssh opened to the internet",
+			live: group([]awsm.SecurityGroupRule{
+				{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIpv4: "10.0.0.0/8"},
+				{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrIpv4: "0.0.0.0/0"},
+			}, nil),
+			state: &sgState{Address: "aws_security_group.web", GroupID: "sg-web", Managed: true, Rules: https},
+			expected: []Drift{
+				stamp(Drift{Attribute: "ingress", Actual: "tcp 22 from 0.0.0.0/0", Severity: SeverityCritical, Change: ChangeAdded,
+					Details: details("tcp", "22", "0.0.0.0/0")}),
+			},
+		},
+		{
+			name:  "rule deleted",
+			live:  group(nil, nil),
+			state: &sgState{Address: "aws_security_group.web", GroupID: "sg-web", Managed: true, Rules: https},
+			expected: []Drift{
+				stamp(Drift{Attribute: "ingress", Expected: "tcp 443 from 10.0.0.0/8", Severity: SeverityHigh, Change: ChangeRemoved,
+					Details: details("tcp", "443", "10.0.0.0/8")}),
+			},
+		},
+		{
+			name:  "port range changed",
+			live:  group([]awsm.SecurityGroupRule{{Protocol: "tcp", FromPort: 443, ToPort: 8443, CidrIpv4: "10.0.0.0/8"}}, nil),
+			state: &sgState{Address: "aws_security_group.web", GroupID: "sg-web", Managed: true, Rules: https},
+			expected: []Drift{
+				stamp(Drift{Attribute: "ingress", Expected: "tcp 443 from 10.0.0.0/8", Actual: "tcp 443-8443 from 10.0.0.0/8", Severity: SeverityHigh, Change: ChangeModified,
+					Details: details("tcp", "443-8443", "10.0.0.0/8")}),
+			},
+		},
+		{
+			name:  "description changed",
+			live:  group([]awsm.SecurityGroupRule{{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIpv4: "10.0.0.0/8", Description: "temp"}}, nil),
+			state: &sgState{Address: "aws_security_group.web", GroupID: "sg-web", Managed: true, Rules: https},
+			expected: []Drift{
+				stamp(Drift{Attribute: "ingress.description", Actual: "temp", Severity: SeverityHigh, Change: ChangeModified,
+					Details: map[string]string{"group_name": "web", "vpc_id": "vpc-1", "protocol": "tcp", "port_range": "443", "source": "10.0.0.0/8", "description": "temp"}}),
+			},
+		},
+		{
+			name:  "default egress added outside Terraform",
+			live:  group(nil, []awsm.SecurityGroupRule{allEgress}),
+			state: &sgState{Address: "aws_security_group.web", GroupID: "sg-web", Managed: true, Rules: sgRuleSet{}},
+			expected: []Drift{
+				stamp(Drift{Attribute: "egress", Actual: "all all to 0.0.0.0/0", Severity: SeverityMedium, Change: ChangeAdded,
+					Details: details("all", "all", "0.0.0.0/0")}),
+			},
+		},
+		{
+			name: "rule resources only compare their direction",
+			live: group([]awsm.SecurityGroupRule{{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIpv4: "10.0.0.0/8"}}, []awsm.SecurityGroupRule{allEgress}),
+			state: &sgState{Address: "aws_security_group.web", GroupID: "sg-web",
+				Directions: map[string]bool{directionIngress: true}, Rules: https},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, compareSecurityGroupRules(tt.live, tt.state))
+		})
+	}
+}
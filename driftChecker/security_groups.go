@@ -0,0 +1,177 @@
+package driftChecker
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+// compareSecurityGroups diffs the security groups attached to the live instance with
+// the state in both directions, by ID (vpc_security_group_ids) and by name
+// (security_groups). Each side is only compared when the state records it. detached
+// are the groups of the state that still exist but are no longer attached, looked up
+// to describe them.
+func compareSecurityGroups(ctx context.Context, aws *awsm.AWSInstance, tf *terafm.Instance,
+	detached []awsm.SecurityGroup, ch chan<- Drift) {
+	byID, byName := indexSecurityGroups(aws.SecurityGroups)
+	detachedByID, detachedByName := indexSecurityGroups(detached)
+
+	if len(tf.Attributes.VpcSecurityGroupIDs) > 0 {
+		diffSecurityGroups("vpc_security_group_ids", tf.Attributes.VpcSecurityGroupIDs, byID, detachedByID,
+			func(sg awsm.SecurityGroup) string { return sg.GroupId }, aws.VpcId, ch)
+	}
+	if len(tf.Attributes.SecurityGroups) > 0 {
+		diffSecurityGroups("security_groups", tf.Attributes.SecurityGroups, byName, detachedByName,
+			func(sg awsm.SecurityGroup) string { return sg.GroupName }, aws.VpcId, ch)
+	}
+}
+
+// indexSecurityGroups indexes security groups by ID and by name
+func indexSecurityGroups(groups []awsm.SecurityGroup) (byID, byName map[string]awsm.SecurityGroup) {
+	byID = make(map[string]awsm.SecurityGroup, len(groups))
+	byName = make(map[string]awsm.SecurityGroup, len(groups))
+	for _, sg := range groups {
+		byID[sg.GroupId] = sg
+		if sg.GroupName != "" {
+			byName[sg.GroupName] = sg
+		}
+	}
+	return byID, byName
+}
+
+// diffSecurityGroups reports the groups only in the state as removed and the live
+// groups missing from the state as added. A removed group is described from detached
+// when it still exists; a group that is gone is only known by the instance's VPC. key
+// returns the ID or name a live group is listed under in the attribute.
+func diffSecurityGroups(attribute string, state []string, live, detached map[string]awsm.SecurityGroup,
+	key func(awsm.SecurityGroup) string, vpcID string, ch chan<- Drift) {
+	inState := make(map[string]bool, len(state))
+	for _, value := range state {
+		inState[value] = true
+	}
+
+	for _, value := range sortedUnique(state) {
+		if _, ok := live[value]; ok {
+			continue
+		}
+		drift := attributeDrift(attribute, value, "")
+		drift.Change = ChangeRemoved
+		if sg, ok := detached[value]; ok {
+			drift.Details = securityGroupDetails(sg)
+		} else if vpcID != "" {
+			drift.Details = map[string]string{"vpc_id": vpcID}
+		}
+		ch <- drift
+	}
+
+	var added []awsm.SecurityGroup
+	for value, sg := range live {
+		if !inState[value] {
+			added = append(added, sg)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return key(added[i]) < key(added[j]) })
+	for _, sg := range added {
+		drift := attributeDrift(attribute, "", key(sg))
+		drift.Change = ChangeAdded
+		drift.Details = securityGroupDetails(sg)
+		ch <- drift
+	}
+}
+
+// securityGroupLookup lists the live security groups on first use and keeps them, so
+// that the instances of a check share a single call
+type securityGroupLookup struct {
+	client AWSClient
+	once   sync.Once
+	groups []*awsm.AWSSecurityGroup
+	err    error
+}
+
+// newSecurityGroupLookup creates a lookup of the security groups visible to client
+func newSecurityGroupLookup(client AWSClient) *securityGroupLookup {
+	return &securityGroupLookup{client: client}
+}
+
+// get returns the live security groups, listing them on the first call
+func (l *securityGroupLookup) get(ctx context.Context) ([]*awsm.AWSSecurityGroup, error) {
+	l.once.Do(func() {
+		l.groups, l.err = l.client.GetSecurityGroups(ctx)
+	})
+	return l.groups, l.err
+}
+
+// detachedSecurityGroups returns the groups the state attaches to the instance, by ID
+// or by name, that still exist but aren't attached to the live instance. Groups are
+// only listed when one is missing; a failed lookup is logged and leaves them
+// undescribed.
+func detachedSecurityGroups(ctx context.Context, lookup *securityGroupLookup, logger *zap.Logger,
+	aws *awsm.AWSInstance, tf *terafm.Instance) []awsm.SecurityGroup {
+	attachedIDs, attachedNames := indexSecurityGroups(aws.SecurityGroups)
+	missingIDs := make(map[string]bool)
+	for _, id := range tf.Attributes.VpcSecurityGroupIDs {
+		if _, ok := attachedIDs[id]; !ok {
+			missingIDs[id] = true
+		}
+	}
+	missingNames := make(map[string]bool)
+	for _, name := range tf.Attributes.SecurityGroups {
+		if _, ok := attachedNames[name]; !ok {
+			missingNames[name] = true
+		}
+	}
+	if len(missingIDs) == 0 && len(missingNames) == 0 {
+		return nil
+	}
+
+	groups, err := lookup.get(ctx)
+	if err != nil {
+		logger.Warn("Failed to look up detached security groups",
+			zap.String("operation", "security_group_lookup"),
+			zap.String("instance_id", aws.InstanceID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	var detached []awsm.SecurityGroup
+	for _, group := range groups {
+		// Names are only unique within a VPC
+		byName := missingNames[group.GroupName] && (aws.VpcId == "" || group.VpcId == aws.VpcId)
+		if missingIDs[group.GroupId] || byName {
+			detached = append(detached, awsm.SecurityGroup{
+				GroupId:   group.GroupId,
+				GroupName: group.GroupName,
+				VpcId:     group.VpcId,
+			})
+		}
+	}
+	return detached
+}
+
+// securityGroupDetails describes a live security group in a drift
+func securityGroupDetails(sg awsm.SecurityGroup) map[string]string {
+	return map[string]string{
+		"group_id":   sg.GroupId,
+		"group_name": sg.GroupName,
+		"vpc_id":     sg.VpcId,
+	}
+}
+
+// sortedUnique returns the distinct values in sorted order
+func sortedUnique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,169 @@
+package driftChecker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	awsm "Savannahtakehomeassi/awsd/models"
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+func TestCompareSecurityGroups(t *testing.T) {
+	web := awsm.SecurityGroup{GroupId: "sg-web", GroupName: "web", VpcId: "vpc-1"}
+	ssh := awsm.SecurityGroup{GroupId: "sg-ssh", GroupName: "ssh", VpcId: "vpc-1"}
+
+	tests := []struct {
+		name     string
+		live     []awsm.SecurityGroup
+		detached []awsm.SecurityGroup
+		ids      []string
+		names    []string
+		expected []Drift
+	}{
+		{
+			name:  "no drift",
+			live:  []awsm.SecurityGroup{web},
+			ids:   []string{"sg-web"},
+			names: []string{"web"},
+		},
+		{
+			name: "group attached out of band",
+			live: []awsm.SecurityGroup{web, ssh},
+			ids:  []string{"sg-web"},
+			expected: []Drift{
+				{Attribute: "vpc_security_group_ids", Actual: "sg-ssh", Severity: SeverityHigh, Change: ChangeAdded,
+					Details: map[string]string{"group_id": "sg-ssh", "group_name": "ssh", "vpc_id": "vpc-1"}},
+			},
+		},
+		{
+			name: "group detached out of band",
+			live: []awsm.SecurityGroup{web},
+			ids:  []string{"sg-web", "sg-ssh"},
+			expected: []Drift{
+				{Attribute: "vpc_security_group_ids", Expected: "sg-ssh", Severity: SeverityHigh, Change: ChangeRemoved,
+					Details: map[string]string{"vpc_id": "vpc-1"}},
+			},
+		},
+		{
+			name:     "group detached out of band but still exists",
+			live:     []awsm.SecurityGroup{web},
+			detached: []awsm.SecurityGroup{ssh},
+			ids:      []string{"sg-web", "sg-ssh"},
+			expected: []Drift{
+				{Attribute: "vpc_security_group_ids", Expected: "sg-ssh", Severity: SeverityHigh, Change: ChangeRemoved,
+					Details: map[string]string{"group_id": "sg-ssh", "group_name": "ssh", "vpc_id": "vpc-1"}},
+			},
+		},
+		{
+			name:  "group swapped by name",
+			live:  []awsm.SecurityGroup{ssh},
+			names: []string{"web"},
+			expected: []Drift{
+				{Attribute: "security_groups", Expected: "web", Severity: SeverityHigh, Change: ChangeRemoved,
+					Details: map[string]string{"vpc_id": "vpc-1"}},
+				{Attribute: "security_groups", Actual: "ssh", Severity: SeverityHigh, Change: ChangeAdded,
+					Details: map[string]string{"group_id": "sg-ssh", "group_name": "ssh", "vpc_id": "vpc-1"}},
+			},
+		},
+		{
+			name: "state records no groups",
+			live: []awsm.SecurityGroup{web},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aws := &awsm.AWSInstance{InstanceID: "i-1", VpcId: "vpc-1", SecurityGroups: tt.live}
+			tf := &terafm.Instance{Attributes: terafm.InstanceAttributes{
+				VpcSecurityGroupIDs: tt.ids,
+				SecurityGroups:      tt.names,
+			}}
+
+			ch := make(chan Drift, 10)
+			compareSecurityGroups(context.Background(), aws, tf, tt.detached, ch)
+			close(ch)
+
+			var drifts []Drift
+			for d := range ch {
+				drifts = append(drifts, d)
+			}
+			assert.Equal(t, tt.expected, drifts)
+		})
+	}
+}
+
+func TestDetachedSecurityGroups(t *testing.T) {
+	aws := &awsm.AWSInstance{InstanceID: "i-1", VpcId: "vpc-1", SecurityGroups: []awsm.SecurityGroup{
+		{GroupId: "sg-web", GroupName: "web", VpcId: "vpc-1"},
+	}}
+
+	t.Run("looks up the groups no longer attached", func(t *testing.T) {
+		client := new(MockAWSClient)
+		client.On("GetSecurityGroups").Return([]*awsm.AWSSecurityGroup{
+			{GroupId: "sg-web", GroupName: "web", VpcId: "vpc-1"},
+			{GroupId: "sg-ssh", GroupName: "ssh", VpcId: "vpc-1"},
+			{GroupId: "sg-db", GroupName: "db", VpcId: "vpc-1"},
+			{GroupId: "sg-db-2", GroupName: "db", VpcId: "vpc-2"},
+		}, nil)
+		tf := &terafm.Instance{Attributes: terafm.InstanceAttributes{
+			VpcSecurityGroupIDs: []string{"sg-web", "sg-ssh", "sg-gone"},
+			SecurityGroups:      []string{"web", "db"},
+		}}
+
+		assert.Equal(t, []awsm.SecurityGroup{
+			{GroupId: "sg-ssh", GroupName: "ssh", VpcId: "vpc-1"},
+			{GroupId: "sg-db", GroupName: "db", VpcId: "vpc-1"},
+		}, detachedSecurityGroups(context.Background(), newSecurityGroupLookup(client), zap.NewNop(), aws, tf))
+	})
+
+	t.Run("skips the lookup when every group is attached", func(t *testing.T) {
+		client := new(MockAWSClient)
+		tf := &terafm.Instance{Attributes: terafm.InstanceAttributes{VpcSecurityGroupIDs: []string{"sg-web"}}}
+
+		assert.Nil(t, detachedSecurityGroups(context.Background(), newSecurityGroupLookup(client), zap.NewNop(), aws, tf))
+		client.AssertNotCalled(t, "GetSecurityGroups")
+	})
+
+	t.Run("leaves the groups undescribed when the lookup fails", func(t *testing.T) {
+		client := new(MockAWSClient)
+		client.On("GetSecurityGroups").Return(nil, errors.New("throttled"))
+		tf := &terafm.Instance{Attributes: terafm.InstanceAttributes{VpcSecurityGroupIDs: []string{"sg-ssh"}}}
+
+		assert.Nil(t, detachedSecurityGroups(context.Background(), newSecurityGroupLookup(client), zap.NewNop(), aws, tf))
+	})
+}
+
+func TestInstanceHandler_SecurityGroupsListedOncePerCheck(t *testing.T) {
+	client := new(MockAWSClient)
+	client.On("GetAWSInstances").Return([]*awsm.AWSInstance{
+		{InstanceID: "i-1", VpcId: "vpc-1"},
+		{InstanceID: "i-2", VpcId: "vpc-1"},
+	}, nil)
+	client.On("GetSecurityGroups").Return([]*awsm.AWSSecurityGroup{
+		{GroupId: "sg-ssh", GroupName: "ssh", VpcId: "vpc-1"},
+	}, nil)
+	h := NewInstanceHandler(client, zap.NewNop())
+
+	check := func() {
+		live, err := h.FetchLive(context.Background())
+		require.NoError(t, err)
+		for _, l := range live {
+			entry := &StateEntry{ID: l.ID, Resource: &terafm.Resource{Type: "aws_instance", Name: l.ID}, Instance: &terafm.Instance{Attributes: terafm.InstanceAttributes{
+				InstanceID:          l.ID,
+				VpcSecurityGroupIDs: []string{"sg-ssh"},
+			}}}
+			_, err := h.Compare(context.Background(), l, entry, &terafm.Config{})
+			require.NoError(t, err)
+		}
+	}
+
+	check()
+	client.AssertNumberOfCalls(t, "GetSecurityGroups", 1)
+	check()
+	client.AssertNumberOfCalls(t, "GetSecurityGroups", 2)
+}
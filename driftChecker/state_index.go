@@ -0,0 +1,63 @@
+package driftChecker
+
+import (
+	"go.uber.org/zap"
+)
+
+// stateIndex looks up the state entries of one resource type by AWS ID
+type stateIndex struct {
+	byID    map[string]*StateEntry
+	entries []*StateEntry
+}
+
+// newStateIndex indexes state entries by ID. When two entries claim the same ID the
+// first one wins.
+func newStateIndex(entries []*StateEntry) *stateIndex {
+	logger := zap.L().With(
+		zap.String("function", "newStateIndex"),
+	)
+
+	idx := &stateIndex{
+		byID:    make(map[string]*StateEntry),
+		entries: entries,
+	}
+	for _, entry := range entries {
+		if entry.ID == "" {
+			continue
+		}
+		if existing, ok := idx.byID[entry.ID]; ok {
+			logger.Warn("Resource ID claimed by more than one Terraform resource",
+				zap.String("operation", "state_index"),
+				zap.String("resource_id", entry.ID),
+				zap.String("address", entry.Address),
+				zap.String("existing_address", existing.Address),
+			)
+			continue
+		}
+		idx.byID[entry.ID] = entry
+	}
+
+	logger.Info("Terraform state indexed",
+		zap.String("operation", "state_index"),
+		zap.Int("resource_count", len(idx.byID)),
+	)
+	return idx
+}
+
+// lookup returns the state entry that manages the live resource with the given ID, or nil
+func (idx *stateIndex) lookup(id string) *StateEntry {
+	return idx.byID[id]
+}
+
+// missing returns the indexed state entries with no live resource, ordered as they
+// appear in the state. Entries without an ID can't be checked and are skipped.
+func (idx *stateIndex) missing(liveIDs map[string]bool) []*StateEntry {
+	var missing []*StateEntry
+	for _, entry := range idx.entries {
+		if entry.ID == "" || liveIDs[entry.ID] {
+			continue
+		}
+		missing = append(missing, entry)
+	}
+	return missing
+}
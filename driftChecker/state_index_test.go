@@ -0,0 +1,162 @@
+package driftChecker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	terafm "Savannahtakehomeassi/teraform/models"
+)
+
+func TestStateIndex_Lookup(t *testing.T) {
+	tfState := &terafm.TerraformState{
+		Resources: []terafm.Resource{
+			{
+				Type: "aws_security_group",
+				Name: "web",
+				Instances: []terafm.Instance{
+					{Attributes: terafm.InstanceAttributes{InstanceID: "sg-123"}},
+				},
+			},
+			{
+				Type: "aws_instance",
+				Name: "web",
+				Instances: []terafm.Instance{
+					{IndexKey: float64(0), Attributes: terafm.InstanceAttributes{InstanceID: "i-web0", InstanceType: "t2.micro"}},
+					{IndexKey: float64(1), Attributes: terafm.InstanceAttributes{InstanceID: "i-web1", InstanceType: "t2.large"}},
+				},
+			},
+			{
+				Type: "aws_instance",
+				Name: "app",
+				Instances: []terafm.Instance{
+					{IndexKey: "blue", Attributes: terafm.InstanceAttributes{ARN: "arn:aws:ec2:us-east-1:000000000000:instance/i-blue"}},
+				},
+			},
+			{
+				Type: "aws_instance",
+				Name: "db",
+				Instances: []terafm.Instance{
+					{Attributes: terafm.InstanceAttributes{InstanceID: "i-db"}},
+				},
+			},
+			{
+				Module:   "module.app",
+				Mode:     terafm.ModeManaged,
+				Type:     "aws_instance",
+				Name:     "web",
+				EachMode: "map",
+				Instances: []terafm.Instance{
+					{IndexKey: "blue", Attributes: terafm.InstanceAttributes{InstanceID: "i-module-blue"}},
+				},
+			},
+			{
+				Mode: terafm.ModeData,
+				Type: "aws_instance",
+				Name: "lookup",
+				Instances: []terafm.Instance{
+					{Attributes: terafm.InstanceAttributes{InstanceID: "i-data"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		instanceID      string
+		expectMatch     bool
+		expectedAddress string
+		expectedType    string
+	}{
+		{
+			name:            "count index 0",
+			instanceID:      "i-web0",
+			expectMatch:     true,
+			expectedAddress: "aws_instance.web[0]",
+			expectedType:    "t2.micro",
+		},
+		{
+			name:            "count index 1",
+			instanceID:      "i-web1",
+			expectMatch:     true,
+			expectedAddress: "aws_instance.web[1]",
+			expectedType:    "t2.large",
+		},
+		{
+			name:            "ARN fallback with for_each key",
+			instanceID:      "i-blue",
+			expectMatch:     true,
+			expectedAddress: `aws_instance.app["blue"]`,
+		},
+		{
+			name:            "single instance",
+			instanceID:      "i-db",
+			expectMatch:     true,
+			expectedAddress: "aws_instance.db",
+		},
+		{
+			name:            "child module resource",
+			instanceID:      "i-module-blue",
+			expectMatch:     true,
+			expectedAddress: `module.app.aws_instance.web["blue"]`,
+		},
+		{
+			name:        "data sources are ignored",
+			instanceID:  "i-data",
+			expectMatch: false,
+		},
+		{
+			name:        "non aws_instance resources are ignored",
+			instanceID:  "sg-123",
+			expectMatch: false,
+		},
+		{
+			name:        "unknown instance",
+			instanceID:  "i-unknown",
+			expectMatch: false,
+		},
+	}
+
+	index := newStateIndex(NewInstanceHandler(nil, zap.NewNop()).MapState(tfState))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := index.lookup(tt.instanceID)
+			if !tt.expectMatch {
+				assert.Nil(t, match)
+				return
+			}
+			require.NotNil(t, match)
+			assert.Equal(t, tt.expectedAddress, match.Address)
+			if tt.expectedType != "" {
+				assert.Equal(t, tt.expectedType, match.Instance.Attributes.InstanceType)
+			}
+		})
+	}
+}
+
+func TestStateIndex_NilState(t *testing.T) {
+	index := newStateIndex(NewInstanceHandler(nil, zap.NewNop()).MapState(nil))
+	assert.Nil(t, index.lookup("i-12345"))
+}
+
+func TestStateIndex_Missing(t *testing.T) {
+	index := newStateIndex([]*StateEntry{
+		{Address: "aws_instance.a", ID: "i-a"},
+		{Address: "aws_instance.b", ID: "i-b"},
+		{Address: "aws_instance.unknown"},
+		{Address: "aws_instance.duplicate", ID: "i-a"},
+	})
+
+	assert.Equal(t, "aws_instance.a", index.lookup("i-a").Address, "first entry wins")
+	missing := index.missing(map[string]bool{"i-a": true})
+	require.Len(t, missing, 1)
+	assert.Equal(t, "aws_instance.b", missing[0].Address)
+}
+
+func TestInstanceIDFromARN(t *testing.T) {
+	assert.Equal(t, "i-0b0f62398bf34f224", instanceIDFromARN("arn:aws:ec2:us-east-1::instance/i-0b0f62398bf34f224"))
+	assert.Equal(t, "", instanceIDFromARN("arn:aws:ec2:us-east-1::volume/vol-123"))
+	assert.Equal(t, "", instanceIDFromARN(""))
+}
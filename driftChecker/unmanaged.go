@@ -0,0 +1,47 @@
+package driftChecker
+
+import "path"
+
+// UnmanagedFilter excludes known exceptions from the unmanaged resource report, e.g.
+// bastion hosts or instances launched by an autoscaling group
+type UnmanagedFilter struct {
+	// IgnoreTags skips resources carrying any of these tags. An empty value matches
+	// every value of the key.
+	IgnoreTags map[string]string
+	// IgnoreNames skips resources whose Name tag or ID matches one of these
+	// path.Match patterns, e.g. "eks-node-*"
+	IgnoreNames []string
+}
+
+// Ignores reports whether the filter excludes the live resource with the given ID and tags
+func (f UnmanagedFilter) Ignores(resourceID string, tags map[string]string) bool {
+	for key, want := range f.IgnoreTags {
+		if value, ok := tags[key]; ok && (want == "" || value == want) {
+			return true
+		}
+	}
+
+	name := tags["Name"]
+	for _, pattern := range f.IgnoreNames {
+		if matched, _ := path.Match(pattern, resourceID); matched {
+			return true
+		}
+		if name == "" {
+			continue
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Option configures optional DriftService behaviour
+type Option func(*DriftService)
+
+// WithUnmanagedFilter sets the filter applied to unmanaged live resources
+func WithUnmanagedFilter(filter UnmanagedFilter) Option {
+	return func(s *DriftService) {
+		s.unmanagedFilter = filter
+	}
+}
@@ -15,6 +15,12 @@ const (
 	// AWS errors
 	ErrAWSClient   ErrorType = "AWS_CLIENT_ERROR"
 	ErrAWSInstance ErrorType = "AWS_INSTANCE_ERROR"
+	ErrAWSVolume   ErrorType = "AWS_VOLUME_ERROR"
+
+	ErrAWSSecurityGroup ErrorType = "AWS_SECURITY_GROUP_ERROR"
+	ErrAWSNetwork       ErrorType = "AWS_NETWORK_ERROR"
+	ErrAWSS3            ErrorType = "AWS_S3_ERROR"
+	ErrAWSIAM           ErrorType = "AWS_IAM_ERROR"
 
 	// Terraform errors
 	ErrTerraformState  ErrorType = "TERRAFORM_STATE_ERROR"
@@ -14,6 +14,11 @@ var (
 
 // Initialize sets up the logger with the specified log level
 func Initialize(level string) error {
+	return InitializeWithOutput(level, os.Stdout)
+}
+
+// InitializeWithOutput sets up the logger with the specified log level, writing to out
+func InitializeWithOutput(level string, out zapcore.WriteSyncer) error {
 	// Create a custom encoder config
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
@@ -32,10 +37,10 @@ func Initialize(level string) error {
 	// Create a console encoder
 	consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
 
-	// Create a core that writes to stdout
+	// Create a core that writes to out
 	core := zapcore.NewCore(
 		consoleEncoder,
-		zapcore.AddSync(os.Stdout),
+		out,
 		zap.NewAtomicLevelAt(getLogLevel(level)),
 	)
 
@@ -0,0 +1,308 @@
+package teraform
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/teraform/models"
+)
+
+const (
+	// envVarPrefix is the prefix Terraform uses for variables set in the environment
+	envVarPrefix = "TF_VAR_"
+)
+
+// evalFunctions are the pure Terraform functions available while evaluating the config
+var evalFunctions = map[string]function.Function{
+	"concat":   stdlib.ConcatFunc,
+	"format":   stdlib.FormatFunc,
+	"lookup":   stdlib.LookupFunc,
+	"merge":    stdlib.MergeFunc,
+	"tobool":   stdlib.MakeToFunc(cty.Bool),
+	"tolist":   stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+	"tomap":    stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+	"tonumber": stdlib.MakeToFunc(cty.Number),
+	"toset":    stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+	"tostring": stdlib.MakeToFunc(cty.String),
+}
+
+// newEvalContext resolves the config's variables and locals and returns the context
+// used to evaluate resource attributes. Variable values come from, lowest precedence
+// first: defaults, TF_VAR_ environment variables, terraform.tfvars(.json) and
+// *.auto.tfvars(.json) files in varsDir.
+func newEvalContext(config *models.Config, varsDir string) (*hcl.EvalContext, hcl.Diagnostics) {
+	vars, diags := resolveVariables(config.Variables, varsDir)
+	config.VariableValues = vars
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(vars),
+		},
+		Functions: evalFunctions,
+	}
+
+	config.LocalValues = resolveLocals(config.Locals, ctx)
+	ctx.Variables["local"] = cty.ObjectVal(config.LocalValues)
+	return ctx, diags
+}
+
+// resolveVariables computes the value of every declared variable. Variables without
+// any value are unknown.
+func resolveVariables(blocks []models.VariableBlock, varsDir string) (map[string]cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	types := make(map[string]cty.Type, len(blocks))
+	values := make(map[string]cty.Value, len(blocks))
+
+	for _, block := range blocks {
+		content, _, contentDiags := block.Body.PartialContent(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{{Name: "default"}, {Name: "type"}},
+		})
+		diags = append(diags, contentDiags...)
+
+		types[block.Name] = cty.DynamicPseudoType
+		if attr, ok := content.Attributes["type"]; ok {
+			typ, typeDiags := typeexpr.TypeConstraint(attr.Expr)
+			diags = append(diags, typeDiags...)
+			if !typeDiags.HasErrors() {
+				types[block.Name] = typ
+			}
+		}
+		if attr, ok := content.Attributes["default"]; ok {
+			value, valueDiags := attr.Expr.Value(nil)
+			diags = append(diags, valueDiags...)
+			if !valueDiags.HasErrors() {
+				values[block.Name] = value
+			}
+		}
+	}
+
+	for name, raw := range environmentVariables() {
+		if typ, ok := types[name]; ok {
+			values[name] = parseEnvironmentValue(raw, typ)
+		}
+	}
+
+	for _, path := range tfvarsFiles(varsDir) {
+		fileValues, fileDiags := readTFVarsFile(path)
+		diags = append(diags, fileDiags...)
+		for name, value := range fileValues {
+			if _, ok := types[name]; ok {
+				values[name] = value
+			}
+		}
+	}
+
+	for name, typ := range types {
+		value, ok := values[name]
+		if !ok {
+			values[name] = cty.DynamicVal
+			continue
+		}
+		if converted, err := convert.Convert(value, typ); err == nil {
+			values[name] = converted
+		}
+	}
+	return values, diags
+}
+
+// environmentVariables returns the TF_VAR_ environment variables keyed by variable name
+func environmentVariables() map[string]string {
+	result := make(map[string]string)
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, envVarPrefix) {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(env, envVarPrefix), "=")
+		if ok && name != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// parseEnvironmentValue interprets a TF_VAR_ value the way Terraform does: as a raw
+// string for primitive types and as an HCL expression for complex ones
+func parseEnvironmentValue(raw string, typ cty.Type) cty.Value {
+	if typ.IsPrimitiveType() || typ == cty.DynamicPseudoType {
+		return cty.StringVal(raw)
+	}
+	expr, diags := hclsyntax.ParseExpression([]byte(raw), envVarPrefix+"value", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.DynamicVal
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.DynamicVal
+	}
+	return value
+}
+
+// tfvarsFiles lists the variable files Terraform loads automatically, in precedence order
+func tfvarsFiles(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+
+	var files []string
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	var auto []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && (strings.HasSuffix(name, ".auto.tfvars") || strings.HasSuffix(name, ".auto.tfvars.json")) {
+			auto = append(auto, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(auto)
+	return append(files, auto...)
+}
+
+// readTFVarsFile reads the literal values of a .tfvars or .tfvars.json file
+func readTFVarsFile(path string) (map[string]cty.Value, hcl.Diagnostics) {
+	parser := hclparse.NewParser()
+	file, diags := parseConfigFile(parser, path)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs, attrDiags := file.Body.JustAttributes()
+	diags = append(diags, attrDiags...)
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		value, valueDiags := attr.Expr.Value(nil)
+		diags = append(diags, valueDiags...)
+		if !valueDiags.HasErrors() {
+			values[name] = value
+		}
+	}
+	return values, diags
+}
+
+// resolveLocals evaluates local values in dependency order. Locals that reference
+// something unresolvable, or take part in a cycle, are unknown.
+func resolveLocals(blocks []models.LocalsBlock, ctx *hcl.EvalContext) map[string]cty.Value {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "resolveLocals"),
+	)
+
+	pending := make(map[string]hcl.Expression)
+	for _, block := range blocks {
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			continue
+		}
+		for name, attr := range attrs {
+			pending[name] = attr.Expr
+		}
+	}
+
+	values := make(map[string]cty.Value, len(pending))
+	for len(pending) > 0 {
+		progressed := false
+		for name, expr := range pending {
+			if !localDependenciesResolved(expr, values) {
+				continue
+			}
+			child := ctx.NewChild()
+			child.Variables = map[string]cty.Value{"local": cty.ObjectVal(values)}
+			values[name] = evaluate(expr, child)
+			delete(pending, name)
+			progressed = true
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	for name := range pending {
+		logger.Warn("Local value could not be resolved",
+			zap.String("operation", "locals_resolve"),
+			zap.String("local", name),
+		)
+		values[name] = cty.DynamicVal
+	}
+	return values
+}
+
+// localDependenciesResolved reports whether every local.X reference in expr has a value
+func localDependenciesResolved(expr hcl.Expression, values map[string]cty.Value) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		if _, ok := values[attr.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate returns the value of expr. References the context can't resolve, such as
+// other resources or data sources, evaluate to unknown so that the rest of the value
+// is kept; an expression that fails outright is entirely unknown.
+func evaluate(expr hcl.Expression, ctx *hcl.EvalContext) cty.Value {
+	value, diags := expr.Value(withUnknownReferences(expr, ctx))
+	if diags.HasErrors() {
+		return cty.DynamicVal
+	}
+	return value
+}
+
+// withUnknownReferences returns a child of ctx in which every root name referenced by
+// expr that ctx doesn't define is bound to an unknown value
+func withUnknownReferences(expr hcl.Expression, ctx *hcl.EvalContext) *hcl.EvalContext {
+	unknown := make(map[string]cty.Value)
+	for _, traversal := range expr.Variables() {
+		root := traversal.RootName()
+		if !contextDefines(ctx, root) {
+			unknown[root] = cty.DynamicVal
+		}
+	}
+	if len(unknown) == 0 {
+		return ctx
+	}
+
+	if ctx == nil {
+		ctx = &hcl.EvalContext{}
+	}
+	child := ctx.NewChild()
+	child.Variables = unknown
+	return child
+}
+
+// contextDefines reports whether ctx or one of its parents defines the variable name
+func contextDefines(ctx *hcl.EvalContext, name string) bool {
+	for ; ctx != nil; ctx = ctx.Parent() {
+		if _, ok := ctx.Variables[name]; ok {
+			return true
+		}
+	}
+	return false
+}
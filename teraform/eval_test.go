@@ -0,0 +1,223 @@
+package teraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParseHCLConfig_Evaluation(t *testing.T) {
+	client := NewTerraformClient()
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		env      map[string]string
+		expected map[string]string
+		unknown  []string
+		tags     map[string]string
+	}{
+		{
+			name: "Variable defaults",
+			files: map[string]string{
+				"main.tf": `
+variable "ami_id" {
+  default = "ami-default"
+}
+
+resource "aws_instance" "web" {
+  ami           = var.ami_id
+  instance_type = "t2.micro"
+}
+`,
+			},
+			expected: map[string]string{"ami": "ami-default", "instance_type": "t2.micro"},
+		},
+		{
+			name: "tfvars precedence",
+			files: map[string]string{
+				"main.tf": `
+variable "ami_id" {
+  default = "ami-default"
+}
+variable "instance_type" {
+  type    = string
+  default = "t2.nano"
+}
+variable "env" {}
+
+resource "aws_instance" "web" {
+  ami           = var.ami_id
+  instance_type = var.instance_type
+  tags = {
+    Env = var.env
+  }
+}
+`,
+				"terraform.tfvars":      `ami_id = "ami-tfvars"`,
+				"b.auto.tfvars":         `instance_type = "t3.large"`,
+				"a.auto.tfvars.json":    `{"instance_type": "t3.small", "ami_id": "ami-auto"}`,
+				"unrelated.tfvars":      `ami_id = "ami-ignored"`,
+				"terraform.tfvars.json": `{"env": "json"}`,
+			},
+			env:      map[string]string{"TF_VAR_env": "from-env", "TF_VAR_ami_id": "ami-env"},
+			expected: map[string]string{"ami": "ami-auto", "instance_type": "t3.large"},
+			tags:     map[string]string{"Env": "json"},
+		},
+		{
+			name: "Environment variables",
+			files: map[string]string{
+				"main.tf": `
+variable "ami_id" {}
+variable "extra_tags" {
+  type = map(string)
+}
+
+resource "aws_instance" "web" {
+  ami           = var.ami_id
+  instance_type = "t2.micro"
+  tags          = var.extra_tags
+}
+`,
+			},
+			env:      map[string]string{"TF_VAR_ami_id": "ami-env", "TF_VAR_extra_tags": `{ Team = "ops" }`},
+			expected: map[string]string{"ami": "ami-env"},
+			tags:     map[string]string{"Team": "ops"},
+		},
+		{
+			name: "Locals and functions",
+			files: map[string]string{
+				"main.tf": `
+variable "env" {
+  default = "prod"
+}
+variable "sizes" {
+  default = {
+    prod = "m5.large"
+    dev  = "t2.micro"
+  }
+}
+
+locals {
+  name        = format("web-%s", local.env_suffix)
+  env_suffix  = var.env
+  common_tags = merge(tomap({ Env = var.env }), { Name = local.name })
+  sg_ids      = concat(["sg-1"], ["sg-2"])
+}
+
+resource "aws_instance" "web" {
+  ami                    = "ami-123"
+  instance_type          = lookup(var.sizes, var.env, "t2.micro")
+  vpc_security_group_ids = local.sg_ids
+  tags                   = local.common_tags
+}
+`,
+			},
+			expected: map[string]string{"ami": "ami-123", "instance_type": "m5.large"},
+			tags:     map[string]string{"Env": "prod", "Name": "web-prod"},
+		},
+		{
+			name: "Unresolvable attributes are unknown",
+			files: map[string]string{
+				"main.tf": `
+variable "ami_id" {}
+
+locals {
+  a = local.b
+  b = local.a
+}
+
+resource "aws_instance" "web" {
+  ami           = var.ami_id
+  instance_type = local.a
+  subnet_id     = aws_subnet.main.id
+  tags = {
+    Name  = "web"
+    Owner = data.aws_caller_identity.current.account_id
+  }
+}
+`,
+			},
+			expected: map[string]string{},
+			unknown:  []string{"ami", "instance_type"},
+			tags:     map[string]string{"Name": "web"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+
+			// Single file mode resolves tfvars next to the file, just like module mode
+			for _, path := range []string{dir, filepath.Join(dir, "main.tf")} {
+				config, err := client.ParseHCLConfig(context.Background(), path)
+				require.NoError(t, err)
+
+				resource := config.FindResource("aws_instance", "web")
+				require.NotNil(t, resource)
+				instance := resource.TFInstance()
+
+				if v, ok := tc.expected["ami"]; ok {
+					assert.Equal(t, v, instance.AMI)
+				}
+				if v, ok := tc.expected["instance_type"]; ok {
+					assert.Equal(t, v, instance.InstanceType)
+				}
+				for _, attr := range tc.unknown {
+					assert.True(t, instance.IsUnknown(attr), "expected %s to be unknown", attr)
+				}
+				if tc.tags != nil {
+					assert.Equal(t, tc.tags, instance.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestParseHCLConfig_UnknownValues(t *testing.T) {
+	client := NewTerraformClient()
+	tmpFile := writeTempFile(t, `
+resource "aws_instance" "web" {
+  ami       = "ami-123"
+  subnet_id = aws_subnet.main.id
+  tags = {
+    Owner = data.aws_caller_identity.current.account_id
+  }
+}
+`)
+
+	config, err := client.ParseHCLConfig(context.Background(), tmpFile)
+	require.NoError(t, err)
+	resource := config.FindResource("aws_instance", "web")
+	require.NotNil(t, resource)
+
+	assert.Equal(t, cty.StringVal("ami-123"), resource.Attributes["ami"].Value)
+	assert.False(t, resource.Attributes["subnet_id"].Value.IsKnown())
+	assert.True(t, resource.TFInstance().IsUnknown("tags.Owner"))
+	assert.False(t, resource.TFInstance().IsUnknown("ami"))
+}
+
+func TestParseHCLConfig_InvalidTFVars(t *testing.T) {
+	client := NewTerraformClient()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`
+variable "ami_id" {}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte(`ami_id = `), 0644))
+
+	config, err := client.ParseHCLConfig(context.Background(), dir)
+	require.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "terraform.tfvars:1")
+}
@@ -0,0 +1,159 @@
+package teraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+	"go.uber.org/zap"
+
+	"Savannahtakehomeassi/teraform/models"
+)
+
+// expandResource expands the resource's count or for_each meta-argument into one
+// instance per index key, decoding each with count.index or each.key/each.value set.
+// A resource without either meta-argument has a single instance with a nil key. When
+// the meta-argument can't be resolved no instances are returned.
+func expandResource(resource *models.ResourceBlock, ctx *hcl.EvalContext) ([]*models.ResourceInstance, hcl.Diagnostics) {
+	countAttr, hasCount := resource.Attributes["count"]
+	forEachAttr, hasForEach := resource.Attributes["for_each"]
+
+	switch {
+	case hasCount && hasForEach:
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid combination of \"count\" and \"for_each\"",
+			Detail:   fmt.Sprintf("The count and for_each meta-arguments are mutually-exclusive, only one should be used in %s.", resource.Address()),
+			Subject:  &forEachAttr.Range,
+		}}
+	case hasCount:
+		return expandCount(resource, countAttr, ctx)
+	case hasForEach:
+		return expandForEach(resource, forEachAttr, ctx)
+	default:
+		return []*models.ResourceInstance{{
+			Address:    resource.Address(),
+			Attributes: resource.Attributes,
+			Blocks:     resource.Blocks,
+		}}, nil
+	}
+}
+
+// expandCount creates count instances of the resource, indexed from zero
+func expandCount(resource *models.ResourceBlock, attr *models.Attribute, ctx *hcl.EvalContext) ([]*models.ResourceInstance, hcl.Diagnostics) {
+	if !attr.Value.IsKnown() {
+		logUnexpanded(resource, "count")
+		return nil, nil
+	}
+
+	var count int
+	value, err := convert.Convert(attr.Value, cty.Number)
+	if err == nil && !value.IsNull() {
+		err = gocty.FromCtyValue(value, &count)
+	}
+	if err != nil || value.IsNull() || count < 0 {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid count argument",
+			Detail:   "The given \"count\" argument value is unsuitable: must be a whole number greater than or equal to zero.",
+			Subject:  &attr.Range,
+		}}
+	}
+
+	var diags hcl.Diagnostics
+	instances := make([]*models.ResourceInstance, 0, count)
+	for i := 0; i < count; i++ {
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"count": cty.ObjectVal(map[string]cty.Value{
+				"index": cty.NumberIntVal(int64(i)),
+			}),
+		}
+		instance, instanceDiags := decodeInstance(resource, i, child)
+		diags = append(diags, instanceDiags...)
+		instances = append(instances, instance)
+	}
+	return instances, diags
+}
+
+// expandForEach creates one instance of the resource per element of a map or set of strings
+func expandForEach(resource *models.ResourceBlock, attr *models.Attribute, ctx *hcl.EvalContext) ([]*models.ResourceInstance, hcl.Diagnostics) {
+	if !attr.Value.IsWhollyKnown() {
+		logUnexpanded(resource, "for_each")
+		return nil, nil
+	}
+
+	invalid := hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid for_each argument",
+		Detail:   "The given \"for_each\" argument value is unsuitable: the \"for_each\" argument must be a map, or set of strings.",
+		Subject:  &attr.Range,
+	}}
+
+	value := attr.Value
+	if value.IsNull() {
+		return nil, invalid
+	}
+
+	// Elements in key order; a set of strings uses each string as both key and value
+	var keys []string
+	elements := make(map[string]cty.Value)
+	switch typ := value.Type(); {
+	case typ.IsMapType() || typ.IsObjectType():
+		for it := value.ElementIterator(); it.Next(); {
+			key, val := it.Element()
+			keys = append(keys, key.AsString())
+			elements[key.AsString()] = val
+		}
+	case typ.IsSetType() && typ.ElementType() == cty.String:
+		for it := value.ElementIterator(); it.Next(); {
+			_, val := it.Element()
+			if val.IsNull() {
+				return nil, invalid
+			}
+			keys = append(keys, val.AsString())
+			elements[val.AsString()] = val
+		}
+	default:
+		return nil, invalid
+	}
+
+	var diags hcl.Diagnostics
+	instances := make([]*models.ResourceInstance, 0, len(keys))
+	for _, name := range keys {
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{
+				"key":   cty.StringVal(name),
+				"value": elements[name],
+			}),
+		}
+		instance, instanceDiags := decodeInstance(resource, name, child)
+		diags = append(diags, instanceDiags...)
+		instances = append(instances, instance)
+	}
+	return instances, diags
+}
+
+// decodeInstance decodes the resource body for a single index key
+func decodeInstance(resource *models.ResourceBlock, key interface{}, ctx *hcl.EvalContext) (*models.ResourceInstance, hcl.Diagnostics) {
+	attrs, blocks, diags := decodeBody(resource.Body, ctx)
+	return &models.ResourceInstance{
+		Address:    resource.Address() + models.FormatIndexKey(key),
+		IndexKey:   key,
+		Attributes: attrs,
+		Blocks:     blocks,
+	}, diags
+}
+
+// logUnexpanded records a resource whose instances can't be known from the config alone
+func logUnexpanded(resource *models.ResourceBlock, metaArgument string) {
+	zap.L().Warn("Resource meta-argument could not be resolved, instances not expanded",
+		zap.String("package", packageName),
+		zap.String("function", "expandResource"),
+		zap.String("address", resource.Address()),
+		zap.String("meta_argument", metaArgument),
+	)
+}
@@ -0,0 +1,270 @@
+package teraform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHCLConfig_Expansion(t *testing.T) {
+	client := NewTerraformClient()
+
+	tests := []struct {
+		name      string
+		content   string
+		addresses []string
+		types     map[string]string
+		tags      map[string]map[string]string
+	}{
+		{
+			name: "No meta-arguments",
+			content: `
+resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+}
+`,
+			addresses: []string{"aws_instance.web"},
+			types:     map[string]string{"aws_instance.web": "t2.micro"},
+		},
+		{
+			name: "Count with count.index",
+			content: `
+resource "aws_instance" "web" {
+  count         = 2
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+  tags = {
+    Name = "web-${count.index}"
+  }
+}
+`,
+			addresses: []string{"aws_instance.web[0]", "aws_instance.web[1]"},
+			tags: map[string]map[string]string{
+				"aws_instance.web[0]": {"Name": "web-0"},
+				"aws_instance.web[1]": {"Name": "web-1"},
+			},
+		},
+		{
+			name: "Count from variable",
+			content: `
+variable "replicas" {
+  type    = number
+  default = 3
+}
+
+resource "aws_instance" "web" {
+  count         = var.replicas
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+}
+`,
+			addresses: []string{"aws_instance.web[0]", "aws_instance.web[1]", "aws_instance.web[2]"},
+		},
+		{
+			name: "Zero count",
+			content: `
+resource "aws_instance" "web" {
+  count         = 0
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+}
+`,
+			addresses: []string{},
+		},
+		{
+			name: "for_each over a map",
+			content: `
+resource "aws_instance" "web" {
+  for_each = {
+    a = "t2.micro"
+    b = "t3.large"
+  }
+  ami           = "ami-123"
+  instance_type = each.value
+  tags = {
+    Name = each.key
+  }
+}
+`,
+			addresses: []string{`aws_instance.web["a"]`, `aws_instance.web["b"]`},
+			types: map[string]string{
+				`aws_instance.web["a"]`: "t2.micro",
+				`aws_instance.web["b"]`: "t3.large",
+			},
+			tags: map[string]map[string]string{
+				`aws_instance.web["a"]`: {"Name": "a"},
+				`aws_instance.web["b"]`: {"Name": "b"},
+			},
+		},
+		{
+			name: "for_each over a set of strings",
+			content: `
+locals {
+  names = ["blue", "green"]
+}
+
+resource "aws_instance" "web" {
+  for_each      = toset(local.names)
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+  tags = {
+    Name = each.value
+  }
+}
+`,
+			addresses: []string{`aws_instance.web["blue"]`, `aws_instance.web["green"]`},
+			tags: map[string]map[string]string{
+				`aws_instance.web["blue"]`:  {"Name": "blue"},
+				`aws_instance.web["green"]`: {"Name": "green"},
+			},
+		},
+		{
+			name: "Unknown count is not expanded",
+			content: `
+resource "aws_instance" "web" {
+  count         = length(data.aws_subnets.all.ids)
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+}
+`,
+			addresses: []string{},
+		},
+		{
+			name: "Unknown for_each is not expanded",
+			content: `
+resource "aws_instance" "web" {
+  for_each      = data.aws_subnets.all.ids
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+}
+`,
+			addresses: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := client.ParseHCLConfig(context.Background(), writeTempFile(t, tt.content))
+			require.NoError(t, err)
+
+			resource := config.FindResource("aws_instance", "web")
+			require.NotNil(t, resource)
+
+			addresses := make([]string, 0, len(resource.Instances))
+			for _, instance := range resource.Instances {
+				addresses = append(addresses, instance.Address)
+			}
+			assert.Equal(t, tt.addresses, addresses)
+
+			for _, instance := range resource.Instances {
+				tfInstance := instance.TFInstance()
+				assert.Equal(t, instance.Address, tfInstance.Address)
+				if expected, ok := tt.types[instance.Address]; ok {
+					assert.Equal(t, expected, tfInstance.InstanceType)
+				}
+				if expected, ok := tt.tags[instance.Address]; ok {
+					assert.Equal(t, expected, tfInstance.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestParseHCLConfig_InvalidExpansion(t *testing.T) {
+	client := NewTerraformClient()
+
+	tests := []struct {
+		name     string
+		content  string
+		errorMsg string
+	}{
+		{
+			name: "count and for_each together",
+			content: `
+resource "aws_instance" "web" {
+  count         = 1
+  for_each      = toset(["a"])
+  instance_type = "t2.micro"
+}
+`,
+			errorMsg: "Invalid combination of \"count\" and \"for_each\"",
+		},
+		{
+			name: "Negative count",
+			content: `
+resource "aws_instance" "web" {
+  count         = -1
+  instance_type = "t2.micro"
+}
+`,
+			errorMsg: "Invalid count argument",
+		},
+		{
+			name: "Non-numeric count",
+			content: `
+resource "aws_instance" "web" {
+  count         = "many"
+  instance_type = "t2.micro"
+}
+`,
+			errorMsg: "Invalid count argument",
+		},
+		{
+			name: "for_each over a list",
+			content: `
+resource "aws_instance" "web" {
+  for_each      = ["a", "b"]
+  instance_type = "t2.micro"
+}
+`,
+			errorMsg: "Invalid for_each argument",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.ParseHCLConfig(context.Background(), writeTempFile(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestResourceBlock_FindInstance(t *testing.T) {
+	client := NewTerraformClient()
+
+	config, err := client.ParseHCLConfig(context.Background(), writeTempFile(t, `
+resource "aws_instance" "counted" {
+  count         = 2
+  instance_type = "t2.micro"
+}
+
+resource "aws_instance" "keyed" {
+  for_each      = toset(["a"])
+  instance_type = "t2.micro"
+}
+
+resource "aws_instance" "single" {
+  instance_type = "t2.micro"
+}
+`))
+	require.NoError(t, err)
+
+	// Index keys as decoded from the state JSON
+	counted := config.FindResource("aws_instance", "counted")
+	require.NotNil(t, counted.FindInstance(float64(1)))
+	assert.Equal(t, "aws_instance.counted[1]", counted.FindInstance(float64(1)).Address)
+	assert.Nil(t, counted.FindInstance(float64(2)))
+
+	keyed := config.FindResource("aws_instance", "keyed")
+	require.NotNil(t, keyed.FindInstance("a"))
+	assert.Equal(t, `aws_instance.keyed["a"]`, keyed.FindInstance("a").Address)
+	assert.Nil(t, keyed.FindInstance(nil))
+
+	single := config.FindResource("aws_instance", "single")
+	require.NotNil(t, single.FindInstance(nil))
+	assert.Equal(t, "aws_instance.single", single.FindInstance(nil).Address)
+}
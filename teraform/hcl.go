@@ -0,0 +1,167 @@
+package teraform
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"Savannahtakehomeassi/errors"
+	"Savannahtakehomeassi/teraform/models"
+)
+
+// isConfigFile reports whether name is a Terraform configuration file
+func isConfigFile(name string) bool {
+	return strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json")
+}
+
+// parseConfigFile parses a native syntax or JSON configuration file
+func parseConfigFile(parser *hclparse.Parser, path string) (*hcl.File, hcl.Diagnostics) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to read file",
+			Detail:   fmt.Sprintf("The configuration file %q could not be read: %s.", path, err),
+		}}
+	}
+	if strings.HasSuffix(path, ".json") {
+		return parser.ParseJSON(src, path)
+	}
+	return parser.ParseHCL(src, path)
+}
+
+// decodeConfig merges the given files into one module and decodes its resource blocks,
+// evaluating their attributes with the variables, tfvars and locals found for varsDir
+func decodeConfig(files []*hcl.File, path, varsDir string) (*models.Config, error) {
+	var config models.Config
+	diags := gohcl.DecodeBody(hcl.MergeFiles(files), nil, &config)
+	if diags.HasErrors() {
+		return nil, diagnosticsError("failed to decode HCL config", "hcl_decode", path, diags)
+	}
+
+	ctx, diags := newEvalContext(&config, varsDir)
+	if diags.HasErrors() {
+		return nil, diagnosticsError("failed to resolve HCL variables", "variable_resolve", path, diags)
+	}
+
+	declared := make(map[string]*models.ResourceBlock, len(config.Resources))
+	for i := range config.Resources {
+		resource := &config.Resources[i]
+		var bodyDiags hcl.Diagnostics
+		resource.Attributes, resource.Blocks, bodyDiags = decodeBody(resource.Body, ctx)
+		if bodyDiags.HasErrors() {
+			return nil, diagnosticsError("failed to decode resource block", "resource_decode", path, bodyDiags)
+		}
+		resource.DeclRange = bodyRange(resource.Body)
+
+		var expandDiags hcl.Diagnostics
+		resource.Instances, expandDiags = expandResource(resource, ctx)
+		if expandDiags.HasErrors() {
+			return nil, diagnosticsError("failed to expand resource instances", "resource_expand", path, expandDiags)
+		}
+
+		if previous, ok := declared[resource.Address()]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate resource",
+				Detail: fmt.Sprintf("A %s resource named %q was already declared at %s. Resource names must be unique per type in each module.",
+					resource.Type, resource.Name, previous.DeclRange.String()),
+				Subject: &resource.DeclRange,
+			})
+			continue
+		}
+		declared[resource.Address()] = resource
+	}
+	if diags.HasErrors() {
+		return nil, diagnosticsError("duplicate resource addresses in HCL config", "resource_decode", path, diags)
+	}
+	return &config, nil
+}
+
+// decodeBody walks a resource or nested block body and returns its attributes, evaluated
+// with ctx, and nested blocks. Native syntax bodies are walked directly; other bodies
+// (JSON) only expose their attributes.
+func decodeBody(body hcl.Body, ctx *hcl.EvalContext) (map[string]*models.Attribute, []*models.Block, hcl.Diagnostics) {
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		hclAttrs, diags := body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, nil, diags
+		}
+		attrs := make(map[string]*models.Attribute, len(hclAttrs))
+		for name, attr := range hclAttrs {
+			attrs[name] = newAttribute(name, attr.Expr, attr.Range, ctx)
+		}
+		return attrs, nil, diags
+	}
+
+	attrs := make(map[string]*models.Attribute, len(syntaxBody.Attributes))
+	for name, attr := range syntaxBody.Attributes {
+		attrs[name] = newAttribute(name, attr.Expr, attr.SrcRange, ctx)
+	}
+
+	var diags hcl.Diagnostics
+	blocks := make([]*models.Block, 0, len(syntaxBody.Blocks))
+	for _, block := range syntaxBody.Blocks {
+		blockAttrs, nested, blockDiags := decodeBody(block.Body, ctx)
+		diags = append(diags, blockDiags...)
+		blocks = append(blocks, &models.Block{
+			Type:       block.Type,
+			Labels:     block.Labels,
+			Attributes: blockAttrs,
+			Blocks:     nested,
+			Range:      block.Range(),
+		})
+	}
+	return attrs, blocks, diags
+}
+
+// newAttribute evaluates an attribute expression. Expressions that can't be resolved
+// (references to other resources, missing variables, ...) get an unknown value.
+func newAttribute(name string, expr hcl.Expression, rng hcl.Range, ctx *hcl.EvalContext) *models.Attribute {
+	return &models.Attribute{
+		Name:  name,
+		Expr:  expr,
+		Value: evaluate(expr, ctx),
+		Range: rng,
+	}
+}
+
+// bodyRange returns the source range of a body when the syntax exposes one
+func bodyRange(body hcl.Body) hcl.Range {
+	if syntaxBody, ok := body.(*hclsyntax.Body); ok {
+		return syntaxBody.SrcRange
+	}
+	return body.MissingItemRange()
+}
+
+// formatDiagnostics renders every diagnostic with its file:line range
+func formatDiagnostics(diags hcl.Diagnostics) []string {
+	result := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		msg := diag.Summary
+		if diag.Detail != "" {
+			msg = fmt.Sprintf("%s; %s", diag.Summary, diag.Detail)
+		}
+		if diag.Subject != nil {
+			msg = fmt.Sprintf("%s: %s", diag.Subject.String(), msg)
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// diagnosticsError wraps HCL diagnostics in a Terraform config error
+func diagnosticsError(message, operation, path string, diags hcl.Diagnostics) error {
+	return errors.New(errors.ErrTerraformConfig, message,
+		map[string]interface{}{
+			"operation":   operation,
+			"file_path":   path,
+			"diagnostics": formatDiagnostics(diags),
+		}, diags)
+}
@@ -0,0 +1,274 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ValueKind is the type of a node in a state attribute tree
+type ValueKind int
+
+const (
+	KindNull ValueKind = iota
+	KindString
+	KindNumber
+	KindBool
+	KindList
+	KindMap
+)
+
+// String returns the name of the kind
+func (k ValueKind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindString:
+		return "string"
+	case KindNumber:
+		return "number"
+	case KindBool:
+		return "bool"
+	case KindList:
+		return "list"
+	case KindMap:
+		return "map"
+	default:
+		return fmt.Sprintf("ValueKind(%d)", int(k))
+	}
+}
+
+// AttributeValue is a node in the attribute tree of a state instance. Every attribute
+// Terraform recorded is kept, including explicit nulls, so any attribute can be read
+// without a dedicated struct field. Numbers keep their JSON text to avoid float
+// rounding of large values.
+type AttributeValue struct {
+	Kind   ValueKind
+	String string
+	Number json.Number
+	Bool   bool
+	List   []*AttributeValue
+	Map    map[string]*AttributeValue
+}
+
+// UnmarshalJSON decodes any JSON value into the tree
+func (v *AttributeValue) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	*v = *NewAttributeValue(raw)
+	return nil
+}
+
+// MarshalJSON encodes the tree back to the JSON it was decoded from
+func (v *AttributeValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Interface())
+}
+
+// NewAttributeValue builds a tree from a decoded JSON value (nil, string, json.Number,
+// float64, bool, []interface{} or map[string]interface{})
+func NewAttributeValue(raw interface{}) *AttributeValue {
+	switch r := raw.(type) {
+	case nil:
+		return &AttributeValue{Kind: KindNull}
+	case string:
+		return &AttributeValue{Kind: KindString, String: r}
+	case json.Number:
+		return &AttributeValue{Kind: KindNumber, Number: r}
+	case float64:
+		return &AttributeValue{Kind: KindNumber, Number: json.Number(strconv.FormatFloat(r, 'f', -1, 64))}
+	case bool:
+		return &AttributeValue{Kind: KindBool, Bool: r}
+	case []interface{}:
+		list := make([]*AttributeValue, len(r))
+		for i, elem := range r {
+			list[i] = NewAttributeValue(elem)
+		}
+		return &AttributeValue{Kind: KindList, List: list}
+	case map[string]interface{}:
+		m := make(map[string]*AttributeValue, len(r))
+		for key, elem := range r {
+			m[key] = NewAttributeValue(elem)
+		}
+		return &AttributeValue{Kind: KindMap, Map: m}
+	default:
+		return &AttributeValue{Kind: KindString, String: fmt.Sprint(r)}
+	}
+}
+
+// Interface converts the tree back into plain Go values
+func (v *AttributeValue) Interface() interface{} {
+	if v == nil {
+		return nil
+	}
+	switch v.Kind {
+	case KindString:
+		return v.String
+	case KindNumber:
+		return v.Number
+	case KindBool:
+		return v.Bool
+	case KindList:
+		list := make([]interface{}, len(v.List))
+		for i, elem := range v.List {
+			list[i] = elem.Interface()
+		}
+		return list
+	case KindMap:
+		m := make(map[string]interface{}, len(v.Map))
+		for key, elem := range v.Map {
+			m[key] = elem.Interface()
+		}
+		return m
+	default:
+		return nil
+	}
+}
+
+// IsNull reports whether the value is absent or an explicit null
+func (v *AttributeValue) IsNull() bool {
+	return v == nil || v.Kind == KindNull
+}
+
+// Get walks the tree along path, where list elements are addressed by their index,
+// e.g. Get("root_block_device", "0", "volume_size"). It returns nil when any step of
+// the path doesn't exist.
+func (v *AttributeValue) Get(path ...string) *AttributeValue {
+	current := v
+	for _, step := range path {
+		if current == nil {
+			return nil
+		}
+		switch current.Kind {
+		case KindMap:
+			current = current.Map[step]
+		case KindList:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(current.List) {
+				return nil
+			}
+			current = current.List[i]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// Primitive returns a primitive value as a string, the way it would be written in
+// HCL. ok is false for nulls, lists and maps.
+func (v *AttributeValue) Primitive() (s string, ok bool) {
+	if v == nil {
+		return "", false
+	}
+	switch v.Kind {
+	case KindString:
+		return v.String, true
+	case KindNumber:
+		return v.Number.String(), true
+	case KindBool:
+		return strconv.FormatBool(v.Bool), true
+	default:
+		return "", false
+	}
+}
+
+// Equal reports whether two trees hold the same value. Numbers are compared by value
+// so that 8 and 8.0 are equal.
+func (v *AttributeValue) Equal(other *AttributeValue) bool {
+	if v.IsNull() || other.IsNull() {
+		return v.IsNull() && other.IsNull()
+	}
+	if v.Kind != other.Kind {
+		return false
+	}
+	switch v.Kind {
+	case KindString:
+		return v.String == other.String
+	case KindNumber:
+		a, errA := v.Number.Float64()
+		b, errB := other.Number.Float64()
+		if errA != nil || errB != nil {
+			return v.Number == other.Number
+		}
+		return a == b
+	case KindBool:
+		return v.Bool == other.Bool
+	case KindList:
+		if len(v.List) != len(other.List) {
+			return false
+		}
+		for i := range v.List {
+			if !v.List[i].Equal(other.List[i]) {
+				return false
+			}
+		}
+		return true
+	case KindMap:
+		if len(v.Map) != len(other.Map) {
+			return false
+		}
+		for key, elem := range v.Map {
+			if !elem.Equal(other.Map[key]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Flatten returns every primitive leaf of the tree keyed by its dotted path, e.g.
+// "root_block_device.0.volume_size" or "tags.Name". Nulls and empty collections
+// are left out.
+func (v *AttributeValue) Flatten() map[string]string {
+	result := make(map[string]string)
+	v.flatten("", result)
+	return result
+}
+
+func (v *AttributeValue) flatten(prefix string, result map[string]string) {
+	if v == nil {
+		return
+	}
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v.Kind {
+	case KindList:
+		for i, elem := range v.List {
+			elem.flatten(join(strconv.Itoa(i)), result)
+		}
+	case KindMap:
+		for key, elem := range v.Map {
+			elem.flatten(join(key), result)
+		}
+	default:
+		if s, ok := v.Primitive(); ok {
+			result[prefix] = s
+		}
+	}
+}
+
+// Keys returns the keys of a map value in sorted order
+func (v *AttributeValue) Keys() []string {
+	if v == nil || v.Kind != KindMap {
+		return nil
+	}
+	keys := make([]string, 0, len(v.Map))
+	for key := range v.Map {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
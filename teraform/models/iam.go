@@ -0,0 +1,52 @@
+package models
+
+// IAMRoleAttributes is the state view of an aws_iam_role. InlinePolicies and
+// ManagedPolicyArns hold every inline and attached policy of the role after a refresh,
+// including the ones managed by aws_iam_role_policy and
+// aws_iam_role_policy_attachment resources.
+type IAMRoleAttributes struct {
+	ID                 string            `json:"id"`
+	ARN                string            `json:"arn"`
+	Name               string            `json:"name"`
+	Path               string            `json:"path"`
+	Description        string            `json:"description"`
+	AssumeRolePolicy   string            `json:"assume_role_policy"`
+	MaxSessionDuration int               `json:"max_session_duration"`
+	InlinePolicies     []IAMInlinePolicy `json:"inline_policy"`
+	ManagedPolicyArns  []string          `json:"managed_policy_arns"`
+	Tags               map[string]string `json:"tags"`
+}
+
+// IAMInlinePolicy is an inline_policy block of an aws_iam_role
+type IAMInlinePolicy struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+// IAMPolicyAttributes is the state view of an aws_iam_policy. Its ID is the ARN.
+type IAMPolicyAttributes struct {
+	ID          string            `json:"id"`
+	ARN         string            `json:"arn"`
+	Name        string            `json:"name"`
+	Path        string            `json:"path"`
+	Description string            `json:"description"`
+	Policy      string            `json:"policy"`
+	Tags        map[string]string `json:"tags"`
+}
+
+// IAMRolePolicyAttributes is the state view of an aws_iam_role_policy. Its ID is
+// role:name.
+type IAMRolePolicyAttributes struct {
+	ID     string `json:"id"`
+	Role   string `json:"role"`
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+// IAMRolePolicyAttachmentAttributes is the state view of an
+// aws_iam_role_policy_attachment
+type IAMRolePolicyAttachmentAttributes struct {
+	ID        string `json:"id"`
+	Role      string `json:"role"`
+	PolicyARN string `json:"policy_arn"`
+}
@@ -1,6 +1,15 @@
 package models
 
-import "github.com/hashicorp/hcl/v2"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
 
 // Root structure of the Terraform state file
 type TerraformState struct {
@@ -12,25 +21,128 @@ type TerraformState struct {
 	Resources        []Resource             `json:"resources"`
 }
 
-// Resource represents a single resource in the Terraform state file
+// Resource modes recorded in the Terraform state
+const (
+	ModeManaged = "managed"
+	ModeData    = "data"
+)
+
+// Resource represents a single resource in the Terraform state file. Module is the
+// path of the child module declaring it (e.g. module.app or module.app.module.db),
+// empty for the root module. EachMode is "list" for count and "map" for for_each in
+// states written by Terraform 0.12; newer states only record the instance index_key.
 type Resource struct {
+	Module    string     `json:"module,omitempty"`
 	Mode      string     `json:"mode"`
 	Type      string     `json:"type"`
 	Name      string     `json:"name"`
+	EachMode  string     `json:"each,omitempty"`
 	Provider  string     `json:"provider"`
 	Instances []Instance `json:"instances"`
 }
 
-// Instance represents a specific instance of a resource
+// IsManaged reports whether the resource is managed by Terraform rather than read by
+// a data source. Resources without a mode count as managed.
+func (r *Resource) IsManaged() bool {
+	return r.Mode != ModeData
+}
+
+// Address returns the full Terraform address of the resource, e.g. aws_instance.web,
+// data.aws_ami.ubuntu or module.app.aws_instance.web
+func (r *Resource) Address() string {
+	address := r.Type + "." + r.Name
+	if r.Mode == ModeData {
+		address = "data." + address
+	}
+	if r.Module != "" {
+		address = r.Module + "." + address
+	}
+	return address
+}
+
+// InstanceAddress returns the full Terraform address of one of the resource's instances,
+// e.g. aws_instance.web[0] or module.app.aws_instance.web["blue"]
+func (r *Resource) InstanceAddress(inst *Instance) string {
+	return r.Address() + FormatIndexKey(inst.IndexKey)
+}
+
+// FormatIndexKey renders a count or for_each key as an address suffix
+func FormatIndexKey(key interface{}) string {
+	switch k := key.(type) {
+	case nil:
+		return ""
+	case string:
+		return "[" + strconv.Quote(k) + "]"
+	case float64:
+		return "[" + strconv.FormatFloat(k, 'f', -1, 64) + "]"
+	case int:
+		return "[" + strconv.Itoa(k) + "]"
+	default:
+		return fmt.Sprintf("[%v]", k)
+	}
+}
+
+// Instance represents a specific instance of a resource. The state's attributes
+// are decoded twice: into AttributeTree, which keeps every attribute, and into the
+// InstanceAttributes convenience view of the common aws_instance fields.
 type Instance struct {
+	IndexKey            interface{}        `json:"index_key,omitempty"`
 	SchemaVersion       int                `json:"schema_version"`
 	Attributes          InstanceAttributes `json:"attributes"`
+	AttributeTree       *AttributeValue    `json:"-"`
 	SensitiveAttributes []interface{}      `json:"sensitive_attributes"`
 	Private             string             `json:"private"`
 	Dependencies        []string           `json:"dependencies"`
 }
 
-// InstanceAttributes contains all the instance's attributes (like ami, instance_type, etc.)
+// UnmarshalJSON decodes a state instance, filling both the attribute tree and the
+// InstanceAttributes view. The view only fits aws_instance: attributes of other
+// resources that don't match it, such as tags stored as a list, are left out of the
+// view and only found in the tree.
+func (i *Instance) UnmarshalJSON(data []byte) error {
+	type plain Instance
+	var decoded struct {
+		plain
+		RawAttributes json.RawMessage `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*i = Instance(decoded.plain)
+
+	if len(decoded.RawAttributes) == 0 {
+		return nil
+	}
+	var tree AttributeValue
+	if err := json.Unmarshal(decoded.RawAttributes, &tree); err != nil {
+		return err
+	}
+	i.AttributeTree = &tree
+	// A mismatching attribute is skipped; the rest of the view is still filled
+	_ = json.Unmarshal(decoded.RawAttributes, &i.Attributes)
+	return nil
+}
+
+// Attribute returns the state attribute at path (see AttributeValue.Get), or nil when
+// it isn't recorded. Instances built without a tree fall back to the fields of the
+// InstanceAttributes view.
+func (i *Instance) Attribute(path ...string) *AttributeValue {
+	tree := i.AttributeTree
+	if tree == nil {
+		data, err := json.Marshal(i.Attributes)
+		if err != nil {
+			return nil
+		}
+		tree = &AttributeValue{}
+		if err := tree.UnmarshalJSON(data); err != nil {
+			return nil
+		}
+	}
+	return tree.Get(path...)
+}
+
+// InstanceAttributes is a convenience view of the common aws_instance attributes
+// (ami, instance_type, etc.); the full set is in Instance.AttributeTree
 type InstanceAttributes struct {
 	AMI                       string            `json:"ami"`
 	ARN                       string            `json:"arn"`
@@ -42,6 +154,7 @@ type InstanceAttributes struct {
 	PublicIP                  string            `json:"public_ip"`
 	KeyName                   string            `json:"key_name"`
 	RootBlockDevice           []RootBlockDevice `json:"root_block_device"`
+	EbsBlockDevice            []EbsBlockDevice  `json:"ebs_block_device"`
 	SecurityGroups            []string          `json:"security_groups"`
 	Tags                      map[string]string `json:"tags"`
 	VpcSecurityGroupIDs       []string          `json:"vpc_security_group_ids"`
@@ -55,6 +168,23 @@ type RootBlockDevice struct {
 	DeleteOnTermination bool   `json:"delete_on_termination"`
 	DeviceName          string `json:"device_name"`
 	Encrypted           bool   `json:"encrypted"`
+	Iops                int    `json:"iops"`
+	KmsKeyID            string `json:"kms_key_id"`
+	Throughput          int    `json:"throughput"`
+	VolumeID            string `json:"volume_id"`
+	VolumeSize          int    `json:"volume_size"`
+	VolumeType          string `json:"volume_type"`
+}
+
+// EbsBlockDevice represents an additional EBS volume declared on an EC2 instance
+type EbsBlockDevice struct {
+	DeleteOnTermination bool   `json:"delete_on_termination"`
+	DeviceName          string `json:"device_name"`
+	Encrypted           bool   `json:"encrypted"`
+	Iops                int    `json:"iops"`
+	KmsKeyID            string `json:"kms_key_id"`
+	SnapshotID          string `json:"snapshot_id"`
+	Throughput          int    `json:"throughput"`
 	VolumeID            string `json:"volume_id"`
 	VolumeSize          int    `json:"volume_size"`
 	VolumeType          string `json:"volume_type"`
@@ -74,24 +204,187 @@ type AWSInstanceBlock struct {
 }
 
 type OutputBlock struct {
-	Name  string         `hcl:"name,label"`
-	Value hcl.Expression `hcl:"value,attr"`
+	Name   string         `hcl:"name,label"`
+	Value  hcl.Expression `hcl:"value,attr"`
+	Remain hcl.Body       `hcl:",remain"`
 }
 
+// ResourceBlock is a resource block of the HCL configuration. Type, Name and Body are
+// decoded by gohcl; the remaining fields are filled in by the parser from Body.
+// Attributes and Blocks hold the block as declared, with count.index and each.*
+// unknown; Instances holds it expanded per count index or for_each key.
 type ResourceBlock struct {
-	Type string `hcl:"type,label"`
-	Name string `hcl:"name,label"`
-	Body hcl.Body
+	Type string   `hcl:"type,label"`
+	Name string   `hcl:"name,label"`
+	Body hcl.Body `hcl:",remain"`
+
+	Attributes map[string]*Attribute
+	Blocks     []*Block
+	Instances  []*ResourceInstance
+	DeclRange  hcl.Range
+}
+
+// ResourceInstance is one instance of a resource block after count and for_each
+// expansion. IndexKey is nil, an int (count) or a string (for_each).
+type ResourceInstance struct {
+	Address    string
+	IndexKey   interface{}
+	Attributes map[string]*Attribute
+	Blocks     []*Block
+}
+
+// Attribute is a single argument of a resource or nested block
+type Attribute struct {
+	Name  string
+	Expr  hcl.Expression
+	Value cty.Value
+	Range hcl.Range
+}
+
+// Block is a block nested inside a resource, e.g. root_block_device
+type Block struct {
+	Type       string
+	Labels     []string
+	Attributes map[string]*Attribute
+	Blocks     []*Block
+	Range      hcl.Range
+}
+
+// VariableBlock is an input variable declaration
+type VariableBlock struct {
+	Name string   `hcl:"name,label"`
+	Body hcl.Body `hcl:",remain"`
+}
+
+// LocalsBlock is a locals block; each of its attributes is a local value
+type LocalsBlock struct {
+	Body hcl.Body `hcl:",remain"`
 }
 
+// Config is the root of a parsed HCL configuration. Blocks the drift checker
+// doesn't use (provider, terraform, ...) are left in Remain.
 type Config struct {
 	Resources []ResourceBlock `hcl:"resource,block"`
 	Outputs   []OutputBlock   `hcl:"output,block"`
+	Variables []VariableBlock `hcl:"variable,block"`
+	Locals    []LocalsBlock   `hcl:"locals,block"`
+	Remain    hcl.Body        `hcl:",remain"`
+
+	// Resolved input variable and local values used to evaluate the resources
+	VariableValues map[string]cty.Value
+	LocalValues    map[string]cty.Value
+}
+
+// FindResource returns the resource block with the given type and name, or nil
+func (c *Config) FindResource(resourceType, name string) *ResourceBlock {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Resources {
+		if c.Resources[i].Type == resourceType && c.Resources[i].Name == name {
+			return &c.Resources[i]
+		}
+	}
+	return nil
+}
+
+// Address returns the Terraform address of the resource block, e.g. aws_instance.web
+func (r *ResourceBlock) Address() string {
+	return r.Type + "." + r.Name
+}
+
+// FindInstance returns the expanded instance with the given index key, or nil. The key
+// may come from the state, where count indexes are decoded as float64.
+func (r *ResourceBlock) FindInstance(key interface{}) *ResourceInstance {
+	want := FormatIndexKey(key)
+	for _, instance := range r.Instances {
+		if FormatIndexKey(instance.IndexKey) == want {
+			return instance
+		}
+	}
+	return nil
+}
+
+// TFInstance returns the aws_instance view of the resource block as declared
+func (r *ResourceBlock) TFInstance() *TFInstance {
+	return newTFInstance(r.Address(), r.Attributes)
+}
+
+// TFInstance returns the aws_instance view of the expanded resource instance
+func (i *ResourceInstance) TFInstance() *TFInstance {
+	return newTFInstance(i.Address, i.Attributes)
+}
+
+// newTFInstance builds the aws_instance view of a set of attributes. Attributes that
+// couldn't be resolved are recorded in Unknown and left empty.
+func newTFInstance(address string, attrs map[string]*Attribute) *TFInstance {
+	instance := &TFInstance{
+		Address: address,
+		Tags:    make(map[string]string),
+		Unknown: make(map[string]bool),
+	}
+	instance.AMI = instance.stringAttribute(attrs, "ami")
+	instance.InstanceType = instance.stringAttribute(attrs, "instance_type")
+
+	if attr, ok := attrs["tags"]; ok {
+		switch {
+		case !attr.Value.IsKnown():
+			instance.Unknown["tags"] = true
+		case attr.Value.IsNull():
+		case attr.Value.Type().IsMapType() || attr.Value.Type().IsObjectType():
+			for it := attr.Value.ElementIterator(); it.Next(); {
+				key, val := it.Element()
+				if s, ok := StringValue(val); ok {
+					instance.Tags[key.AsString()] = s
+				} else if !val.IsWhollyKnown() {
+					instance.Unknown["tags."+key.AsString()] = true
+				}
+			}
+		}
+	}
+	return instance
+}
+
+// stringAttribute reads a string attribute, recording it in Unknown when unresolved
+func (t *TFInstance) stringAttribute(attrs map[string]*Attribute, name string) string {
+	attr, ok := attrs[name]
+	if !ok {
+		return ""
+	}
+	if !attr.Value.IsWhollyKnown() {
+		t.Unknown[name] = true
+		return ""
+	}
+	s, _ := StringValue(attr.Value)
+	return s
+}
+
+// StringValue returns v as a Go string if it is a known, non-null primitive
+func StringValue(v cty.Value) (string, bool) {
+	if v == cty.NilVal || !v.IsWhollyKnown() || v.IsNull() {
+		return "", false
+	}
+	v, err := convert.Convert(v, cty.String)
+	if err != nil {
+		return "", false
+	}
+	return v.AsString(), true
 }
 
 type TFInstance struct {
 	ID           string
+	Address      string
 	InstanceType string
 	AMI          string
 	Tags         map[string]string
+	// Unknown holds the attributes (tags as tags.<key>) whose value couldn't be resolved
+	Unknown map[string]bool
+}
+
+// IsUnknown reports whether the attribute couldn't be resolved from the config
+func (t *TFInstance) IsUnknown(name string) bool {
+	if t.Unknown[name] {
+		return true
+	}
+	return strings.HasPrefix(name, "tags.") && t.Unknown["tags"]
 }
@@ -0,0 +1,113 @@
+package models
+
+// VpcAttributes is the state view of an aws_vpc
+type VpcAttributes struct {
+	ID              string            `json:"id"`
+	ARN             string            `json:"arn"`
+	CidrBlock       string            `json:"cidr_block"`
+	Ipv6CidrBlock   string            `json:"ipv6_cidr_block"`
+	InstanceTenancy string            `json:"instance_tenancy"`
+	Tags            map[string]string `json:"tags"`
+}
+
+// SubnetAttributes is the state view of an aws_subnet
+type SubnetAttributes struct {
+	ID                  string            `json:"id"`
+	ARN                 string            `json:"arn"`
+	VpcID               string            `json:"vpc_id"`
+	CidrBlock           string            `json:"cidr_block"`
+	Ipv6CidrBlock       string            `json:"ipv6_cidr_block"`
+	AvailabilityZone    string            `json:"availability_zone"`
+	MapPublicIPOnLaunch bool              `json:"map_public_ip_on_launch"`
+	Tags                map[string]string `json:"tags"`
+}
+
+// RouteTableAttributes is the state view of an aws_route_table. Routes holds every
+// route of the table after a refresh, including the ones managed by aws_route
+// resources, but never the local route.
+type RouteTableAttributes struct {
+	ID     string            `json:"id"`
+	ARN    string            `json:"arn"`
+	VpcID  string            `json:"vpc_id"`
+	Routes []RouteBlock      `json:"route"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// RouteBlock is an inline route block of an aws_route_table. Exactly one destination
+// and one target are set.
+type RouteBlock struct {
+	CidrBlock               string `json:"cidr_block"`
+	Ipv6CidrBlock           string `json:"ipv6_cidr_block"`
+	DestinationPrefixListID string `json:"destination_prefix_list_id"`
+	GatewayID               string `json:"gateway_id"`
+	NatGatewayID            string `json:"nat_gateway_id"`
+	TransitGatewayID        string `json:"transit_gateway_id"`
+	VpcPeeringConnectionID  string `json:"vpc_peering_connection_id"`
+	EgressOnlyGatewayID     string `json:"egress_only_gateway_id"`
+	VpcEndpointID           string `json:"vpc_endpoint_id"`
+	CarrierGatewayID        string `json:"carrier_gateway_id"`
+	LocalGatewayID          string `json:"local_gateway_id"`
+	NetworkInterfaceID      string `json:"network_interface_id"`
+	CoreNetworkARN          string `json:"core_network_arn"`
+}
+
+// RouteAttributes is the state view of an aws_route
+type RouteAttributes struct {
+	ID                       string `json:"id"`
+	RouteTableID             string `json:"route_table_id"`
+	DestinationCidrBlock     string `json:"destination_cidr_block"`
+	DestinationIpv6CidrBlock string `json:"destination_ipv6_cidr_block"`
+	DestinationPrefixListID  string `json:"destination_prefix_list_id"`
+	GatewayID                string `json:"gateway_id"`
+	NatGatewayID             string `json:"nat_gateway_id"`
+	TransitGatewayID         string `json:"transit_gateway_id"`
+	VpcPeeringConnectionID   string `json:"vpc_peering_connection_id"`
+	EgressOnlyGatewayID      string `json:"egress_only_gateway_id"`
+	VpcEndpointID            string `json:"vpc_endpoint_id"`
+	CarrierGatewayID         string `json:"carrier_gateway_id"`
+	LocalGatewayID           string `json:"local_gateway_id"`
+	NetworkInterfaceID       string `json:"network_interface_id"`
+	CoreNetworkARN           string `json:"core_network_arn"`
+}
+
+// InternetGatewayAttributes is the state view of an aws_internet_gateway
+type InternetGatewayAttributes struct {
+	ID    string            `json:"id"`
+	ARN   string            `json:"arn"`
+	VpcID string            `json:"vpc_id"`
+	Tags  map[string]string `json:"tags"`
+}
+
+// Destination returns the CIDR block or prefix list the route matches
+func (r RouteBlock) Destination() string {
+	return firstNonEmpty(r.CidrBlock, r.Ipv6CidrBlock, r.DestinationPrefixListID)
+}
+
+// Target returns the ID of the resource the route sends traffic to
+func (r RouteBlock) Target() string {
+	return firstNonEmpty(r.GatewayID, r.NatGatewayID, r.TransitGatewayID, r.VpcPeeringConnectionID,
+		r.EgressOnlyGatewayID, r.VpcEndpointID, r.CarrierGatewayID, r.LocalGatewayID,
+		r.NetworkInterfaceID, r.CoreNetworkARN)
+}
+
+// Destination returns the CIDR block or prefix list the route matches
+func (r RouteAttributes) Destination() string {
+	return firstNonEmpty(r.DestinationCidrBlock, r.DestinationIpv6CidrBlock, r.DestinationPrefixListID)
+}
+
+// Target returns the ID of the resource the route sends traffic to
+func (r RouteAttributes) Target() string {
+	return firstNonEmpty(r.GatewayID, r.NatGatewayID, r.TransitGatewayID, r.VpcPeeringConnectionID,
+		r.EgressOnlyGatewayID, r.VpcEndpointID, r.CarrierGatewayID, r.LocalGatewayID,
+		r.NetworkInterfaceID, r.CoreNetworkARN)
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
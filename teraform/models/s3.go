@@ -0,0 +1,148 @@
+package models
+
+// S3BucketAttributes is the state view of an aws_s3_bucket. Since version 4 of the AWS
+// provider the versioning, encryption, lifecycle and policy arguments are deprecated
+// in favour of companion resources, and the state holds whatever the last refresh
+// read for them.
+type S3BucketAttributes struct {
+	ID                                string                            `json:"id"`
+	ARN                               string                            `json:"arn"`
+	Bucket                            string                            `json:"bucket"`
+	Versioning                        []S3BucketVersioningBlock         `json:"versioning"`
+	ServerSideEncryptionConfiguration []S3BucketEncryptionConfiguration `json:"server_side_encryption_configuration"`
+	LifecycleRules                    []S3BucketLifecycleRuleBlock      `json:"lifecycle_rule"`
+	Policy                            string                            `json:"policy"`
+	Tags                              map[string]string                 `json:"tags"`
+}
+
+// S3BucketVersioningBlock is the inline versioning block of an aws_s3_bucket
+type S3BucketVersioningBlock struct {
+	Enabled   bool `json:"enabled"`
+	MFADelete bool `json:"mfa_delete"`
+}
+
+// S3BucketEncryptionConfiguration is the inline server_side_encryption_configuration
+// block of an aws_s3_bucket
+type S3BucketEncryptionConfiguration struct {
+	Rules []S3BucketEncryptionRule `json:"rule"`
+}
+
+// S3BucketEncryptionRule is a default encryption rule, inline or in an
+// aws_s3_bucket_server_side_encryption_configuration
+type S3BucketEncryptionRule struct {
+	ApplyServerSideEncryptionByDefault []S3BucketEncryptionByDefault `json:"apply_server_side_encryption_by_default"`
+	BucketKeyEnabled                   bool                          `json:"bucket_key_enabled"`
+}
+
+// S3BucketEncryptionByDefault is the apply_server_side_encryption_by_default block of
+// an encryption rule
+type S3BucketEncryptionByDefault struct {
+	SSEAlgorithm   string `json:"sse_algorithm"`
+	KMSMasterKeyID string `json:"kms_master_key_id"`
+}
+
+// S3BucketLifecycleRuleBlock is an inline lifecycle_rule block of an aws_s3_bucket
+type S3BucketLifecycleRuleBlock struct {
+	ID                                 string                              `json:"id"`
+	Enabled                            bool                                `json:"enabled"`
+	Prefix                             string                              `json:"prefix"`
+	Expiration                         []S3BucketLifecycleExpiration       `json:"expiration"`
+	NoncurrentVersionExpiration        []S3BucketLifecycleNoncurrentExpiry `json:"noncurrent_version_expiration"`
+	Transitions                        []S3BucketLifecycleTransition       `json:"transition"`
+	AbortIncompleteMultipartUploadDays int                                 `json:"abort_incomplete_multipart_upload_days"`
+}
+
+// S3BucketLifecycleExpiration is the expiration block of a lifecycle rule
+type S3BucketLifecycleExpiration struct {
+	Days int `json:"days"`
+}
+
+// S3BucketLifecycleNoncurrentExpiry is the noncurrent_version_expiration block of a
+// lifecycle rule. The inline block calls the day count days, the companion resource
+// noncurrent_days.
+type S3BucketLifecycleNoncurrentExpiry struct {
+	Days           int `json:"days"`
+	NoncurrentDays int `json:"noncurrent_days"`
+}
+
+// S3BucketLifecycleTransition is a transition block of a lifecycle rule
+type S3BucketLifecycleTransition struct {
+	Days         int    `json:"days"`
+	StorageClass string `json:"storage_class"`
+}
+
+// S3BucketVersioningAttributes is the state view of an aws_s3_bucket_versioning.
+// Status is Enabled, Suspended or Disabled; MFADelete is Enabled or Disabled.
+type S3BucketVersioningAttributes struct {
+	ID                      string                            `json:"id"`
+	Bucket                  string                            `json:"bucket"`
+	VersioningConfiguration []S3BucketVersioningConfiguration `json:"versioning_configuration"`
+}
+
+// S3BucketVersioningConfiguration is the versioning_configuration block of an
+// aws_s3_bucket_versioning
+type S3BucketVersioningConfiguration struct {
+	Status    string `json:"status"`
+	MFADelete string `json:"mfa_delete"`
+}
+
+// S3BucketEncryptionAttributes is the state view of an
+// aws_s3_bucket_server_side_encryption_configuration
+type S3BucketEncryptionAttributes struct {
+	ID     string                   `json:"id"`
+	Bucket string                   `json:"bucket"`
+	Rules  []S3BucketEncryptionRule `json:"rule"`
+}
+
+// S3BucketPublicAccessBlockAttributes is the state view of an
+// aws_s3_bucket_public_access_block
+type S3BucketPublicAccessBlockAttributes struct {
+	ID                    string `json:"id"`
+	Bucket                string `json:"bucket"`
+	BlockPublicAcls       bool   `json:"block_public_acls"`
+	BlockPublicPolicy     bool   `json:"block_public_policy"`
+	IgnorePublicAcls      bool   `json:"ignore_public_acls"`
+	RestrictPublicBuckets bool   `json:"restrict_public_buckets"`
+}
+
+// S3BucketLifecycleAttributes is the state view of an
+// aws_s3_bucket_lifecycle_configuration
+type S3BucketLifecycleAttributes struct {
+	ID     string                       `json:"id"`
+	Bucket string                       `json:"bucket"`
+	Rules  []S3BucketLifecycleRuleState `json:"rule"`
+}
+
+// S3BucketLifecycleRuleState is a rule of an aws_s3_bucket_lifecycle_configuration.
+// Status is Enabled or Disabled. The rule's prefix is either the deprecated top-level
+// prefix or the prefix of its filter.
+type S3BucketLifecycleRuleState struct {
+	ID                             string                              `json:"id"`
+	Status                         string                              `json:"status"`
+	Prefix                         string                              `json:"prefix"`
+	Filter                         []S3BucketLifecycleFilter           `json:"filter"`
+	Expiration                     []S3BucketLifecycleExpiration       `json:"expiration"`
+	NoncurrentVersionExpiration    []S3BucketLifecycleNoncurrentExpiry `json:"noncurrent_version_expiration"`
+	Transitions                    []S3BucketLifecycleTransition       `json:"transition"`
+	AbortIncompleteMultipartUpload []S3BucketLifecycleAbortUpload      `json:"abort_incomplete_multipart_upload"`
+}
+
+// S3BucketLifecycleAbortUpload is the abort_incomplete_multipart_upload block of a
+// lifecycle rule
+type S3BucketLifecycleAbortUpload struct {
+	DaysAfterInitiation int `json:"days_after_initiation"`
+}
+
+// S3BucketLifecycleFilter is the filter block of a lifecycle rule, or the and block
+// combining several conditions inside it
+type S3BucketLifecycleFilter struct {
+	Prefix string                    `json:"prefix"`
+	And    []S3BucketLifecycleFilter `json:"and"`
+}
+
+// S3BucketPolicyAttributes is the state view of an aws_s3_bucket_policy
+type S3BucketPolicyAttributes struct {
+	ID     string `json:"id"`
+	Bucket string `json:"bucket"`
+	Policy string `json:"policy"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "encoding/json"
+
+// SecurityGroupAttributes is the state view of an aws_security_group. Ingress and
+// Egress hold every rule of the group after a refresh, including the ones managed by
+// separate rule resources.
+type SecurityGroupAttributes struct {
+	ID          string                   `json:"id"`
+	ARN         string                   `json:"arn"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	VpcID       string                   `json:"vpc_id"`
+	Ingress     []SecurityGroupRuleBlock `json:"ingress"`
+	Egress      []SecurityGroupRuleBlock `json:"egress"`
+	Tags        map[string]string        `json:"tags"`
+}
+
+// SecurityGroupRuleBlock is an inline ingress or egress block of an aws_security_group.
+// SecurityGroups holds the IDs of the referenced groups.
+type SecurityGroupRuleBlock struct {
+	CidrBlocks     []string `json:"cidr_blocks"`
+	Ipv6CidrBlocks []string `json:"ipv6_cidr_blocks"`
+	PrefixListIDs  []string `json:"prefix_list_ids"`
+	SecurityGroups []string `json:"security_groups"`
+	Self           bool     `json:"self"`
+	Protocol       string   `json:"protocol"`
+	FromPort       int      `json:"from_port"`
+	ToPort         int      `json:"to_port"`
+	Description    string   `json:"description"`
+}
+
+// SecurityGroupRuleAttributes is the state view of an aws_security_group_rule. Type
+// is ingress or egress.
+type SecurityGroupRuleAttributes struct {
+	ID                    string   `json:"id"`
+	Type                  string   `json:"type"`
+	SecurityGroupID       string   `json:"security_group_id"`
+	SourceSecurityGroupID string   `json:"source_security_group_id"`
+	CidrBlocks            []string `json:"cidr_blocks"`
+	Ipv6CidrBlocks        []string `json:"ipv6_cidr_blocks"`
+	PrefixListIDs         []string `json:"prefix_list_ids"`
+	Self                  bool     `json:"self"`
+	Protocol              string   `json:"protocol"`
+	FromPort              int      `json:"from_port"`
+	ToPort                int      `json:"to_port"`
+	Description           string   `json:"description"`
+}
+
+// VpcSecurityGroupRuleAttributes is the state view of an
+// aws_vpc_security_group_ingress_rule or aws_vpc_security_group_egress_rule. Each
+// has exactly one source; the ports are null for all protocols.
+type VpcSecurityGroupRuleAttributes struct {
+	ID                        string `json:"id"`
+	SecurityGroupRuleID       string `json:"security_group_rule_id"`
+	SecurityGroupID           string `json:"security_group_id"`
+	CidrIPv4                  string `json:"cidr_ipv4"`
+	CidrIPv6                  string `json:"cidr_ipv6"`
+	PrefixListID              string `json:"prefix_list_id"`
+	ReferencedSecurityGroupID string `json:"referenced_security_group_id"`
+	IPProtocol                string `json:"ip_protocol"`
+	FromPort                  int    `json:"from_port"`
+	ToPort                    int    `json:"to_port"`
+	Description               string `json:"description"`
+}
+
+// DecodeAttributes decodes the instance's state attributes into a typed view such as
+// SecurityGroupAttributes. Instances built without a tree decode the
+// InstanceAttributes view instead.
+func (i *Instance) DecodeAttributes(out interface{}) error {
+	var data []byte
+	var err error
+	if i.AttributeTree != nil {
+		data, err = json.Marshal(i.AttributeTree)
+	} else {
+		data, err = json.Marshal(i.Attributes)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
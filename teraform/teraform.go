@@ -3,12 +3,13 @@ package teraform
 import (
 	"Savannahtakehomeassi/errors"
 	"Savannahtakehomeassi/teraform/models"
-	"bufio"
+	"context"
 	"encoding/json"
 	"os"
-	"regexp"
-	"strings"
+	"path/filepath"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"go.uber.org/zap"
 )
 
@@ -31,13 +32,21 @@ func NewTerraformClient() *TerraformClient {
 }
 
 // ParseTerraformInstance parses the Terraform state file for an EC2 instance
-func (c *TerraformClient) ParseTerraformInstance(filePath string) (*models.TerraformState, error) {
+func (c *TerraformClient) ParseTerraformInstance(ctx context.Context, filePath string) (*models.TerraformState, error) {
 	logger := zap.L().With(
 		zap.String("package", packageName),
 		zap.String("function", "ParseTerraformInstance"),
 		zap.String("file_path", filePath),
 	)
 
+	if err := ctx.Err(); err != nil {
+		return nil, errors.New(errors.ErrTerraformState, "terraform state parse cancelled",
+			map[string]interface{}{
+				"operation": "state_parse",
+				"file_path": filePath,
+			}, err)
+	}
+
 	// Read the Terraform state file
 	file, err := os.ReadFile(filePath)
 	if err != nil {
@@ -63,95 +72,115 @@ func (c *TerraformClient) ParseTerraformInstance(filePath string) (*models.Terra
 	return &tfState, nil
 }
 
-// ParseHCLConfig parses the HCL configuration file
-func (c *TerraformClient) ParseHCLConfig(filename string) (*models.TFInstance, error) {
-	logger := zap.L().With(
-		zap.String("package", packageName),
-		zap.String("function", "ParseHCLConfig"),
-		zap.String("file_path", filename),
-	)
+// ParseHCLConfig parses the HCL configuration at path and returns every resource block
+// with its attributes and nested blocks. path may be a single .tf file or a module
+// directory, in which case every .tf and .tf.json file in it is loaded.
+func (c *TerraformClient) ParseHCLConfig(ctx context.Context, path string) (*models.Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.New(errors.ErrTerraformConfig, "HCL config parse cancelled",
+			map[string]interface{}{
+				"operation": "config_parse",
+				"file_path": path,
+			}, err)
+	}
 
-	file, err := os.Open(filename)
+	info, err := os.Stat(path)
 	if err != nil {
 		return nil, errors.New(errors.ErrTerraformConfig, "failed to open HCL config file",
 			map[string]interface{}{
 				"operation": "file_open",
-				"file_path": filename,
+				"file_path": path,
 			}, err)
 	}
-	defer file.Close()
-
-	var instance models.TFInstance
-	instance.Tags = make(map[string]string)
+	if info.IsDir() {
+		return c.ParseHCLModule(ctx, path)
+	}
 
-	scanner := bufio.NewScanner(file)
-	var insideResource, insideTags bool
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "ParseHCLConfig"),
+		zap.String("file_path", path),
+	)
 
-	reKV := regexp.MustCompile(`^\s*(\w+)\s*=\s*["']?([^"']+)["']?`)
+	parser := hclparse.NewParser()
+	file, diags := parseConfigFile(parser, path)
+	if diags.HasErrors() {
+		return nil, diagnosticsError("failed to parse HCL config file", "hcl_parse", path, diags)
+	}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+	config, err := decodeConfig([]*hcl.File{file}, path, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
 
-		// Skip comments and blank lines
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
-			continue
-		}
+	logger.Info("HCL config parsed successfully",
+		zap.String("operation", "config_parse"),
+		zap.Int("resource_count", len(config.Resources)),
+		zap.Int("output_count", len(config.Outputs)),
+	)
+	return config, nil
+}
 
-		// Remove inline comments
-		if idx := strings.Index(line, "#"); idx != -1 {
-			line = strings.TrimSpace(line[:idx])
-		}
+// ParseHCLModule loads every .tf and .tf.json file in dir and merges them into a
+// single module. Duplicate resource addresses across files are reported as errors.
+// Parsing stops between files once ctx is done.
+func (c *TerraformClient) ParseHCLModule(ctx context.Context, dir string) (*models.Config, error) {
+	logger := zap.L().With(
+		zap.String("package", packageName),
+		zap.String("function", "ParseHCLModule"),
+		zap.String("dir_path", dir),
+	)
 
-		if strings.HasPrefix(line, "resource") && strings.Contains(line, `"aws_instance"`) {
-			insideResource = true
-			continue
-		}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.New(errors.ErrTerraformConfig, "failed to read module directory",
+			map[string]interface{}{
+				"operation": "dir_read",
+				"dir_path":  dir,
+			}, err)
+	}
 
-		if insideResource && strings.HasPrefix(line, "tags") && strings.Contains(line, "{") {
-			insideTags = true
+	parser := hclparse.NewParser()
+	var files []*hcl.File
+	var diags hcl.Diagnostics
+	for _, entry := range entries {
+		if entry.IsDir() || !isConfigFile(entry.Name()) {
 			continue
 		}
-
-		if insideTags {
-			if strings.HasPrefix(line, "}") {
-				insideTags = false
-				continue
-			}
-			if match := reKV.FindStringSubmatch(line); len(match) == 3 {
-				instance.Tags[match[1]] = match[2]
-			}
-			continue
+		if err := ctx.Err(); err != nil {
+			return nil, errors.New(errors.ErrTerraformConfig, "HCL module parse cancelled",
+				map[string]interface{}{
+					"operation": "config_parse",
+					"dir_path":  dir,
+				}, err)
 		}
-
-		if insideResource {
-			if strings.HasPrefix(line, "}") {
-				insideResource = false
-				continue
-			}
-			if match := reKV.FindStringSubmatch(line); len(match) == 3 {
-				key, val := match[1], match[2]
-				switch key {
-				case "ami":
-					instance.AMI = val
-				case "instance_type":
-					instance.InstanceType = val
-				}
-			}
+		file, fileDiags := parseConfigFile(parser, filepath.Join(dir, entry.Name()))
+		diags = append(diags, fileDiags...)
+		if file != nil {
+			files = append(files, file)
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, errors.New(errors.ErrTerraformConfig, "failed to scan HCL config file",
+	if diags.HasErrors() {
+		return nil, diagnosticsError("failed to parse HCL module", "hcl_parse", dir, diags)
+	}
+	if len(files) == 0 {
+		return nil, errors.New(errors.ErrTerraformConfig, "no Terraform configuration files found",
 			map[string]interface{}{
-				"operation": "file_scan",
-				"file_path": filename,
-			}, err)
+				"operation": "dir_read",
+				"dir_path":  dir,
+			}, nil)
 	}
 
-	logger.Info("HCL config parsed successfully",
-		zap.String("operation", "config_parse"),
-		zap.String("ami", instance.AMI),
-		zap.String("instance_type", instance.InstanceType),
+	config, err := decodeConfig(files, dir, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("HCL module parsed successfully",
+		zap.String("operation", "module_parse"),
+		zap.Int("file_count", len(files)),
+		zap.Int("resource_count", len(config.Resources)),
+		zap.Int("output_count", len(config.Outputs)),
 	)
-	return &instance, nil
+	return config, nil
 }
@@ -1,6 +1,7 @@
 package teraform
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -84,7 +85,7 @@ func TestParseTerraformInstance(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			state, err := client.ParseTerraformInstance(tc.filePath)
+			state, err := client.ParseTerraformInstance(context.Background(), tc.filePath)
 			if tc.expectError {
 				require.Error(t, err)
 				assert.Nil(t, state)
@@ -97,6 +98,386 @@ func TestParseTerraformInstance(t *testing.T) {
 	}
 }
 
+func TestParseTerraformInstance_Addresses(t *testing.T) {
+	client := NewTerraformClient()
+
+	state := `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "instances": [{"schema_version": 0, "attributes": {"id": "ami-123"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "each": "list",
+      "instances": [
+        {"index_key": 0, "schema_version": 1, "attributes": {"id": "i-0"}},
+        {"index_key": 1, "schema_version": 1, "attributes": {"id": "i-1"}}
+      ]
+    },
+    {
+      "module": "module.app",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "each": "map",
+      "instances": [
+        {"index_key": "blue", "schema_version": 1, "attributes": {"id": "i-blue"}}
+      ]
+    },
+    {
+      "module": "module.app.module.db[0]",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "primary",
+      "instances": [{"schema_version": 1, "attributes": {"id": "i-db"}}]
+    }
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(state), 0644))
+
+	parsed, err := client.ParseTerraformInstance(context.Background(), path)
+	require.NoError(t, err)
+	require.Len(t, parsed.Resources, 4)
+
+	tests := []struct {
+		name      string
+		resource  models.Resource
+		managed   bool
+		eachMode  string
+		addresses []string
+	}{
+		{
+			name:      "Data source",
+			resource:  parsed.Resources[0],
+			managed:   false,
+			addresses: []string{"data.aws_ami.ubuntu"},
+		},
+		{
+			name:      "Root module with count",
+			resource:  parsed.Resources[1],
+			managed:   true,
+			eachMode:  "list",
+			addresses: []string{"aws_instance.web[0]", "aws_instance.web[1]"},
+		},
+		{
+			name:      "Child module with for_each",
+			resource:  parsed.Resources[2],
+			managed:   true,
+			eachMode:  "map",
+			addresses: []string{`module.app.aws_instance.web["blue"]`},
+		},
+		{
+			name:      "Nested module",
+			resource:  parsed.Resources[3],
+			managed:   true,
+			addresses: []string{"module.app.module.db[0].aws_instance.primary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.managed, tt.resource.IsManaged())
+			assert.Equal(t, tt.eachMode, tt.resource.EachMode)
+
+			addresses := make([]string, 0, len(tt.resource.Instances))
+			for i := range tt.resource.Instances {
+				addresses = append(addresses, tt.resource.InstanceAddress(&tt.resource.Instances[i]))
+			}
+			assert.Equal(t, tt.addresses, addresses)
+		})
+	}
+}
+
+func TestParseTerraformInstance_AttributeTree(t *testing.T) {
+	client := NewTerraformClient()
+
+	state := `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [
+        {
+          "schema_version": 1,
+          "attributes": {
+            "id": "i-0123456789abcdef0",
+            "ami": "ami-12345678",
+            "instance_type": "t3.micro",
+            "monitoring": false,
+            "iam_instance_profile": "",
+            "user_data": "4b6e2a9ac6e4a7a8e1f6c4b0d3f5e1a2c9b8d7e6",
+            "cpu_core_count": 1,
+            "placement_partition_number": null,
+            "tags": {"Name": "web"},
+            "credit_specification": [{"cpu_credits": "unlimited"}],
+            "metadata_options": [
+              {"http_endpoint": "enabled", "http_tokens": "required", "http_put_response_hop_limit": 1}
+            ],
+            "ebs_block_device": [
+              {"device_name": "/dev/sdf", "volume_size": 100, "volume_type": "gp3", "iops": 3000, "encrypted": true, "kms_key_id": null}
+            ],
+            "root_block_device": [
+              {"device_name": "/dev/xvda", "volume_size": 8, "volume_type": "gp2", "encrypted": false}
+            ]
+          }
+        }
+      ]
+    }
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(state), 0644))
+
+	parsed, err := client.ParseTerraformInstance(context.Background(), path)
+	require.NoError(t, err)
+	instance := parsed.Resources[0].Instances[0]
+
+	// The convenience view is still filled in
+	assert.Equal(t, "i-0123456789abcdef0", instance.Attributes.InstanceID)
+	assert.Equal(t, "t3.micro", instance.Attributes.InstanceType)
+	assert.Equal(t, 8, instance.Attributes.RootBlockDevice[0].VolumeSize)
+	require.NotNil(t, instance.AttributeTree)
+
+	tests := []struct {
+		name     string
+		path     []string
+		kind     models.ValueKind
+		expected string
+	}{
+		{name: "String", path: []string{"ami"}, kind: models.KindString, expected: "ami-12345678"},
+		{name: "Empty string", path: []string{"iam_instance_profile"}, kind: models.KindString, expected: ""},
+		{name: "Bool", path: []string{"monitoring"}, kind: models.KindBool, expected: "false"},
+		{name: "Number", path: []string{"cpu_core_count"}, kind: models.KindNumber, expected: "1"},
+		{name: "Map element", path: []string{"tags", "Name"}, kind: models.KindString, expected: "web"},
+		{name: "Nested block", path: []string{"metadata_options", "0", "http_tokens"}, kind: models.KindString, expected: "required"},
+		{name: "Nested number", path: []string{"ebs_block_device", "0", "iops"}, kind: models.KindNumber, expected: "3000"},
+		{name: "Credit specification", path: []string{"credit_specification", "0", "cpu_credits"}, kind: models.KindString, expected: "unlimited"},
+		{name: "User data hash", path: []string{"user_data"}, kind: models.KindString, expected: "4b6e2a9ac6e4a7a8e1f6c4b0d3f5e1a2c9b8d7e6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := instance.Attribute(tt.path...)
+			require.NotNil(t, value)
+			assert.Equal(t, tt.kind, value.Kind)
+			s, ok := value.Primitive()
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, s)
+		})
+	}
+
+	t.Run("Null tracking", func(t *testing.T) {
+		explicit := instance.Attribute("placement_partition_number")
+		require.NotNil(t, explicit, "explicit null is recorded")
+		assert.Equal(t, models.KindNull, explicit.Kind)
+		assert.True(t, explicit.IsNull())
+
+		assert.True(t, instance.Attribute("ebs_block_device", "0", "kms_key_id").IsNull())
+		assert.Nil(t, instance.Attribute("not_in_state"), "missing attribute is absent")
+		assert.Nil(t, instance.Attribute("ebs_block_device", "5", "iops"), "out of range index")
+		assert.Nil(t, instance.Attribute("ami", "nested"), "path through a primitive")
+	})
+
+	t.Run("Flatten", func(t *testing.T) {
+		flat := instance.Attribute("ebs_block_device").Flatten()
+		assert.Equal(t, map[string]string{
+			"0.device_name": "/dev/sdf",
+			"0.volume_size": "100",
+			"0.volume_type": "gp3",
+			"0.iops":        "3000",
+			"0.encrypted":   "true",
+		}, flat)
+	})
+
+	t.Run("Equal", func(t *testing.T) {
+		var a, b models.AttributeValue
+		require.NoError(t, json.Unmarshal([]byte(`{"size": 8, "tags": ["a", "b"], "x": null}`), &a))
+		require.NoError(t, json.Unmarshal([]byte(`{"size": 8.0, "tags": ["a", "b"], "x": null}`), &b))
+		assert.True(t, a.Equal(&b))
+
+		require.NoError(t, json.Unmarshal([]byte(`{"size": 8, "tags": ["b", "a"], "x": null}`), &b))
+		assert.False(t, a.Equal(&b))
+		assert.True(t, a.Get("x").Equal(nil), "null equals absent")
+	})
+
+	t.Run("View fallback", func(t *testing.T) {
+		built := models.Instance{Attributes: models.InstanceAttributes{InstanceType: "t2.micro"}}
+		s, ok := built.Attribute("instance_type").Primitive()
+		assert.True(t, ok)
+		assert.Equal(t, "t2.micro", s)
+	})
+}
+
+func TestParseTerraformInstance_MismatchingAttributes(t *testing.T) {
+	client := NewTerraformClient()
+
+	state := `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_autoscaling_group",
+      "name": "web",
+      "instances": [
+        {
+          "schema_version": 0,
+          "attributes": {
+            "id": "web-asg",
+            "tags": [{"key": "Name", "value": "web", "propagate_at_launch": true}]
+          }
+        }
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_launch_configuration",
+      "name": "web",
+      "instances": [
+        {
+          "schema_version": 0,
+          "attributes": {
+            "id": "web-lc",
+            "instance_type": "t3.micro",
+            "security_groups": [{"id": "sg-12345678"}]
+          }
+        }
+      ]
+    }
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(state), 0644))
+
+	parsed, err := client.ParseTerraformInstance(context.Background(), path)
+	require.NoError(t, err)
+	require.Len(t, parsed.Resources, 2)
+
+	asg := parsed.Resources[0].Instances[0]
+	assert.Equal(t, "web-asg", asg.Attributes.InstanceID)
+	assert.Nil(t, asg.Attributes.Tags)
+	name, ok := asg.Attribute("tags", "0", "value").Primitive()
+	assert.True(t, ok)
+	assert.Equal(t, "web", name)
+
+	lc := parsed.Resources[1].Instances[0]
+	assert.Equal(t, "t3.micro", lc.Attributes.InstanceType)
+	group, ok := lc.Attribute("security_groups", "0", "id").Primitive()
+	assert.True(t, ok)
+	assert.Equal(t, "sg-12345678", group)
+}
+
+func TestParseTerraformInstance_SecurityGroups(t *testing.T) {
+	client := NewTerraformClient()
+
+	state := `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_security_group",
+      "name": "web",
+      "instances": [
+        {
+          "attributes": {
+            "id": "sg-web",
+            "name": "web",
+            "vpc_id": "vpc-1",
+            "ingress": [
+              {"protocol": "tcp", "from_port": 443, "to_port": 443, "cidr_blocks": ["0.0.0.0/0"], "ipv6_cidr_blocks": [], "prefix_list_ids": [], "security_groups": [], "self": false, "description": "https"}
+            ],
+            "egress": []
+          }
+        }
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_security_group_rule",
+      "name": "ssh",
+      "instances": [
+        {
+          "attributes": {
+            "id": "sgrule-1",
+            "type": "ingress",
+            "security_group_id": "sg-web",
+            "source_security_group_id": "sg-bastion",
+            "cidr_blocks": null,
+            "protocol": "tcp",
+            "from_port": 22,
+            "to_port": 22
+          }
+        }
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_vpc_security_group_egress_rule",
+      "name": "all",
+      "instances": [
+        {
+          "attributes": {
+            "id": "sgr-1",
+            "security_group_id": "sg-web",
+            "cidr_ipv4": "0.0.0.0/0",
+            "ip_protocol": "-1",
+            "from_port": null,
+            "to_port": null
+          }
+        }
+      ]
+    }
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(state), 0644))
+
+	parsed, err := client.ParseTerraformInstance(context.Background(), path)
+	require.NoError(t, err)
+	require.Len(t, parsed.Resources, 3)
+
+	var group models.SecurityGroupAttributes
+	require.NoError(t, parsed.Resources[0].Instances[0].DecodeAttributes(&group))
+	assert.Equal(t, models.SecurityGroupAttributes{
+		ID:    "sg-web",
+		Name:  "web",
+		VpcID: "vpc-1",
+		Ingress: []models.SecurityGroupRuleBlock{
+			{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrBlocks: []string{"0.0.0.0/0"}, Ipv6CidrBlocks: []string{}, PrefixListIDs: []string{}, SecurityGroups: []string{}, Description: "https"},
+		},
+		Egress: []models.SecurityGroupRuleBlock{},
+	}, group)
+
+	var rule models.SecurityGroupRuleAttributes
+	require.NoError(t, parsed.Resources[1].Instances[0].DecodeAttributes(&rule))
+	assert.Equal(t, models.SecurityGroupRuleAttributes{
+		ID:                    "sgrule-1",
+		Type:                  "ingress",
+		SecurityGroupID:       "sg-web",
+		SourceSecurityGroupID: "sg-bastion",
+		Protocol:              "tcp",
+		FromPort:              22,
+		ToPort:                22,
+	}, rule)
+
+	var vpcRule models.VpcSecurityGroupRuleAttributes
+	require.NoError(t, parsed.Resources[2].Instances[0].DecodeAttributes(&vpcRule))
+	assert.Equal(t, models.VpcSecurityGroupRuleAttributes{
+		ID:              "sgr-1",
+		SecurityGroupID: "sg-web",
+		CidrIPv4:        "0.0.0.0/0",
+		IPProtocol:      "-1",
+	}, vpcRule)
+}
+
 func TestParseHCLConfig(t *testing.T) {
 	client := NewTerraformClient()
 
@@ -105,6 +486,8 @@ func TestParseHCLConfig(t *testing.T) {
 		content     string
 		expected    *models.TFInstance
 		expectError bool
+		errorMsg    string
+		verify      func(t *testing.T, config *models.Config)
 	}{
 		{
 			name: "Valid HCL with tags",
@@ -119,6 +502,7 @@ resource "aws_instance" "example" {
 }
 `,
 			expected: &models.TFInstance{
+				Address:      "aws_instance.example",
 				AMI:          "ami-123456",
 				InstanceType: "t2.micro",
 				Tags: map[string]string{
@@ -136,6 +520,7 @@ resource "aws_instance" "example" {
 }
 `,
 			expected: &models.TFInstance{
+				Address:      "aws_instance.example",
 				AMI:          "ami-789012",
 				InstanceType: "t3.medium",
 				Tags:         map[string]string{},
@@ -154,6 +539,7 @@ resource "aws_instance" "example" {
 }
 `,
 			expected: &models.TFInstance{
+				Address:      "aws_instance.example",
 				AMI:          "ami-irregular",
 				InstanceType: "t3.small",
 				Tags: map[string]string{
@@ -169,17 +555,115 @@ resource "aws_s3_bucket" "example" {
   bucket = "my-bucket"
 }
 `,
-			expected: &models.TFInstance{
-				Tags: map[string]string{},
+			verify: func(t *testing.T, config *models.Config) {
+				require.Len(t, config.Resources, 1)
+				assert.Equal(t, "aws_s3_bucket", config.Resources[0].Type)
+				assert.Nil(t, config.FindResource("aws_instance", "example"))
+				bucket, ok := models.StringValue(config.Resources[0].Attributes["bucket"].Value)
+				assert.True(t, ok)
+				assert.Equal(t, "my-bucket", bucket)
+			},
+		},
+		{
+			name: "Multiple instances, nested blocks, heredocs and multi-line lists",
+			content: `
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+  ami           = "ami-web"
+  instance_type = "t2.micro"
+  vpc_security_group_ids = [
+    "sg-1",
+    "sg-2",
+  ]
+  user_data = <<-EOT
+    #!/bin/bash
+    echo "{ not a block }"
+  EOT
+
+  root_block_device {
+    volume_size = 20
+    volume_type = "gp3"
+  }
+
+  ebs_block_device {
+    device_name = "/dev/sdb"
+    volume_size = 100
+  }
+
+  tags = {
+    Name = "web {braces}"
+  }
+}
+
+resource "aws_instance" "db" {
+  ami           = "ami-db"
+  instance_type = "r5.large"
+}
+
+output "web_id" {
+  value       = aws_instance.web.id
+  description = "web instance"
+}
+`,
+			verify: func(t *testing.T, config *models.Config) {
+				require.Len(t, config.Resources, 2)
+				require.Len(t, config.Outputs, 1)
+
+				web := config.FindResource("aws_instance", "web")
+				require.NotNil(t, web)
+				assert.Equal(t, "ami-web", web.TFInstance().AMI)
+				assert.Equal(t, "web {braces}", web.TFInstance().Tags["Name"])
+				assert.Equal(t, 2, web.Attributes["vpc_security_group_ids"].Value.LengthInt())
+				userData, ok := models.StringValue(web.Attributes["user_data"].Value)
+				assert.True(t, ok)
+				assert.Contains(t, userData, `echo "{ not a block }"`)
+
+				require.Len(t, web.Blocks, 2)
+				assert.Equal(t, "root_block_device", web.Blocks[0].Type)
+				size, ok := models.StringValue(web.Blocks[0].Attributes["volume_size"].Value)
+				assert.True(t, ok)
+				assert.Equal(t, "20", size)
+				assert.Equal(t, "ebs_block_device", web.Blocks[1].Type)
+				assert.Equal(t, 6, web.DeclRange.Start.Line)
+
+				db := config.FindResource("aws_instance", "db")
+				require.NotNil(t, db)
+				assert.Equal(t, "r5.large", db.TFInstance().InstanceType)
+			},
+		},
+		{
+			name: "Unresolved references are unknown",
+			content: `
+resource "aws_instance" "example" {
+  ami           = var.ami_id
+  instance_type = "t2.micro"
+}
+`,
+			verify: func(t *testing.T, config *models.Config) {
+				resource := config.FindResource("aws_instance", "example")
+				require.NotNil(t, resource)
+				assert.False(t, resource.Attributes["ami"].Value.IsKnown())
+				assert.Equal(t, "", resource.TFInstance().AMI)
+				assert.Equal(t, "t2.micro", resource.TFInstance().InstanceType)
 			},
 		},
 		{
 			name:        "Corrupted file format",
 			content:     `{{{{{`,
-			expectError: false, // Will parse nothing, but won't return an error since scanner just sees junk
-			expected: &models.TFInstance{
-				Tags: map[string]string{},
-			},
+			expectError: true,
+			errorMsg:    "main.tf:1,1",
+		},
+		{
+			name: "Unclosed resource block",
+			content: `
+resource "aws_instance" "example" {
+  ami = "ami-123"
+`,
+			expectError: true,
+			errorMsg:    "main.tf:2",
 		},
 	}
 
@@ -188,17 +672,170 @@ resource "aws_s3_bucket" "example" {
 			tmpFile := writeTempFile(t, tc.content)
 			defer os.Remove(tmpFile)
 
-			instance, err := client.ParseHCLConfig(tmpFile)
+			config, err := client.ParseHCLConfig(context.Background(), tmpFile)
 			if tc.expectError {
 				require.Error(t, err)
-				assert.Nil(t, instance)
-			} else {
-				require.NoError(t, err)
-				require.NotNil(t, instance)
+				assert.Nil(t, config)
+				if tc.errorMsg != "" {
+					assert.Contains(t, err.Error(), tc.errorMsg)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, config)
+			if tc.expected != nil {
+				resource := config.FindResource("aws_instance", "example")
+				require.NotNil(t, resource)
+				instance := resource.TFInstance()
+				assert.Equal(t, tc.expected.Address, instance.Address)
 				assert.Equal(t, tc.expected.AMI, instance.AMI)
 				assert.Equal(t, tc.expected.InstanceType, instance.InstanceType)
 				assert.Equal(t, tc.expected.Tags, instance.Tags)
 			}
+			if tc.verify != nil {
+				tc.verify(t, config)
+			}
+		})
+	}
+}
+
+func TestParseHCLConfig_MissingFile(t *testing.T) {
+	client := NewTerraformClient()
+	config, err := client.ParseHCLConfig(context.Background(), filepath.Join(t.TempDir(), "missing.tf"))
+	require.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestParseHCLModule(t *testing.T) {
+	client := NewTerraformClient()
+
+	tests := []struct {
+		name        string
+		files       map[string]string
+		expectError bool
+		errorMsg    string
+		verify      func(t *testing.T, config *models.Config)
+	}{
+		{
+			name: "Module split across files",
+			files: map[string]string{
+				"main.tf": `
+resource "aws_instance" "web" {
+  ami           = "ami-web"
+  instance_type = "t2.micro"
+}
+`,
+				"variables.tf": `
+variable "ami_id" {
+  default = "ami-123"
+}
+`,
+				"outputs.tf": `
+output "web_id" {
+  value = aws_instance.web.id
+}
+`,
+				"locals.tf": `
+locals {
+  env = "dev"
+}
+`,
+				"db.tf.json": `{
+  "resource": {
+    "aws_instance": {
+      "db": {
+        "ami": "ami-db",
+        "instance_type": "r5.large",
+        "tags": {"Name": "db"}
+      }
+    }
+  }
+}`,
+				"terraform.tfvars": `ami_id = "ami-override"`,
+				"README.md":        `not terraform`,
+			},
+			verify: func(t *testing.T, config *models.Config) {
+				require.Len(t, config.Resources, 2)
+				require.Len(t, config.Outputs, 1)
+
+				web := config.FindResource("aws_instance", "web")
+				require.NotNil(t, web)
+				assert.Equal(t, "t2.micro", web.TFInstance().InstanceType)
+
+				db := config.FindResource("aws_instance", "db")
+				require.NotNil(t, db)
+				assert.Equal(t, "ami-db", db.TFInstance().AMI)
+				assert.Equal(t, "r5.large", db.TFInstance().InstanceType)
+				assert.Equal(t, map[string]string{"Name": "db"}, db.TFInstance().Tags)
+			},
+		},
+		{
+			name: "Duplicate resource across files",
+			files: map[string]string{
+				"a.tf": `
+resource "aws_instance" "web" {
+  ami = "ami-a"
+}
+`,
+				"b.tf": `
+resource "aws_instance" "web" {
+  ami = "ami-b"
+}
+`,
+			},
+			expectError: true,
+			errorMsg:    "b.tf:2",
+		},
+		{
+			name: "Syntax error in one file",
+			files: map[string]string{
+				"main.tf": `
+resource "aws_instance" "web" {
+  ami = "ami-a"
+}
+`,
+				"broken.tf": `
+resource "aws_instance" "db" {
+  ami = 
+}
+`,
+			},
+			expectError: true,
+			errorMsg:    "broken.tf:3",
+		},
+		{
+			name: "No configuration files",
+			files: map[string]string{
+				"notes.txt": "nothing here",
+			},
+			expectError: true,
+			errorMsg:    "no Terraform configuration files found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+
+			// Both the explicit module API and ParseHCLConfig accept a directory
+			for _, parse := range []func(context.Context, string) (*models.Config, error){client.ParseHCLModule, client.ParseHCLConfig} {
+				config, err := parse(context.Background(), dir)
+				if tc.expectError {
+					require.Error(t, err)
+					assert.Nil(t, config)
+					if tc.errorMsg != "" {
+						assert.Contains(t, err.Error(), tc.errorMsg)
+					}
+					continue
+				}
+				require.NoError(t, err)
+				require.NotNil(t, config)
+				tc.verify(t, config)
+			}
 		})
 	}
 }